@@ -0,0 +1,52 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+
+    "primmfy_db/models"
+    "primmfy_db/services"
+)
+
+// ═══════════════════════════════════════════════════════════
+// CREATE-INVITE SUBCOMMAND
+// go run . create-invite admin [jam]   -> invite code untuk admin pertama
+// Registrasi publik hanya untuk student, jadi admin pertama dibuat lewat CLI
+// ═══════════════════════════════════════════════════════════
+
+// runCreateInviteCommand membuat invite code tanpa perlu login sebagai admin
+func runCreateInviteCommand(args []string) error {
+    if len(args) == 0 {
+        return errors.New("usage: create-invite teacher|admin [expires_in_hours]")
+    }
+
+    req := models.CreateInviteRequest{Role: args[0]}
+    if req.Role != models.RoleTeacher && req.Role != models.RoleAdmin {
+        return errors.New("role harus teacher atau admin")
+    }
+
+    if len(args) > 1 {
+        hours, err := strconv.Atoi(args[1])
+        if err != nil || hours < 1 {
+            return errors.New("expires_in_hours harus angka >= 1")
+        }
+        req.ExpiresInHours = hours
+    }
+
+    invite, err := services.CreateRoleInvite(context.Background(), DB, nil, req)
+    if err != nil {
+        return err
+    }
+
+    fmt.Printf("✅ Invite %s dibuat (berlaku sampai %s)\n", invite.Role, invite.ExpiresAt.Format("2006-01-02 15:04"))
+    fmt.Printf("   Code: %s\n", invite.Code)
+    return nil
+}
+
+// isCreateInviteCommand mengecek apakah binary dijalankan sebagai "create-invite ..."
+func isCreateInviteCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "create-invite"
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv" // 👈 Tambah ini untuk load .env
 )
 
-var DB *pgx.Conn
+// DB adalah connection pool yang dipakai bersama oleh semua handler & service
+// (aman dipakai concurrent, beda dengan satu *pgx.Conn)
+var DB *pgxpool.Pool
 
 // InitDB menginisialisasi koneksi ke database
 func InitDB() {
@@ -27,8 +31,18 @@ func InitDB() {
         fmt.Println("⚠️  Warning: DATABASE_URL tidak ditemukan di .env, menggunakan default")
     }
 
-    // 3. Connect ke database
-    DB, err = pgx.Connect(context.Background(), dbURL)
+    // 3. Buat connection pool (ukuran pool bisa diatur lewat environment)
+    config, err := pgxpool.ParseConfig(dbURL)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "❌ DATABASE_URL tidak valid: %v\n", err)
+        os.Exit(1)
+    }
+    applyPoolConfig(config)
+
+    // Kolom TIMESTAMP (tanpa timezone) selalu berisi waktu UTC, apa pun timezone server database
+    config.ConnConfig.RuntimeParams["timezone"] = "UTC"
+
+    DB, err = pgxpool.NewWithConfig(context.Background(), config)
     if err != nil {
         fmt.Fprintf(os.Stderr, "❌ Gagal terhubung ke database: %v\n", err)
         os.Exit(1)
@@ -41,13 +55,33 @@ func InitDB() {
         os.Exit(1)
     }
 
-    fmt.Println("✅ Berhasil terhubung ke database!")
+    fmt.Printf("✅ Berhasil terhubung ke database! (pool max %d koneksi)\n", config.MaxConns)
+}
+
+// applyPoolConfig meng-override ukuran & umur koneksi pool dari environment
+// DB_MAX_CONNS, DB_MIN_CONNS, DB_MAX_CONN_LIFETIME, DB_MAX_CONN_IDLE_TIME (contoh: 30m)
+func applyPoolConfig(config *pgxpool.Config) {
+    if n, err := strconv.Atoi(os.Getenv("DB_MAX_CONNS")); err == nil && n > 0 {
+        config.MaxConns = int32(n)
+    }
+    if n, err := strconv.Atoi(os.Getenv("DB_MIN_CONNS")); err == nil && n >= 0 {
+        config.MinConns = int32(n)
+    }
+    if config.MinConns > config.MaxConns {
+        config.MinConns = config.MaxConns
+    }
+    if d, err := time.ParseDuration(os.Getenv("DB_MAX_CONN_LIFETIME")); err == nil && d > 0 {
+        config.MaxConnLifetime = d
+    }
+    if d, err := time.ParseDuration(os.Getenv("DB_MAX_CONN_IDLE_TIME")); err == nil && d > 0 {
+        config.MaxConnIdleTime = d
+    }
 }
 
-// CloseDB menutup koneksi database
+// CloseDB menutup semua koneksi di pool
 func CloseDB() {
     if DB != nil {
-        DB.Close(context.Background())
+        DB.Close()
         fmt.Println("🔌 Koneksi database ditutup")
     }
 }
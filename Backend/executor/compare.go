@@ -0,0 +1,115 @@
+package executor
+
+import (
+    "fmt"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// OUTPUT COMPARATORS
+// ═══════════════════════════════════════════════════════════
+
+// defaultTolerance dipakai untuk comparison float jika Tolerance tidak di-set
+const defaultTolerance = 1e-6
+
+// compareOutput membandingkan output student dengan expected output
+// sesuai comparison mode test case
+func compareOutput(tc models.TestCase, actual string) bool {
+    switch tc.Comparison {
+    case models.ComparisonExact:
+        return actual == tc.ExpectedOutput
+
+    case models.ComparisonCaseInsensitive:
+        return strings.EqualFold(normalizeOutput(actual), normalizeOutput(tc.ExpectedOutput))
+
+    case models.ComparisonRegex:
+        re, err := compileExpected(tc.ExpectedOutput)
+        if err != nil {
+            return false
+        }
+        return re.MatchString(normalizeOutput(actual))
+
+    case models.ComparisonFloat:
+        tolerance := tc.Tolerance
+        if tolerance <= 0 {
+            tolerance = defaultTolerance
+        }
+        return floatTokensMatch(actual, tc.ExpectedOutput, tolerance)
+
+    default:
+        // "trim" atau kosong: abaikan trailing whitespace
+        return normalizeOutput(actual) == normalizeOutput(tc.ExpectedOutput)
+    }
+}
+
+// ValidateTestCases mengecek test case sebelum disimpan teacher
+// (misalnya regex yang tidak bisa di-compile)
+func ValidateTestCases(testCases []models.TestCase) error {
+    for i, tc := range testCases {
+        if tc.Comparison != models.ComparisonRegex {
+            continue
+        }
+        if _, err := compileExpected(tc.ExpectedOutput); err != nil {
+            return fmt.Errorf("regex test case %d tidak valid: %v", i+1, err)
+        }
+    }
+    return nil
+}
+
+// compileExpected meng-compile expected output sebagai regex full match
+func compileExpected(pattern string) (*regexp.Regexp, error) {
+    return regexp.Compile(`^(?:` + normalizeOutput(pattern) + `)$`)
+}
+
+// floatTokensMatch membandingkan output per token: angka dibandingkan
+// dengan toleransi, selain angka harus sama persis
+func floatTokensMatch(actual, expected string, tolerance float64) bool {
+    actualTokens := strings.Fields(actual)
+    expectedTokens := strings.Fields(expected)
+
+    if len(actualTokens) != len(expectedTokens) {
+        return false
+    }
+
+    for i := range expectedTokens {
+        want, errWant := strconv.ParseFloat(expectedTokens[i], 64)
+        got, errGot := strconv.ParseFloat(actualTokens[i], 64)
+
+        if errWant != nil || errGot != nil {
+            if actualTokens[i] != expectedTokens[i] {
+                return false
+            }
+            continue
+        }
+
+        // NaN / Inf dibandingkan persis (selisihnya NaN, jadi lolos cek tolerance)
+        if math.IsNaN(want) || math.IsNaN(got) || math.IsInf(want, 0) || math.IsInf(got, 0) {
+            if want != got && !(math.IsNaN(want) && math.IsNaN(got)) {
+                return false
+            }
+            continue
+        }
+
+        if math.Abs(want-got) > tolerance {
+            return false
+        }
+    }
+
+    return true
+}
+
+// normalizeOutput menghapus trailing whitespace di setiap baris & di akhir output
+// dan menyamakan line ending (\r\n vs \n)
+func normalizeOutput(s string) string {
+    s = strings.ReplaceAll(s, "\r\n", "\n")
+    lines := strings.Split(s, "\n")
+    for i, line := range lines {
+        lines[i] = strings.TrimRight(line, " \t")
+    }
+    return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
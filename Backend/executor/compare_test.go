@@ -0,0 +1,137 @@
+package executor
+
+import (
+    "strings"
+    "testing"
+
+    "primmfy_db/models"
+)
+
+func TestCompareOutput(t *testing.T) {
+    cases := []struct {
+        name       string
+        comparison string
+        tolerance  float64
+        expected   string
+        actual     string
+        want       bool
+    }{
+        // exact
+        {"exact sama", models.ComparisonExact, 0, "halo\n", "halo\n", true},
+        {"exact beda trailing newline", models.ComparisonExact, 0, "halo", "halo\n", false},
+        {"exact beda trailing spasi", models.ComparisonExact, 0, "halo", "halo ", false},
+
+        // trim (default)
+        {"trim trailing spasi & tab", models.ComparisonTrim, 0, "1 2 3\n4", "1 2 3 \t\n4\t", true},
+        {"trim trailing newline", models.ComparisonTrim, 0, "halo", "halo\n\n\n", true},
+        {"trim CRLF", models.ComparisonTrim, 0, "a\nb\n", "a\r\nb\r\n", true},
+        {"trim leading spasi tetap dibandingkan", models.ComparisonTrim, 0, "halo", " halo", false},
+        {"trim baris kosong di tengah tetap dibandingkan", models.ComparisonTrim, 0, "a\nb", "a\n\nb", false},
+        {"comparison kosong = trim", "", 0, "halo", "halo  \r\n", true},
+
+        // case insensitive
+        {"case insensitive", models.ComparisonCaseInsensitive, 0, "Hello World", "hello WORLD\n", true},
+        {"case insensitive beda isi", models.ComparisonCaseInsensitive, 0, "Hello", "Hallo", false},
+
+        // regex (full match setelah normalisasi)
+        {"regex match", models.ComparisonRegex, 0, `\d+`, "12345\n", true},
+        {"regex harus full match", models.ComparisonRegex, 0, `\d+`, "abc123", false},
+        {"regex alternation tetap di-anchor", models.ComparisonRegex, 0, `a|b`, "ab", false},
+        {"regex multi baris & CRLF", models.ComparisonRegex, 0, "Hasil: \\d+\nSelesai", "Hasil: 42\r\nSelesai \r\n", true},
+        {"regex tidak valid", models.ComparisonRegex, 0, `(`, "(", false},
+
+        // float
+        {"float default tolerance lolos", models.ComparisonFloat, 0, "1.0", "1.0000005", true},
+        {"float default tolerance gagal", models.ComparisonFloat, 0, "1.0", "1.00001", false},
+        {"float tepat di batas tolerance", models.ComparisonFloat, 0.25, "1.5", "1.75", true},
+        {"float melewati tolerance", models.ComparisonFloat, 0.25, "1.5", "1.8", false},
+        {"float tolerance negatif = default", models.ComparisonFloat, -1, "2", "2.0000001", true},
+        {"float integer vs desimal", models.ComparisonFloat, 0, "3", "3.000", true},
+        {"float notasi eksponen", models.ComparisonFloat, 0, "1500", "1.5e3", true},
+        {"float token teks harus sama", models.ComparisonFloat, 0, "x = 1.0", "x = 1", true},
+        {"float token teks beda", models.ComparisonFloat, 0, "x = 1.0", "y = 1.0", false},
+        {"float jumlah token beda", models.ComparisonFloat, 0, "1 2", "1 2 3", false},
+        {"float whitespace & CRLF diabaikan", models.ComparisonFloat, 0, "1.5\n2.5", "1.5   2.5\r\n", true},
+    }
+
+    for _, tc := range cases {
+        testCase := models.TestCase{ExpectedOutput: tc.expected, Comparison: tc.comparison, Tolerance: tc.tolerance}
+        if got := compareOutput(testCase, tc.actual); got != tc.want {
+            t.Errorf("%s: compareOutput(%q, %q) = %v, want %v", tc.name, tc.expected, tc.actual, got, tc.want)
+        }
+    }
+}
+
+func TestFloatTokensMatch(t *testing.T) {
+    cases := []struct {
+        actual    string
+        expected  string
+        tolerance float64
+        want      bool
+    }{
+        {"0.5", "0.5", 0, true},
+        {"0.75", "0.5", 0.25, true},
+        {"0.25", "0.5", 0.25, true},
+        {"0.2", "0.5", 0.25, false},
+        {"-1.5", "1.5", 1, false},
+        {"", "", 0.1, true},
+        {"", "1", 0.1, false},
+        {"abc", "1", 10, false},
+        {"NaN", "NaN", 1, true},
+        {"1", "NaN", 1e9, false},
+        {"NaN", "1", 1e9, false},
+        {"Inf", "+Inf", 0, true},
+        {"Inf", "-Inf", 0, false},
+        {"1e308", "Inf", 1e308, false},
+    }
+
+    for _, tc := range cases {
+        if got := floatTokensMatch(tc.actual, tc.expected, tc.tolerance); got != tc.want {
+            t.Errorf("floatTokensMatch(%q, %q, %v) = %v, want %v", tc.actual, tc.expected, tc.tolerance, got, tc.want)
+        }
+    }
+}
+
+func TestNormalizeOutput(t *testing.T) {
+    cases := []struct {
+        input string
+        want  string
+    }{
+        {"", ""},
+        {"halo", "halo"},
+        {"halo \t\n", "halo"},
+        {"a\r\nb\r\n", "a\nb"},
+        {"a  \nb\t\n\n\n", "a\nb"},
+        {"  indent", "  indent"},
+        {"a\n\nb", "a\n\nb"},
+        {"a\rb", "a\rb"},
+    }
+
+    for _, tc := range cases {
+        if got := normalizeOutput(tc.input); got != tc.want {
+            t.Errorf("normalizeOutput(%q) = %q, want %q", tc.input, got, tc.want)
+        }
+    }
+}
+
+func TestValidateTestCases(t *testing.T) {
+    valid := []models.TestCase{
+        {ExpectedOutput: `\d+`, Comparison: models.ComparisonRegex},
+        {ExpectedOutput: "(", Comparison: models.ComparisonTrim}, // bukan regex, tidak di-compile
+    }
+    if err := ValidateTestCases(valid); err != nil {
+        t.Fatalf("test case valid ditolak: %v", err)
+    }
+
+    invalid := []models.TestCase{
+        {ExpectedOutput: "ok", Comparison: models.ComparisonExact},
+        {ExpectedOutput: `[a-`, Comparison: models.ComparisonRegex},
+    }
+    err := ValidateTestCases(invalid)
+    if err == nil {
+        t.Fatal("regex tidak valid harus ditolak")
+    }
+    if !strings.HasPrefix(err.Error(), "regex test case 2 ") {
+        t.Fatalf("error harus menyebut test case 2, got %q", err)
+    }
+}
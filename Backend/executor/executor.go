@@ -0,0 +1,362 @@
+package executor
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ═══════════════════════════════════════════════════════════
+// SANDBOXED CODE EXECUTION
+// ═══════════════════════════════════════════════════════════
+
+// Limits adalah batas resource untuk satu proses student
+type Limits struct {
+    CPUTime     time.Duration // Batas CPU time (RLIMIT_CPU)
+    WallTime    time.Duration // Batas waktu nyata (kill setelah lewat)
+    MemoryBytes int64         // Batas address space (RLIMIT_AS)
+    OutputBytes int           // Maksimal stdout/stderr yang disimpan
+    Processes   int           // Batas jumlah proses/thread (RLIMIT_NPROC, mencegah fork bomb)
+}
+
+// DefaultLimits dipakai jika tidak ada konfigurasi khusus
+var DefaultLimits = Limits{
+    CPUTime:     2 * time.Second,
+    WallTime:    5 * time.Second,
+    MemoryBytes: 256 * 1024 * 1024,
+    OutputBytes: 64 * 1024,
+    Processes:   64,
+}
+
+// Program adalah kode student yang siap dieksekusi
+type Program struct {
+    Files         map[string]string // Nama file -> isi file
+    Compile       []string          // Command compile (opsional)
+    Run           []string          // Command untuk menjalankan program
+    Env           []string          // Environment tambahan (KEY=VALUE)
+    CompileLimits Limits
+    Limits        Limits
+}
+
+// Result adalah hasil satu kali eksekusi proses
+type Result struct {
+    Stdout    string        `json:"stdout"`
+    Stderr    string        `json:"stderr"`
+    ExitCode  int           `json:"exit_code"`
+    TimedOut  bool          `json:"timed_out"`
+    Truncated bool          `json:"truncated"`
+    Duration  time.Duration `json:"duration"`
+
+    CompileFailed bool `json:"compile_failed"` // true jika Result berasal dari compiler
+}
+
+// Workspace adalah direktori sementara tempat kode student dijalankan
+type Workspace struct {
+    Dir     string
+    base    string // Direktori induk (workspace + mount point sandbox)
+    program Program
+}
+
+// maxFileBlocks membatasi ukuran file yang boleh ditulis program (ulimit -f, blok 1 KB)
+const maxFileBlocks = 131072
+
+// sandboxSetupExitCode dipakai helper sandbox jika gagal menyiapkan isolasi
+const sandboxSetupExitCode = 125
+
+// ErrNoRunCommand dikembalikan jika Program tidak punya command run
+var ErrNoRunCommand = errors.New("command run tidak ditemukan")
+
+// slots membatasi jumlah proses student yang berjalan bersamaan
+// (grading queue & RUN stage berbagi batas yang sama), nil = tanpa batas
+var slots chan struct{}
+
+// SetMaxConcurrent membatasi jumlah proses sandbox yang berjalan bersamaan
+// Dipanggil sekali saat startup, sebelum eksekusi pertama
+func SetMaxConcurrent(n int) {
+    if n > 0 {
+        slots = make(chan struct{}, n)
+    }
+}
+
+// acquireSlot menunggu sampai ada slot eksekusi kosong (atau ctx dibatalkan)
+func acquireSlot(ctx context.Context) (release func(), err error) {
+    if slots == nil {
+        return func() {}, nil
+    }
+    select {
+    case slots <- struct{}{}:
+        return func() { <-slots }, nil
+    case <-ctx.Done():
+        return nil, errors.New("eksekusi code sedang penuh, coba lagi beberapa saat lagi")
+    }
+}
+
+// Prepare membuat workspace sementara, menulis file, lalu compile (jika ada)
+// Caller wajib memanggil Close() setelah selesai
+func Prepare(ctx context.Context, program Program) (*Workspace, *Result, error) {
+    if len(program.Run) == 0 {
+        return nil, nil, ErrNoRunCommand
+    }
+
+    base, err := os.MkdirTemp("", "primmfy-exec-")
+    if err != nil {
+        return nil, nil, errors.New("gagal membuat workspace: " + err.Error())
+    }
+
+    ws := &Workspace{Dir: filepath.Join(base, "work"), base: base, program: program}
+    dir := ws.Dir
+
+    // Induk hanya bisa dilewati (bukan dibaca) oleh user sandbox
+    if err := os.Chmod(base, 0711); err != nil {
+        ws.Close()
+        return nil, nil, errors.New("gagal membuat workspace: " + err.Error())
+    }
+    if err := os.Mkdir(dir, 0755); err != nil {
+        ws.Close()
+        return nil, nil, errors.New("gagal membuat workspace: " + err.Error())
+    }
+
+    for name, content := range program.Files {
+        // Cegah path traversal dari nama file
+        if filepath.Base(name) != name {
+            ws.Close()
+            return nil, nil, fmt.Errorf("nama file tidak valid: %s", name)
+        }
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+            ws.Close()
+            return nil, nil, errors.New("gagal menulis file: " + err.Error())
+        }
+    }
+
+    if err := prepareWorkspace(dir); err != nil {
+        ws.Close()
+        return nil, nil, errors.New("gagal menyiapkan workspace: " + err.Error())
+    }
+
+    if len(program.Compile) == 0 {
+        return ws, nil, nil
+    }
+
+    compileResult, err := runProcess(ctx, dir, program.Compile, program.Env, "", program.CompileLimits)
+    if err != nil {
+        ws.Close()
+        return nil, nil, err
+    }
+
+    return ws, compileResult, nil
+}
+
+// RunOnce compile (jika perlu) lalu jalankan program satu kali
+// Jika compile gagal, Result berisi output compiler dengan CompileFailed = true
+func RunOnce(ctx context.Context, program Program, stdin string) (*Result, error) {
+    ws, compileResult, err := Prepare(ctx, program)
+    if err != nil {
+        return nil, err
+    }
+    defer ws.Close()
+
+    if compileResult != nil && (compileResult.ExitCode != 0 || compileResult.TimedOut) {
+        compileResult.CompileFailed = true
+        return compileResult, nil
+    }
+
+    return ws.Exec(ctx, stdin)
+}
+
+// Exec menjalankan program di workspace dengan stdin tertentu
+func (ws *Workspace) Exec(ctx context.Context, stdin string) (*Result, error) {
+    return runProcess(ctx, ws.Dir, ws.program.Run, ws.program.Env, stdin, ws.program.Limits)
+}
+
+// Close menghapus workspace beserta semua file di dalamnya
+func (ws *Workspace) Close() {
+    os.RemoveAll(ws.base)
+}
+
+// runProcess menjalankan satu proses dengan isolasi & resource limits
+func runProcess(ctx context.Context, dir string, argv []string, env []string, stdin string, limits Limits) (*Result, error) {
+    limits = limits.withDefaults()
+
+    // Wall time baru dihitung setelah mendapat slot
+    release, err := acquireSlot(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer release()
+
+    ctx, cancel := context.WithTimeout(ctx, limits.WallTime)
+    defer cancel()
+
+    cmd, err := sandboxCommand(dir, argv, limits)
+    if err != nil {
+        return nil, err
+    }
+    cmd.Env = append(sandboxEnv(dir), env...)
+    cmd.Stdin = strings.NewReader(stdin)
+    cmd.WaitDelay = time.Second
+
+    stdout := &cappedBuffer{limit: limits.OutputBytes}
+    stderr := &cappedBuffer{limit: limits.OutputBytes}
+    cmd.Stdout = stdout
+    cmd.Stderr = stderr
+
+    start := time.Now()
+    if err := cmd.Start(); err != nil {
+        return nil, errors.New("gagal menjalankan sandbox: " + err.Error())
+    }
+
+    done := make(chan error, 1)
+    go func() { done <- cmd.Wait() }()
+
+    var waitErr error
+    timedOut := false
+    select {
+    case waitErr = <-done:
+    case <-ctx.Done():
+        timedOut = true
+        killProcessGroup(cmd)
+        waitErr = <-done
+    }
+
+    result := &Result{
+        Stdout:    stdout.String(),
+        Stderr:    stderr.String(),
+        TimedOut:  timedOut,
+        Truncated: stdout.truncated || stderr.truncated,
+        Duration:  time.Since(start),
+    }
+
+    if waitErr != nil {
+        var exitErr *exec.ExitError
+        if !errors.As(waitErr, &exitErr) {
+            return nil, errors.New("gagal menunggu proses: " + waitErr.Error())
+        }
+        result.ExitCode = exitErr.ExitCode()
+
+        if result.ExitCode == sandboxSetupExitCode && strings.HasPrefix(result.Stderr, "sandbox: ") {
+            return nil, errors.New("gagal menyiapkan sandbox: " + strings.TrimSpace(strings.TrimPrefix(result.Stderr, "sandbox: ")))
+        }
+
+        // RLIMIT_CPU: soft limit mengirim SIGXCPU, hard limit SIGKILL, jadi CPU time
+        // yang sudah mencapai limit juga dianggap timeout
+        // (RLIMIT_AS membuat alokasi gagal di dalam program -> runtime error biasa)
+        cpuUsed := exitErr.UserTime() + exitErr.SystemTime()
+        if cpuLimitSignal(exitErr) || cpuUsed >= time.Duration(cpuLimitSeconds(limits))*time.Second {
+            result.TimedOut = true
+        }
+    }
+
+    return result, nil
+}
+
+// cpuLimitSeconds membulatkan batas CPU time ke atas (RLIMIT_CPU dalam detik)
+func cpuLimitSeconds(limits Limits) int64 {
+    return int64(limits.CPUTime.Seconds() + 0.999)
+}
+
+// shellCommand menjalankan argv lewat /bin/sh yang memasang resource limits
+// dengan ulimit (dipakai di platform tanpa helper sandbox Linux)
+func shellCommand(dir string, argv []string, limits Limits) *exec.Cmd {
+    script := fmt.Sprintf(`ulimit -t %d; ulimit -v %d; ulimit -f %d; ulimit -u %d; exec "$@"`,
+        cpuLimitSeconds(limits),
+        limits.MemoryBytes/1024,
+        maxFileBlocks,
+        limits.Processes,
+    )
+    args := append([]string{"-c", script, "sandbox"}, argv...)
+
+    cmd := exec.Command("/bin/sh", args...)
+    cmd.Dir = dir
+    return cmd
+}
+
+// withDefaults mengisi field Limits yang kosong dengan DefaultLimits
+func (l Limits) withDefaults() Limits {
+    if l.CPUTime <= 0 {
+        l.CPUTime = DefaultLimits.CPUTime
+    }
+    if l.WallTime <= 0 {
+        l.WallTime = DefaultLimits.WallTime
+    }
+    if l.MemoryBytes <= 0 {
+        l.MemoryBytes = DefaultLimits.MemoryBytes
+    }
+    if l.OutputBytes <= 0 {
+        l.OutputBytes = DefaultLimits.OutputBytes
+    }
+    if l.Processes <= 0 {
+        l.Processes = DefaultLimits.Processes
+    }
+    return l
+}
+
+// LimitsFromEnv membaca override limits dari environment variables
+// EXECUTOR_CPU_SECONDS, EXECUTOR_WALL_SECONDS, EXECUTOR_MEMORY_MB, EXECUTOR_OUTPUT_KB, EXECUTOR_MAX_PROCESSES
+func LimitsFromEnv(base Limits) Limits {
+    if v, err := strconv.Atoi(os.Getenv("EXECUTOR_CPU_SECONDS")); err == nil && v > 0 {
+        base.CPUTime = time.Duration(v) * time.Second
+    }
+    if v, err := strconv.Atoi(os.Getenv("EXECUTOR_WALL_SECONDS")); err == nil && v > 0 {
+        base.WallTime = time.Duration(v) * time.Second
+    }
+    if v, err := strconv.Atoi(os.Getenv("EXECUTOR_MEMORY_MB")); err == nil && v > 0 {
+        base.MemoryBytes = int64(v) * 1024 * 1024
+    }
+    if v, err := strconv.Atoi(os.Getenv("EXECUTOR_OUTPUT_KB")); err == nil && v > 0 {
+        base.OutputBytes = v * 1024
+    }
+    if v, err := strconv.Atoi(os.Getenv("EXECUTOR_MAX_PROCESSES")); err == nil && v > 0 {
+        base.Processes = v
+    }
+    return base
+}
+
+// defaultSandboxPath adalah PATH untuk mencari compiler/interpreter
+const defaultSandboxPath = "/usr/local/go/bin:/usr/local/bin:/usr/bin:/bin"
+
+// sandboxEnv membuat environment minimal (tanpa secret dari server)
+// PATH bisa diganti lewat EXECUTOR_PATH jika toolchain ada di lokasi lain
+func sandboxEnv(dir string) []string {
+    path := os.Getenv("EXECUTOR_PATH")
+    if path == "" {
+        path = defaultSandboxPath
+    }
+    return []string{
+        "PATH=" + path,
+        "HOME=" + dir,
+        "TMPDIR=" + dir,
+        "LANG=C.UTF-8",
+    }
+}
+
+// cappedBuffer menyimpan output sampai batas tertentu lalu membuang sisanya
+type cappedBuffer struct {
+    buf       bytes.Buffer
+    limit     int
+    truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+    remaining := b.limit - b.buf.Len()
+    if remaining <= 0 {
+        b.truncated = true
+        return len(p), nil
+    }
+    if len(p) > remaining {
+        b.buf.Write(p[:remaining])
+        b.truncated = true
+        return len(p), nil
+    }
+    return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+    return b.buf.String()
+}
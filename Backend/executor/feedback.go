@@ -0,0 +1,136 @@
+package executor
+
+import (
+    "strings"
+
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// PER TEST CASE FEEDBACK
+// ═══════════════════════════════════════════════════════════
+
+// maxDiffLines membatasi jumlah baris yang di-diff (LCS O(n*m))
+const maxDiffLines = 300
+
+// Feedback mengubah Report menjadi feedback per test case untuk student
+// testCases harus sama (dan urutannya sama) dengan yang dipakai RunTests
+func (r *Report) Feedback(testCases []models.TestCase) []models.TestCaseResult {
+    feedback := make([]models.TestCaseResult, 0, len(testCases))
+
+    // Compile gagal: semua test case berstatus compile_error
+    if r.CompileError != "" {
+        for i, tc := range testCases {
+            feedback = append(feedback, models.TestCaseResult{
+                Index:       i + 1,
+                Description: tc.Description,
+                Hidden:      tc.Hidden,
+                Status:      models.TestStatusCompileError,
+            })
+        }
+        return feedback
+    }
+
+    for i, res := range r.Results {
+        if i >= len(testCases) {
+            break
+        }
+        feedback = append(feedback, res.Feedback(testCases[i]))
+    }
+
+    return feedback
+}
+
+// Feedback mengubah hasil satu test case menjadi feedback untuk student
+func (res TestResult) Feedback(tc models.TestCase) models.TestCaseResult {
+    item := models.TestCaseResult{
+        Index:           res.Index,
+        Description:     res.Description,
+        Hidden:          res.Hidden,
+        Status:          res.Status(),
+        ExecutionTimeMs: res.Duration.Milliseconds(),
+    }
+
+    // Detail hanya untuk test case visible
+    if !res.Hidden {
+        item.Input = tc.Input
+        item.ActualOutput = res.Output
+        item.ExpectedOutput = tc.ExpectedOutput
+        item.Stderr = res.Stderr
+        if item.Status == models.TestStatusWrongAnswer && tc.Comparison != models.ComparisonRegex {
+            item.Diff = LineDiff(tc.ExpectedOutput, res.Output)
+        }
+    }
+
+    return item
+}
+
+// Status mengembalikan status test case (passed, wrong_answer, dll)
+func (res TestResult) Status() string {
+    switch {
+    case res.Passed:
+        return models.TestStatusPassed
+    case res.TimedOut:
+        return models.TestStatusTimeout
+    case res.ExitCode != 0:
+        return models.TestStatusRuntimeError
+    default:
+        return models.TestStatusWrongAnswer
+    }
+}
+
+// LineDiff membuat diff per baris antara expected dan actual output
+// Format: "  " baris sama, "- " hanya di expected, "+ " hanya di actual
+func LineDiff(expected, actual string) string {
+    want := strings.Split(normalizeOutput(expected), "\n")
+    got := strings.Split(normalizeOutput(actual), "\n")
+
+    if len(want) > maxDiffLines {
+        want = want[:maxDiffLines]
+    }
+    if len(got) > maxDiffLines {
+        got = got[:maxDiffLines]
+    }
+
+    // lcs[i][j] = panjang LCS dari want[i:] dan got[j:]
+    lcs := make([][]int, len(want)+1)
+    for i := range lcs {
+        lcs[i] = make([]int, len(got)+1)
+    }
+    for i := len(want) - 1; i >= 0; i-- {
+        for j := len(got) - 1; j >= 0; j-- {
+            if want[i] == got[j] {
+                lcs[i][j] = lcs[i+1][j+1] + 1
+            } else if lcs[i+1][j] >= lcs[i][j+1] {
+                lcs[i][j] = lcs[i+1][j]
+            } else {
+                lcs[i][j] = lcs[i][j+1]
+            }
+        }
+    }
+
+    var sb strings.Builder
+    i, j := 0, 0
+    for i < len(want) && j < len(got) {
+        switch {
+        case want[i] == got[j]:
+            sb.WriteString("  " + want[i] + "\n")
+            i++
+            j++
+        case lcs[i+1][j] >= lcs[i][j+1]:
+            sb.WriteString("- " + want[i] + "\n")
+            i++
+        default:
+            sb.WriteString("+ " + got[j] + "\n")
+            j++
+        }
+    }
+    for ; i < len(want); i++ {
+        sb.WriteString("- " + want[i] + "\n")
+    }
+    for ; j < len(got); j++ {
+        sb.WriteString("+ " + got[j] + "\n")
+    }
+
+    return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+    "strings"
+    "testing"
+    "time"
+
+    "primmfy_db/models"
+)
+
+func TestLineDiff(t *testing.T) {
+    cases := []struct {
+        name     string
+        expected string
+        actual   string
+        want     string
+    }{
+        {"sama", "a\nb", "a\nb", "  a\n  b"},
+        {"satu baris beda", "a\nb\nc", "a\nx\nc", "  a\n- b\n+ x\n  c"},
+        {"baris hilang", "a\nb\nc", "a\nc", "  a\n- b\n  c"},
+        {"baris tambahan", "a\nc", "a\nb\nc", "  a\n+ b\n  c"},
+        {"tambahan di akhir", "a", "a\nb", "  a\n+ b"},
+        {"trailing whitespace & CRLF diabaikan", "a \r\nb\r\n", "a\nb\n\n", "  a\n  b"},
+        {"semua beda", "1\n2", "3\n4", "- 1\n- 2\n+ 3\n+ 4"},
+    }
+
+    for _, tc := range cases {
+        if got := LineDiff(tc.expected, tc.actual); got != tc.want {
+            t.Errorf("%s: LineDiff(%q, %q) =\n%s\nwant\n%s", tc.name, tc.expected, tc.actual, got, tc.want)
+        }
+    }
+}
+
+func TestLineDiffLimitsLines(t *testing.T) {
+    long := strings.Repeat("x\n", maxDiffLines+100)
+    diff := LineDiff(long, long)
+
+    if lines := strings.Count(diff, "\n") + 1; lines != maxDiffLines {
+        t.Fatalf("diff %d baris, want %d (dibatasi maxDiffLines)", lines, maxDiffLines)
+    }
+}
+
+func TestTestResultStatus(t *testing.T) {
+    cases := []struct {
+        name   string
+        result TestResult
+        want   string
+    }{
+        {"passed", TestResult{Passed: true}, models.TestStatusPassed},
+        {"timeout", TestResult{TimedOut: true, ExitCode: -1}, models.TestStatusTimeout},
+        {"runtime error", TestResult{ExitCode: 1}, models.TestStatusRuntimeError},
+        {"wrong answer", TestResult{}, models.TestStatusWrongAnswer},
+    }
+
+    for _, tc := range cases {
+        if got := tc.result.Status(); got != tc.want {
+            t.Errorf("%s: Status() = %q, want %q", tc.name, got, tc.want)
+        }
+    }
+}
+
+func TestTestResultFeedback(t *testing.T) {
+    tc := models.TestCase{Input: "2 3", ExpectedOutput: "5\n"}
+    res := TestResult{Index: 1, Output: "6\n", Stderr: "warning", Duration: 1500 * time.Microsecond}
+
+    item := res.Feedback(tc)
+    if item.Status != models.TestStatusWrongAnswer || item.ExecutionTimeMs != 1 {
+        t.Fatalf("feedback tidak sesuai: %+v", item)
+    }
+    if item.Input != "2 3" || item.ActualOutput != "6\n" || item.ExpectedOutput != "5\n" || item.Stderr != "warning" {
+        t.Fatalf("detail test case visible tidak lengkap: %+v", item)
+    }
+    if item.Diff != "- 5\n+ 6" {
+        t.Fatalf("diff = %q", item.Diff)
+    }
+
+    // Regex: expected output adalah pola, diff tidak bermakna
+    tc.Comparison = models.ComparisonRegex
+    if item := res.Feedback(tc); item.Diff != "" {
+        t.Fatalf("test case regex tidak boleh punya diff: %q", item.Diff)
+    }
+
+    // Passed: tidak perlu diff
+    tc.Comparison = ""
+    res.Passed = true
+    if item := res.Feedback(tc); item.Status != models.TestStatusPassed || item.Diff != "" {
+        t.Fatalf("test case passed: %+v", item)
+    }
+}
+
+func TestTestResultFeedbackHidden(t *testing.T) {
+    tc := models.TestCase{Input: "rahasia", ExpectedOutput: "42", Hidden: true}
+    res := TestResult{Index: 2, Hidden: true, Output: "41", Stderr: "trace"}
+
+    item := res.Feedback(tc)
+    if !item.Hidden || item.Status != models.TestStatusWrongAnswer {
+        t.Fatalf("feedback hidden tidak sesuai: %+v", item)
+    }
+    if item.Input != "" || item.ActualOutput != "" || item.ExpectedOutput != "" || item.Stderr != "" || item.Diff != "" {
+        t.Fatalf("detail test case hidden tidak boleh dikirim: %+v", item)
+    }
+}
+
+func TestReportFeedbackCompileError(t *testing.T) {
+    testCases := []models.TestCase{
+        {Description: "contoh"},
+        {Hidden: true},
+    }
+    report := &Report{CompileError: "main.go:1: syntax error"}
+
+    feedback := report.Feedback(testCases)
+    if len(feedback) != 2 {
+        t.Fatalf("feedback %d item, want 2", len(feedback))
+    }
+    for i, item := range feedback {
+        if item.Index != i+1 || item.Status != models.TestStatusCompileError || item.Hidden != testCases[i].Hidden {
+            t.Errorf("item %d: %+v", i, item)
+        }
+    }
+    if feedback[0].Description != "contoh" {
+        t.Errorf("description tidak ikut: %+v", feedback[0])
+    }
+}
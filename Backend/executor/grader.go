@@ -0,0 +1,168 @@
+package executor
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// TEST CASE GRADING
+// ═══════════════════════════════════════════════════════════
+
+// TestResult adalah hasil eksekusi code student untuk satu test case
+type TestResult struct {
+    Index       int           `json:"index"`
+    Description string        `json:"description,omitempty"`
+    Hidden      bool          `json:"hidden"`
+    Weight      int           `json:"weight"`
+    Passed      bool          `json:"passed"`
+    Output      string        `json:"output"`
+    Stderr      string        `json:"stderr,omitempty"`
+    ExitCode    int           `json:"exit_code"`
+    TimedOut    bool          `json:"timed_out"`
+    Duration    time.Duration `json:"duration"`
+}
+
+// Report adalah ringkasan hasil grading semua test case
+type Report struct {
+    CompileError string       `json:"compile_error,omitempty"`
+    Results      []TestResult `json:"results"`
+    PassedCount  int          `json:"passed_count"`
+    PassedWeight int          `json:"passed_weight"`
+    TotalWeight  int          `json:"total_weight"`
+    Score        int          `json:"score"` // 0-100 berdasarkan bobot test case
+    AllPassed    bool         `json:"all_passed"`
+}
+
+// ProgressFunc dipanggil setiap kali satu test case selesai dijalankan
+type ProgressFunc func(tc models.TestCase, result TestResult)
+
+type progressKey struct{}
+
+// WithProgress menyisipkan ProgressFunc ke context untuk dipakai RunTests
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+    return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// RunTests compile code sekali, lalu jalankan untuk setiap test case:
+// Input dikirim ke stdin, stdout dibandingkan dengan ExpectedOutput
+// Score dihitung dari total bobot test case yang passed
+func RunTests(ctx context.Context, program Program, testCases []models.TestCase) (*Report, error) {
+    ws, compileResult, err := Prepare(ctx, program)
+    if err != nil {
+        return nil, err
+    }
+    defer ws.Close()
+
+    progress, _ := ctx.Value(progressKey{}).(ProgressFunc)
+
+    report := &Report{}
+    for _, tc := range testCases {
+        report.TotalWeight += testCaseWeight(tc)
+    }
+
+    // Compile gagal = semua test case otomatis gagal
+    if compileResult != nil && (compileResult.ExitCode != 0 || compileResult.TimedOut) {
+        report.CompileError = strings.TrimSpace(compileResult.Stderr + compileResult.Stdout)
+        if compileResult.TimedOut {
+            report.CompileError = "compile melebihi batas waktu"
+        }
+        return report, nil
+    }
+
+    for i, tc := range testCases {
+        result, err := ws.Exec(ctx, tc.Input)
+        if err != nil {
+            return nil, err
+        }
+
+        passed := !result.TimedOut && result.ExitCode == 0 &&
+            compareOutput(tc, result.Stdout)
+
+        weight := testCaseWeight(tc)
+        if passed {
+            report.PassedCount++
+            report.PassedWeight += weight
+        }
+
+        testResult := TestResult{
+            Index:       i + 1,
+            Description: tc.Description,
+            Hidden:      tc.Hidden,
+            Weight:      weight,
+            Passed:      passed,
+            Output:      result.Stdout,
+            Stderr:      result.Stderr,
+            ExitCode:    result.ExitCode,
+            TimedOut:    result.TimedOut,
+            Duration:    result.Duration,
+        }
+        report.Results = append(report.Results, testResult)
+
+        if progress != nil {
+            progress(tc, testResult)
+        }
+    }
+
+    report.AllPassed = len(testCases) > 0 && report.PassedCount == len(testCases)
+    if report.TotalWeight > 0 {
+        report.Score = report.PassedWeight * 100 / report.TotalWeight
+    }
+
+    return report, nil
+}
+
+// testCaseWeight mengembalikan bobot test case (default 1)
+func testCaseWeight(tc models.TestCase) int {
+    if tc.Weight <= 0 {
+        return 1
+    }
+    return tc.Weight
+}
+
+// Summary membuat ringkasan text untuk disimpan di kolom *_output
+func (r *Report) Summary() string {
+    if r.CompileError != "" {
+        return "Compile error:\n" + r.CompileError
+    }
+
+    var sb strings.Builder
+    for _, res := range r.Results {
+        // Detail test case hidden tidak boleh bocor ke student
+        if res.Hidden {
+            status := "FAILED"
+            if res.Passed {
+                status = "PASSED"
+            }
+            fmt.Fprintf(&sb, "Test case %d (hidden): %s\n", res.Index, status)
+            continue
+        }
+
+        switch {
+        case res.Passed:
+            fmt.Fprintf(&sb, "Test case %d: PASSED\n", res.Index)
+        case res.TimedOut:
+            fmt.Fprintf(&sb, "Test case %d: TIMEOUT\n", res.Index)
+        case res.ExitCode != 0:
+            fmt.Fprintf(&sb, "Test case %d: RUNTIME ERROR (exit code %d)\n", res.Index, res.ExitCode)
+            if res.Stderr != "" {
+                fmt.Fprintf(&sb, "%s\n", strings.TrimRight(res.Stderr, "\n"))
+            }
+        default:
+            fmt.Fprintf(&sb, "Test case %d: FAILED\n", res.Index)
+            fmt.Fprintf(&sb, "Output:\n%s\n", strings.TrimRight(res.Output, "\n"))
+        }
+    }
+
+    if r.AllPassed {
+        sb.WriteString("All tests passed!")
+    } else {
+        fmt.Fprintf(&sb, "%d/%d tests passed (score %d/100)", r.PassedCount, len(r.Results), r.Score)
+    }
+
+    return sb.String()
+}
@@ -0,0 +1,197 @@
+package executor
+
+import (
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// ═══════════════════════════════════════════════════════════
+// LANGUAGE RUNTIME REGISTRY
+// ═══════════════════════════════════════════════════════════
+
+// Runner mendefinisikan cara compile & menjalankan code untuk satu bahasa
+// Nama bahasa harus sama dengan lessons.category (python, javascript, dll)
+type Runner interface {
+    Language() string         // Nama bahasa / category lesson
+    SourceFile() string       // Nama file tempat code student ditulis
+    CompileCommand() []string // nil jika bahasa interpreted
+    RunCommand() []string     // Command untuk menjalankan program
+    Env() []string            // Environment tambahan untuk compile & run
+    CompileLimits() Limits    // Limits untuk compile (field 0 = pakai default)
+    RunLimits() Limits        // Limits untuk run (field 0 = pakai default)
+}
+
+var (
+    registryMu sync.RWMutex
+    registry   = map[string]Runner{}
+)
+
+// Register mendaftarkan runner untuk satu bahasa
+// Runner dengan nama bahasa yang sama akan di-replace
+func Register(r Runner) {
+    registryMu.Lock()
+    defer registryMu.Unlock()
+    registry[r.Language()] = r
+}
+
+// Lookup mencari runner berdasarkan nama bahasa (category lesson)
+func Lookup(language string) (Runner, error) {
+    registryMu.RLock()
+    defer registryMu.RUnlock()
+
+    r, ok := registry[language]
+    if !ok {
+        return nil, fmt.Errorf("bahasa %s belum didukung executor", language)
+    }
+    return r, nil
+}
+
+// Languages mengembalikan semua bahasa yang sudah terdaftar (urut abjad)
+func Languages() []string {
+    registryMu.RLock()
+    defer registryMu.RUnlock()
+
+    languages := make([]string, 0, len(registry))
+    for name := range registry {
+        languages = append(languages, name)
+    }
+    sort.Strings(languages)
+    return languages
+}
+
+// NewProgram membungkus source code student menjadi Program sesuai runner
+// Limits dari environment (EXECUTOR_*) dipakai untuk field yang tidak di-set runner
+func NewProgram(r Runner, code string) Program {
+    base := LimitsFromEnv(DefaultLimits)
+    return Program{
+        Files:         map[string]string{r.SourceFile(): code},
+        Compile:       r.CompileCommand(),
+        Run:           r.RunCommand(),
+        Env:           r.Env(),
+        CompileLimits: mergeLimits(r.CompileLimits(), base),
+        Limits:        mergeLimits(r.RunLimits(), base),
+    }
+}
+
+// MaxRunWallTime mengembalikan wall time terlama satu RunOnce (compile + run)
+// dari semua runner terdaftar (harus di bawah REQUEST_TIMEOUT untuk RUN stage)
+func MaxRunWallTime() time.Duration {
+    var longest time.Duration
+    for _, language := range Languages() {
+        r, err := Lookup(language)
+        if err != nil {
+            continue
+        }
+        program := NewProgram(r, "")
+        total := program.Limits.withDefaults().WallTime
+        if len(program.Compile) > 0 {
+            total += program.CompileLimits.withDefaults().WallTime
+        }
+        if total > longest {
+            longest = total
+        }
+    }
+    return longest
+}
+
+// mergeLimits mengisi field yang kosong di override dengan nilai dari base
+func mergeLimits(override, base Limits) Limits {
+    if override.CPUTime <= 0 {
+        override.CPUTime = base.CPUTime
+    }
+    if override.WallTime <= 0 {
+        override.WallTime = base.WallTime
+    }
+    if override.MemoryBytes <= 0 {
+        override.MemoryBytes = base.MemoryBytes
+    }
+    if override.OutputBytes <= 0 {
+        override.OutputBytes = base.OutputBytes
+    }
+    if override.Processes <= 0 {
+        override.Processes = base.Processes
+    }
+    return override
+}
+
+// ═══════════════════════════════════════════════════════════
+// BUILT-IN RUNNERS
+// ═══════════════════════════════════════════════════════════
+
+// CommandRunner adalah Runner sederhana berbasis command line
+// Cukup untuk sebagian besar compiler/interpreter
+type CommandRunner struct {
+    Name     string
+    File     string
+    Compile  []string
+    Run      []string
+    ExtraEnv []string
+    Build    Limits
+    Exec     Limits
+}
+
+func (r CommandRunner) Language() string         { return r.Name }
+func (r CommandRunner) SourceFile() string       { return r.File }
+func (r CommandRunner) CompileCommand() []string { return r.Compile }
+func (r CommandRunner) RunCommand() []string     { return r.Run }
+func (r CommandRunner) Env() []string            { return r.ExtraEnv }
+func (r CommandRunner) CompileLimits() Limits    { return r.Build }
+func (r CommandRunner) RunLimits() Limits        { return r.Exec }
+
+// compilerLimits untuk bahasa compiled (compiler butuh waktu, memory & thread lebih)
+// Wall time compile + run harus di bawah REQUEST_TIMEOUT (default 60s), karena RUN stage
+// dijalankan di dalam request
+var compilerLimits = Limits{
+    CPUTime:     30 * time.Second,
+    WallTime:    40 * time.Second,
+    MemoryBytes: 2 * 1024 * 1024 * 1024,
+    Processes:   512,
+}
+
+func init() {
+    Register(CommandRunner{
+        Name: "python",
+        File: "main.py",
+        Run:  []string{"python3", "main.py"},
+    })
+
+    // V8 me-reserve virtual memory besar, heap dibatasi lewat --max-old-space-size
+    Register(CommandRunner{
+        Name: "javascript",
+        File: "main.js",
+        Run:  []string{"node", "--max-old-space-size=128", "main.js"},
+        Exec: Limits{MemoryBytes: 1024 * 1024 * 1024},
+    })
+
+    // Go runtime me-reserve arena besar saat start, jadi RLIMIT_AS harus longgar
+    // (thread runtime mengikuti jumlah CPU, jadi batas proses juga dilonggarkan)
+    Register(CommandRunner{
+        Name:     "golang",
+        File:     "main.go",
+        Compile:  []string{"go", "build", "-o", "main", "main.go"},
+        Run:      []string{"./main"},
+        ExtraEnv: []string{"CGO_ENABLED=0", "GOTOOLCHAIN=local", "GOFLAGS=", "GOMEMLIMIT=256MiB"},
+        Build:    compilerLimits,
+        Exec:     Limits{MemoryBytes: 2 * 1024 * 1024 * 1024, Processes: 256},
+    })
+
+    // Class student harus bernama Main (public class Main)
+    Register(CommandRunner{
+        Name:    "java",
+        File:    "Main.java",
+        Compile: []string{"javac", "-J-Xmx512m", "Main.java"},
+        Run:     []string{"java", "-Xmx256m", "-Xss8m", "-XX:+UseSerialGC", "-cp", ".", "Main"},
+        Build:   Limits{CPUTime: 30 * time.Second, WallTime: 40 * time.Second, MemoryBytes: 4 * 1024 * 1024 * 1024, Processes: 512},
+        Exec:    Limits{MemoryBytes: 4 * 1024 * 1024 * 1024, Processes: 256},
+    })
+
+    Register(CommandRunner{
+        Name:    "cpp",
+        File:    "main.cpp",
+        Compile: []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"},
+        Run:     []string{"./main"},
+        Build:   compilerLimits,
+    })
+}
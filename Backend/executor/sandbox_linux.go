@@ -0,0 +1,433 @@
+//go:build linux
+
+package executor
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "runtime"
+    "strconv"
+    "syscall"
+)
+
+// ═══════════════════════════════════════════════════════════
+// LINUX SANDBOX
+// ═══════════════════════════════════════════════════════════
+//
+// Proses student dijalankan lewat helper (binary server sendiri, dipanggil ulang
+// dengan argv[0] = sandboxInitName) di dalam namespace baru. Helper:
+//   1. Membangun root filesystem minimal (tmpfs) berisi bind-mount read-only
+//      dari EXECUTOR_ROOTFS, workspace (read-write), /dev minimal & /proc baru
+//   2. pivot_root ke root tersebut lalu melepas root lama
+//   3. Memasang rlimit (CPU, memory, ukuran file, jumlah proses)
+//   4. Membuang semua capability lalu exec program student
+
+// sandboxInitName adalah argv[0] yang menandai proses helper sandbox
+const sandboxInitName = "primmfy-sandbox-init"
+
+// defaultRootfsDirs adalah direktori host yang terlihat (read-only) di sandbox
+// Direktori aplikasi (.env, source code) sengaja tidak termasuk
+const defaultRootfsDirs = "/usr:/bin:/sbin:/lib:/lib32:/lib64:/etc"
+
+// sandboxDevices adalah device yang di-bind ke /dev sandbox
+var sandboxDevices = []string{"null", "zero", "full", "random", "urandom"}
+
+// Konstanta Linux yang tidak tersedia di package syscall
+const (
+    capSysAdmin          = 21
+    prSetNoNewPrivs      = 38
+    prCapAmbient         = 47
+    prCapAmbientClearAll = 4
+    rlimitNproc          = 6
+
+    stNoDev      = 0x4
+    stNoExec     = 0x8
+    stNoAtime    = 0x400
+    stNoDirAtime = 0x800
+    stRelAtime   = 0x1000
+)
+
+func init() {
+    if len(os.Args) > 0 && os.Args[0] == sandboxInitName {
+        runtime.LockOSThread()
+        err := sandboxInit(os.Args[1:])
+        fmt.Fprintln(os.Stderr, "sandbox: "+err.Error())
+        os.Exit(sandboxSetupExitCode)
+    }
+}
+
+// sandboxCommand membuat command helper sandbox untuk menjalankan argv di workspace dir
+// Helper menerima limits lewat argumen karena rlimit dipasang setelah root diganti
+func sandboxCommand(dir string, argv []string, limits Limits) (*exec.Cmd, error) {
+    attr, err := sysProcAttr()
+    if err != nil {
+        return nil, err
+    }
+
+    args := []string{
+        dir,
+        strconv.FormatInt(cpuLimitSeconds(limits), 10),
+        strconv.FormatInt(limits.MemoryBytes, 10),
+        strconv.FormatInt(maxFileBlocks*1024, 10),
+        strconv.Itoa(limits.Processes),
+        "--",
+    }
+
+    cmd := exec.Command("/proc/self/exe", append(args, argv...)...)
+    cmd.Args[0] = sandboxInitName
+    cmd.SysProcAttr = attr
+    return cmd, nil
+}
+
+// sysProcAttr mengisolasi proses student dengan Linux namespaces:
+// - CLONE_NEWUSER: proses berjalan sebagai EXECUTOR_UID tanpa hak akses di host
+// - CLONE_NEWNS: root filesystem sendiri (lihat sandboxInit)
+// - CLONE_NEWNET: tidak ada network interface selain loopback (mati)
+// - CLONE_NEWPID: proses tidak bisa melihat/membunuh proses server
+// - CLONE_NEWIPC & CLONE_NEWUTS: isolasi IPC dan hostname
+// CAP_SYS_ADMIN (hanya di user namespace baru) diberikan ke helper untuk mount,
+// lalu dibuang sebelum exec ke program student
+func sysProcAttr() (*syscall.SysProcAttr, error) {
+    uid, gid, err := sandboxIDs()
+    if err != nil {
+        return nil, err
+    }
+    return &syscall.SysProcAttr{
+        Cloneflags: syscall.CLONE_NEWUSER |
+            syscall.CLONE_NEWNS |
+            syscall.CLONE_NEWNET |
+            syscall.CLONE_NEWPID |
+            syscall.CLONE_NEWIPC |
+            syscall.CLONE_NEWUTS,
+        UidMappings: []syscall.SysProcIDMap{
+            {ContainerID: uid, HostID: uid, Size: 1},
+        },
+        GidMappings: []syscall.SysProcIDMap{
+            {ContainerID: gid, HostID: gid, Size: 1},
+        },
+        Credential:                 &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), NoSetGroups: true},
+        GidMappingsEnableSetgroups: false,
+        AmbientCaps:                []uintptr{capSysAdmin},
+        Setpgid:                    true,
+        Pdeathsig:                  syscall.SIGKILL,
+    }, nil
+}
+
+// sandboxIDs membaca uid/gid khusus untuk proses student (EXECUTOR_UID/EXECUTOR_GID)
+// Sandbox menolak berjalan jika tidak dikonfigurasi atau server tidak berjalan sebagai root,
+// karena tanpa itu code student berjalan dengan uid server dan bisa membaca file server
+func sandboxIDs() (int, int, error) {
+    uid, err := strconv.Atoi(os.Getenv("EXECUTOR_UID"))
+    if err != nil || uid <= 0 {
+        return 0, 0, errors.New("sandbox belum dikonfigurasi: EXECUTOR_UID harus diisi uid khusus (bukan root)")
+    }
+    gid, err := strconv.Atoi(os.Getenv("EXECUTOR_GID"))
+    if err != nil || gid <= 0 {
+        return 0, 0, errors.New("sandbox belum dikonfigurasi: EXECUTOR_GID harus diisi gid khusus (bukan root)")
+    }
+    if os.Getuid() != 0 {
+        return 0, 0, errors.New("sandbox butuh server berjalan sebagai root untuk berpindah ke EXECUTOR_UID")
+    }
+    return uid, gid, nil
+}
+
+// CheckSandbox memastikan sandbox bisa dipakai (dipanggil saat startup)
+func CheckSandbox() error {
+    _, _, err := sandboxIDs()
+    return err
+}
+
+// prepareWorkspace memberikan workspace ke user sandbox dan menyiapkan
+// mount point untuk root filesystem sandbox
+func prepareWorkspace(dir string) error {
+    uid, gid, err := sandboxIDs()
+    if err != nil {
+        return err
+    }
+    if err := os.Mkdir(sandboxRootDir(dir), 0755); err != nil {
+        return err
+    }
+    return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+        if err != nil {
+            return err
+        }
+        return os.Lchown(path, uid, gid)
+    })
+}
+
+// sandboxRootDir adalah mount point root filesystem sandbox (di sebelah workspace)
+func sandboxRootDir(dir string) string {
+    return filepath.Join(filepath.Dir(dir), "rootfs")
+}
+
+// killProcessGroup membunuh proses student beserta semua child-nya
+// (proses pertama di PID namespace baru, jadi semua child ikut mati)
+func killProcessGroup(cmd *exec.Cmd) {
+    if cmd.Process == nil {
+        return
+    }
+    syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+    cmd.Process.Kill()
+}
+
+// cpuLimitSignal mengecek apakah proses dihentikan SIGXCPU (soft limit RLIMIT_CPU)
+func cpuLimitSignal(exitErr *exec.ExitError) bool {
+    status, ok := exitErr.Sys().(syscall.WaitStatus)
+    return ok && status.Signaled() && status.Signal() == syscall.SIGXCPU
+}
+
+// ═══════════════════════════════════════════════════════════
+// SANDBOX HELPER (berjalan di dalam namespace baru)
+// ═══════════════════════════════════════════════════════════
+
+// sandboxInit menyiapkan filesystem & limits lalu exec program student
+// Hanya kembali jika terjadi error
+func sandboxInit(args []string) error {
+    if len(args) < 7 || args[5] != "--" {
+        return errors.New("argumen helper tidak valid")
+    }
+    dir := args[0]
+    argv := args[6:]
+
+    limits := make([]uint64, 4)
+    for i := range limits {
+        v, err := strconv.ParseUint(args[i+1], 10, 64)
+        if err != nil {
+            return errors.New("limit tidak valid: " + args[i+1])
+        }
+        limits[i] = v
+    }
+
+    // Working directory server ikut disembunyikan jika berada di dalam EXECUTOR_ROOTFS
+    serverDir, _ := os.Getwd()
+
+    if err := buildRootfs(sandboxRootDir(dir), dir, serverDir); err != nil {
+        return err
+    }
+    if err := os.Chdir(dir); err != nil {
+        return errors.New("gagal masuk workspace: " + err.Error())
+    }
+
+    rlimits := []struct {
+        resource int
+        value    uint64
+    }{
+        {syscall.RLIMIT_CPU, limits[0]},
+        {syscall.RLIMIT_AS, limits[1]},
+        {syscall.RLIMIT_FSIZE, limits[2]},
+        {rlimitNproc, limits[3]},
+    }
+    for _, rl := range rlimits {
+        if err := syscall.Setrlimit(rl.resource, &syscall.Rlimit{Cur: rl.value, Max: rl.value}); err != nil {
+            return fmt.Errorf("gagal set rlimit %d: %s", rl.resource, err.Error())
+        }
+    }
+
+    // Program student tidak boleh mendapat capability apa pun (termasuk lewat setuid/file caps)
+    if err := prctl(prSetNoNewPrivs, 1, 0); err != nil {
+        return errors.New("gagal set no_new_privs: " + err.Error())
+    }
+    if err := prctl(prCapAmbient, prCapAmbientClearAll, 0); err != nil {
+        return errors.New("gagal membuang capability: " + err.Error())
+    }
+
+    path, err := exec.LookPath(argv[0])
+    if err != nil {
+        return errors.New("program tidak ditemukan: " + argv[0])
+    }
+    return syscall.Exec(path, argv, os.Environ())
+}
+
+// buildRootfs membangun root filesystem sandbox di newRoot lalu pivot_root ke sana
+// Isi: EXECUTOR_ROOTFS (read-only), workspace (read-write), /dev minimal, /proc baru
+func buildRootfs(newRoot string, workDir string, serverDir string) error {
+    // Mount di namespace ini tidak boleh bocor ke host
+    if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+        return errors.New("gagal set mount private: " + err.Error())
+    }
+    if err := syscall.Mount("tmpfs", newRoot, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755,size=16m"); err != nil {
+        return errors.New("gagal mount root sandbox: " + err.Error())
+    }
+
+    dirs := os.Getenv("EXECUTOR_ROOTFS")
+    if dirs == "" {
+        dirs = defaultRootfsDirs
+    }
+    for _, src := range filepath.SplitList(dirs) {
+        if err := bindHostPath(newRoot, src); err != nil {
+            return err
+        }
+    }
+
+    // Sembunyikan direktori server (misal /opt/primmfy berisi .env) jika ikut ter-bind
+    if serverDir != "" && serverDir != "/" {
+        if info, err := os.Stat(filepath.Join(newRoot, serverDir)); err == nil && info.IsDir() {
+            if err := syscall.Mount("tmpfs", filepath.Join(newRoot, serverDir), "tmpfs", syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, "size=4k"); err != nil {
+                return errors.New("gagal menyembunyikan direktori server: " + err.Error())
+            }
+        }
+    }
+
+    // Workspace di path yang sama dengan di host (HOME/TMPDIR menunjuk ke sini)
+    workTarget := filepath.Join(newRoot, workDir)
+    if err := os.MkdirAll(workTarget, 0755); err != nil {
+        return errors.New("gagal membuat workspace sandbox: " + err.Error())
+    }
+    if err := syscall.Mount(workDir, workTarget, "", syscall.MS_BIND|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+        return errors.New("gagal mount workspace: " + err.Error())
+    }
+
+    if err := buildDev(filepath.Join(newRoot, "dev")); err != nil {
+        return err
+    }
+
+    // /proc baru hanya berisi proses di PID namespace sandbox
+    procDir := filepath.Join(newRoot, "proc")
+    if err := os.Mkdir(procDir, 0555); err != nil {
+        return errors.New("gagal membuat /proc: " + err.Error())
+    }
+    if err := syscall.Mount("proc", procDir, "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+        return errors.New("gagal mount /proc: " + err.Error())
+    }
+
+    // pivot_root lalu lepas root lama agar filesystem host tidak bisa diakses lagi
+    oldRoot := filepath.Join(newRoot, ".oldroot")
+    if err := os.Mkdir(oldRoot, 0700); err != nil {
+        return errors.New("gagal membuat mount point root lama: " + err.Error())
+    }
+    if err := syscall.PivotRoot(newRoot, oldRoot); err != nil {
+        return errors.New("gagal pivot_root: " + err.Error())
+    }
+    if err := os.Chdir("/"); err != nil {
+        return err
+    }
+    if err := syscall.Unmount("/.oldroot", syscall.MNT_DETACH); err != nil {
+        return errors.New("gagal melepas root lama: " + err.Error())
+    }
+    if err := os.Remove("/.oldroot"); err != nil {
+        return errors.New("gagal menghapus mount point root lama: " + err.Error())
+    }
+
+    // Root tmpfs sendiri read-only, yang bisa ditulis hanya workspace
+    if err := syscall.Mount("", "/", "", syscall.MS_BIND|syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+        return errors.New("gagal remount root read-only: " + err.Error())
+    }
+    return nil
+}
+
+// bindHostPath mem-bind direktori host ke root sandbox secara read-only
+// Symlink (misal /bin -> usr/bin) dibuat ulang sebagai symlink
+func bindHostPath(newRoot string, src string) error {
+    info, err := os.Lstat(src)
+    if err != nil {
+        if os.IsNotExist(err) {
+            return nil
+        }
+        return errors.New("gagal membaca " + src + ": " + err.Error())
+    }
+
+    target := filepath.Join(newRoot, src)
+    if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+        return errors.New("gagal membuat " + target + ": " + err.Error())
+    }
+
+    if info.Mode()&os.ModeSymlink != 0 {
+        link, err := os.Readlink(src)
+        if err != nil {
+            return errors.New("gagal membaca symlink " + src + ": " + err.Error())
+        }
+        if err := os.Symlink(link, target); err != nil && !os.IsExist(err) {
+            return errors.New("gagal membuat symlink " + src + ": " + err.Error())
+        }
+        return nil
+    }
+
+    if err := os.MkdirAll(target, 0755); err != nil {
+        return errors.New("gagal membuat " + target + ": " + err.Error())
+    }
+    if err := syscall.Mount(src, target, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+        return errors.New("gagal bind " + src + ": " + err.Error())
+    }
+    return remountReadOnly(target)
+}
+
+// remountReadOnly menjadikan bind mount read-only
+// Flag yang terkunci dari mount asal (nosuid, nodev, dll) wajib ikut disertakan
+func remountReadOnly(target string) error {
+    var st syscall.Statfs_t
+    if err := syscall.Statfs(target, &st); err != nil {
+        return errors.New("gagal statfs " + target + ": " + err.Error())
+    }
+
+    flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_NOSUID)
+    locked := []struct {
+        st    int64
+        mount uintptr
+    }{
+        {stNoDev, syscall.MS_NODEV},
+        {stNoExec, syscall.MS_NOEXEC},
+        {stNoAtime, syscall.MS_NOATIME},
+        {stNoDirAtime, syscall.MS_NODIRATIME},
+        {stRelAtime, syscall.MS_RELATIME},
+    }
+    for _, f := range locked {
+        if int64(st.Flags)&f.st != 0 {
+            flags |= f.mount
+        }
+    }
+
+    if err := syscall.Mount("", target, "", flags, ""); err != nil {
+        return errors.New("gagal remount read-only " + target + ": " + err.Error())
+    }
+    return nil
+}
+
+// buildDev membuat /dev minimal (null, zero, urandom, dll) tanpa device lain dari host
+func buildDev(devDir string) error {
+    if err := os.Mkdir(devDir, 0755); err != nil {
+        return errors.New("gagal membuat /dev: " + err.Error())
+    }
+    if err := syscall.Mount("tmpfs", devDir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NOEXEC, "mode=0755,size=64k"); err != nil {
+        return errors.New("gagal mount /dev: " + err.Error())
+    }
+
+    for _, name := range sandboxDevices {
+        target := filepath.Join(devDir, name)
+        f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0666)
+        if err != nil {
+            return errors.New("gagal membuat /dev/" + name + ": " + err.Error())
+        }
+        f.Close()
+        if err := syscall.Mount("/dev/"+name, target, "", syscall.MS_BIND, ""); err != nil {
+            return errors.New("gagal bind /dev/" + name + ": " + err.Error())
+        }
+    }
+
+    links := map[string]string{
+        "fd":     "/proc/self/fd",
+        "stdin":  "/proc/self/fd/0",
+        "stdout": "/proc/self/fd/1",
+        "stderr": "/proc/self/fd/2",
+    }
+    for name, link := range links {
+        if err := os.Symlink(link, filepath.Join(devDir, name)); err != nil {
+            return errors.New("gagal membuat /dev/" + name + ": " + err.Error())
+        }
+    }
+
+    if err := syscall.Mount("", devDir, "", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NOEXEC, "mode=0755,size=64k"); err != nil {
+        return errors.New("gagal remount /dev read-only: " + err.Error())
+    }
+    return nil
+}
+
+// prctl memanggil prctl(2) pada thread saat ini
+func prctl(option uintptr, arg2 uintptr, arg3 uintptr) error {
+    if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, option, arg2, arg3, 0, 0, 0); errno != 0 {
+        return errno
+    }
+    return nil
+}
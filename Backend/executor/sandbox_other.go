@@ -0,0 +1,45 @@
+//go:build unix && !linux
+
+package executor
+
+import (
+    "os/exec"
+    "syscall"
+)
+
+// sandboxCommand di non-Linux hanya memasang ulimit & memisahkan process group
+// PERINGATAN: tidak ada isolasi user/filesystem/network, hanya untuk development lokal
+// (ditolak kecuali EXECUTOR_ALLOW_UNSANDBOXED=true)
+func sandboxCommand(dir string, argv []string, limits Limits) (*exec.Cmd, error) {
+    if err := checkUnsandboxed(); err != nil {
+        return nil, err
+    }
+    cmd := shellCommand(dir, argv, limits)
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+    return cmd, nil
+}
+
+// CheckSandbox gagal kecuali eksekusi tanpa sandbox diizinkan eksplisit (development lokal)
+func CheckSandbox() error {
+    return checkUnsandboxed()
+}
+
+// prepareWorkspace: tidak ada user sandbox terpisah di non-Linux
+func prepareWorkspace(dir string) error {
+    return nil
+}
+
+// killProcessGroup membunuh proses student beserta semua child-nya
+func killProcessGroup(cmd *exec.Cmd) {
+    if cmd.Process == nil {
+        return
+    }
+    syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+    cmd.Process.Kill()
+}
+
+// cpuLimitSignal mengecek apakah proses dihentikan SIGXCPU (soft limit RLIMIT_CPU)
+func cpuLimitSignal(exitErr *exec.ExitError) bool {
+    status, ok := exitErr.Sys().(syscall.WaitStatus)
+    return ok && status.Signaled() && status.Signal() == syscall.SIGXCPU
+}
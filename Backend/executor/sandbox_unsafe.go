@@ -0,0 +1,20 @@
+//go:build !linux
+
+package executor
+
+import (
+    "errors"
+    "os"
+    "runtime"
+)
+
+// checkUnsandboxed menolak eksekusi code student di platform tanpa sandbox Linux
+// (tidak ada isolasi user/filesystem/network, code berjalan sebagai user server)
+// kecuali EXECUTOR_ALLOW_UNSANDBOXED=true di-set untuk development lokal
+func checkUnsandboxed() error {
+    if os.Getenv("EXECUTOR_ALLOW_UNSANDBOXED") != "true" {
+        return errors.New("sandbox tidak didukung di " + runtime.GOOS +
+            ": jalankan backend di Linux, atau set EXECUTOR_ALLOW_UNSANDBOXED=true khusus development lokal")
+    }
+    return nil
+}
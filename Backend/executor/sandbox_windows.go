@@ -0,0 +1,40 @@
+//go:build windows
+
+package executor
+
+import (
+    "os/exec"
+)
+
+// sandboxCommand: sandbox tidak didukung di Windows (butuh /bin/sh & rlimit)
+// Jalankan backend di Linux/WSL untuk eksekusi kode student
+// (ditolak kecuali EXECUTOR_ALLOW_UNSANDBOXED=true untuk development lokal)
+func sandboxCommand(dir string, argv []string, limits Limits) (*exec.Cmd, error) {
+    if err := checkUnsandboxed(); err != nil {
+        return nil, err
+    }
+    return shellCommand(dir, argv, limits), nil
+}
+
+// CheckSandbox gagal kecuali eksekusi tanpa sandbox diizinkan eksplisit (development lokal)
+func CheckSandbox() error {
+    return checkUnsandboxed()
+}
+
+// prepareWorkspace: tidak ada user sandbox terpisah di Windows
+func prepareWorkspace(dir string) error {
+    return nil
+}
+
+// killProcessGroup membunuh proses student
+func killProcessGroup(cmd *exec.Cmd) {
+    if cmd.Process == nil {
+        return
+    }
+    cmd.Process.Kill()
+}
+
+// cpuLimitSignal selalu false karena signal & rlimit tidak ada di Windows
+func cpuLimitSignal(exitErr *exec.ExitError) bool {
+    return false
+}
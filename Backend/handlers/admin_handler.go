@@ -0,0 +1,204 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/services"
+)
+
+// AdminHandler mengelola endpoint khusus admin (invite, role & lockout user)
+type AdminHandler struct {
+    DB      *pgxpool.Pool
+    Limiter *services.LoginLimiter
+}
+
+// NewAdminHandler membuat instance AdminHandler baru
+func NewAdminHandler(db *pgxpool.Pool, limiter *services.LoginLimiter) *AdminHandler {
+    return &AdminHandler{DB: db, Limiter: limiter}
+}
+
+// CreateInvite handler untuk POST /api/admin/invites (admin only)
+// Purpose: Membuat invite code sekali pakai untuk registrasi teacher/admin
+func (h *AdminHandler) CreateInvite(c *gin.Context) {
+    adminID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.CreateInviteRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    createdBy := adminID.(int)
+    invite, err := services.CreateRoleInvite(c.Request.Context(), h.DB, &createdBy, req)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message": "Invite berhasil dibuat! Code hanya ditampilkan sekali.",
+        "invite":  invite,
+    })
+}
+
+// GetInvites handler untuk GET /api/admin/invites (admin only)
+// Purpose: Melihat semua invite beserta status pemakaiannya
+func (h *AdminHandler) GetInvites(c *gin.Context) {
+    invites, err := services.GetRoleInvites(c.Request.Context(), h.DB)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "invites": invites,
+        "count":   len(invites),
+    })
+}
+
+// RevokeInvite handler untuk DELETE /api/admin/invites/:id (admin only)
+// Purpose: Membatalkan invite yang belum dipakai
+func (h *AdminHandler) RevokeInvite(c *gin.Context) {
+    inviteID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invite ID tidak valid"})
+        return
+    }
+
+    err = services.RevokeRoleInvite(c.Request.Context(), h.DB, inviteID)
+    if err != nil {
+        if err.Error() == "invite tidak ditemukan atau sudah dipakai" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Invite berhasil dibatalkan!",
+    })
+}
+
+// UpdateUserRole handler untuk PUT /api/admin/users/:id/role (admin only)
+// Purpose: Promote / demote role user (dicatat di audit log)
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+        return
+    }
+
+    adminID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.UpdateRoleRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    user, err := services.UpdateUserRole(c.Request.Context(), h.DB, adminID.(int), userID, req)
+    if err != nil {
+        switch err.Error() {
+        case "user tidak ditemukan":
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        case "tidak bisa mengubah role sendiri", "user sudah memiliki role " + req.Role:
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Role user berhasil diubah!",
+        "user":    user,
+    })
+}
+
+// GetRoleAuditLog handler untuk GET /api/admin/role-audit (admin only)
+// Purpose: Riwayat perubahan role (filter opsional: ?user_id=)
+func (h *AdminHandler) GetRoleAuditLog(c *gin.Context) {
+    userID := 0
+    if param := c.Query("user_id"); param != "" {
+        id, err := strconv.Atoi(param)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+            return
+        }
+        userID = id
+    }
+
+    entries, err := services.GetRoleAuditLog(c.Request.Context(), h.DB, userID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "entries": entries,
+        "count":   len(entries),
+    })
+}
+
+// UnlockUser handler untuk POST /api/admin/users/:id/unlock (admin only)
+// Purpose: Membuka kunci akun yang terkunci karena terlalu banyak login gagal
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+    userID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+        return
+    }
+
+    user, err := services.GetUserByID(c.Request.Context(), h.DB, userID)
+    if err != nil {
+        if err.Error() == "user tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    if err := h.Limiter.UnlockAccount(c.Request.Context(), user.Email); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Akun berhasil dibuka!",
+        "user_id": user.ID,
+    })
+}
+
+// UnlockIP handler untuk POST /api/admin/unlock-ip (admin only)
+// Purpose: Membuka kunci IP yang terkunci karena terlalu banyak login gagal
+func (h *AdminHandler) UnlockIP(c *gin.Context) {
+    var req models.UnlockIPRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    if err := h.Limiter.UnlockIP(c.Request.Context(), req.IP); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "IP berhasil dibuka!",
+        "ip":      req.IP,
+    })
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/services"
+)
+
+// AssignmentHandler mengelola endpoint assignment (deadline lesson / course per kelas)
+type AssignmentHandler struct {
+    DB *pgxpool.Pool
+}
+
+// NewAssignmentHandler membuat instance AssignmentHandler baru
+func NewAssignmentHandler(db *pgxpool.Pool) *AssignmentHandler {
+    return &AssignmentHandler{DB: db}
+}
+
+// respondAssignmentError memetakan error service assignment ke HTTP status
+func respondAssignmentError(c *gin.Context, err error) {
+    switch {
+    case err.Error() == "assignment tidak ditemukan", err.Error() == "classroom tidak ditemukan",
+        err.Error() == "course tidak ditemukan":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case services.IsPermissionDenied(err):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case err.Error() == "isi salah satu: lesson_id atau course_id",
+        err.Error() == "open_at harus sebelum due_at",
+        err.Error() == "late_penalty_percent wajib diisi (1-100) untuk late_policy penalize",
+        err.Error() == "lesson belum di-assign ke classroom ini":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// CreateAssignment handler untuk POST /api/classrooms/:id/assignments (teacher kelas)
+// Purpose: Memberi deadline & late policy untuk lesson / course yang di-assign ke kelas
+func (h *AssignmentHandler) CreateAssignment(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.CreateAssignmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    assignment, err := services.CreateAssignment(c.Request.Context(), h.DB, actor.(services.Actor), classroomID, req)
+    if err != nil {
+        respondAssignmentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":    "Assignment berhasil dibuat!",
+        "assignment": assignment,
+    })
+}
+
+// GetClassroomAssignments handler untuk GET /api/classrooms/:id/assignments (teacher kelas)
+// Purpose: Melihat semua assignment kelas (urut dari deadline terdekat)
+func (h *AssignmentHandler) GetClassroomAssignments(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    assignments, err := services.GetClassroomAssignments(c.Request.Context(), h.DB, actor.(services.Actor), classroomID)
+    if err != nil {
+        respondAssignmentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "assignments": assignments,
+        "count":       len(assignments),
+    })
+}
+
+// UpdateAssignment handler untuk PUT /api/assignments/:id (teacher kelas)
+// Purpose: Mengubah jadwal (open_at / due_at) atau late policy assignment
+func (h *AssignmentHandler) UpdateAssignment(c *gin.Context) {
+    assignmentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Assignment ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.UpdateAssignmentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    assignment, err := services.UpdateAssignment(c.Request.Context(), h.DB, actor.(services.Actor), assignmentID, req)
+    if err != nil {
+        respondAssignmentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":    "Assignment berhasil diupdate!",
+        "assignment": assignment,
+    })
+}
+
+// DeleteAssignment handler untuk DELETE /api/assignments/:id (teacher kelas)
+// Purpose: Menghapus deadline (student bisa submit tanpa batas waktu lagi)
+func (h *AssignmentHandler) DeleteAssignment(c *gin.Context) {
+    assignmentID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Assignment ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    if err := services.DeleteAssignment(c.Request.Context(), h.DB, actor.(services.Actor), assignmentID); err != nil {
+        respondAssignmentError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Assignment berhasil dihapus!"})
+}
+
+// GetMyAssignments handler untuk GET /api/my-assignments (student)
+// Purpose: Melihat assignment dari semua kelas yang diikuti
+func (h *AssignmentHandler) GetMyAssignments(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    assignments, err := services.GetMyAssignments(c.Request.Context(), h.DB, userID.(int))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "assignments": assignments,
+        "count":       len(assignments),
+    })
+}
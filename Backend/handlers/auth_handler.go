@@ -1,26 +1,34 @@
 package handlers
 
 import (
+    "errors"
+    "log"
+    "math"
     "net/http"
+    "strconv"
+    "strings"
 
     "github.com/gin-gonic/gin"
-    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/mailer"
     "primmfy_db/models"
     "primmfy_db/services"
 )
 
 // AuthHandler mengelola endpoint authentication
 type AuthHandler struct {
-    DB *pgx.Conn
+    DB      *pgxpool.Pool
+    Mailer  mailer.Mailer
+    Limiter *services.LoginLimiter
 }
 
 // NewAuthHandler membuat instance AuthHandler baru
-func NewAuthHandler(db *pgx.Conn) *AuthHandler {
-    return &AuthHandler{DB: db}
+func NewAuthHandler(db *pgxpool.Pool, m mailer.Mailer, limiter *services.LoginLimiter) *AuthHandler {
+    return &AuthHandler{DB: db, Mailer: m, Limiter: limiter}
 }
 
 // Register handler untuk POST /api/register
-// Purpose: Mendaftarkan user baru (student/teacher/admin)
+// Purpose: Mendaftarkan user baru (student, atau teacher/admin dengan invite code)
 func (h *AuthHandler) Register(c *gin.Context) {
     var req models.RegisterRequest
 
@@ -33,26 +41,38 @@ func (h *AuthHandler) Register(c *gin.Context) {
     }
 
     // 2. Register user via service
-    user, err := services.Register(h.DB, req)
+    user, err := services.Register(c.Request.Context(), h.DB, req)
     if err != nil {
         // Cek error type untuk response code yang sesuai
-        if err.Error() == "email sudah terdaftar" {
+        switch err.Error() {
+        case "email sudah terdaftar":
             c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-        } else {
+        case "registrasi teacher/admin membutuhkan invite code":
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        case "invite code tidak valid", "invite code sudah dipakai", "invite code sudah expired",
+            "role tidak sesuai dengan invite code":
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        default:
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         }
         return
     }
 
-    // 3. Return success response
+    // 3. Kirim link verifikasi email (gagal kirim tidak membatalkan registrasi,
+    //    user bisa minta ulang lewat POST /api/email/resend-verification)
+    if err := services.SendVerificationEmail(c.Request.Context(), h.DB, h.Mailer, user.ID); err != nil {
+        log.Printf("⚠️  Gagal mengirim email verifikasi ke %s: %v\n", user.Email, err)
+    }
+
+    // 4. Return success response
     c.JSON(http.StatusCreated, gin.H{
-        "message": "User berhasil didaftarkan!",
+        "message": "User berhasil didaftarkan! Cek email untuk link verifikasi.",
         "user":    user,
     })
 }
 
 // Login handler untuk POST /api/login
-// Purpose: Login user dan return JWT token
+// Purpose: Login user dan return access token + refresh token
 func (h *AuthHandler) Login(c *gin.Context) {
     var req models.LoginRequest
 
@@ -64,11 +84,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
         return
     }
 
-    // 2. Login via service (validate credentials & generate JWT)
-    response, err := services.Login(h.DB, req)
+    // 2. Login via service (validate credentials & buat session)
+    client := services.ClientInfo{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+    response, err := services.Login(c.Request.Context(), h.DB, h.Limiter, req, client)
     if err != nil {
-        // Semua login error return 401 Unauthorized
-        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        // Terlalu banyak login gagal -> 429 + Retry-After
+        var blocked *services.LoginBlockedError
+        if errors.As(err, &blocked) {
+            c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+            c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+            return
+        }
+
+        // Email belum diverifikasi (UNVERIFIED_LOGIN=block) -> 403, error lain 401
+        if err.Error() == "email belum diverifikasi" {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -87,7 +120,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
     }
 
     // Get user details from database
-    user, err := services.GetUserByID(h.DB, userID.(int))
+    user, err := services.GetUserByID(c.Request.Context(), h.DB, userID.(int))
     if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
         return
@@ -96,4 +129,171 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{
         "user": user,
     })
-}
\ No newline at end of file
+}
+
+// RefreshToken handler untuk POST /api/token/refresh
+// Purpose: Menukar refresh token dengan access token + refresh token baru
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+    var req models.RefreshTokenRequest
+
+    // 1. Bind & validate JSON request
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Data tidak valid: " + err.Error(),
+        })
+        return
+    }
+
+    // 2. Rotate refresh token via service
+    tokens, err := services.RefreshSession(c.Request.Context(), h.DB, req.RefreshToken)
+    if err != nil {
+        if strings.HasPrefix(err.Error(), "gagal") {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, tokens)
+}
+
+// Logout handler untuk POST /api/logout (protected route)
+// Purpose: Mencabut session yang sedang dipakai (access & refresh token tidak berlaku lagi)
+func (h *AuthHandler) Logout(c *gin.Context) {
+    // User ID & session ID sudah di-set oleh AuthMiddleware
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+    sessionID, _ := c.Get("session_id")
+
+    if err := services.RevokeSession(c.Request.Context(), h.DB, userID.(int), sessionID.(int64)); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Logout berhasil!",
+    })
+}
+
+// LogoutAll handler untuk POST /api/logout/all (protected route)
+// Purpose: Logout dari semua device (mencabut semua session user)
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    revoked, err := services.RevokeUserSessions(c.Request.Context(), h.DB, userID.(int))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":          "Logout dari semua device berhasil!",
+        "revoked_sessions": revoked,
+    })
+}
+
+// ═══════════════════════════════════════════════════════════
+// EMAIL VERIFICATION & PASSWORD RESET
+// ═══════════════════════════════════════════════════════════
+
+// VerifyEmail handler untuk POST /api/email/verify
+// Purpose: Verifikasi email dengan token dari link email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+    var req models.VerifyEmailRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    user, err := services.VerifyEmail(c.Request.Context(), h.DB, req.Token)
+    if err != nil {
+        if err.Error() == "token tidak valid atau sudah expired" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Email berhasil diverifikasi!",
+        "user":    user,
+    })
+}
+
+// ResendVerification handler untuk POST /api/email/resend-verification (protected route)
+// Purpose: Mengirim ulang link verifikasi ke email user yang login
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    err := services.SendVerificationEmail(c.Request.Context(), h.DB, h.Mailer, userID.(int))
+    if err != nil {
+        switch err.Error() {
+        case "email sudah diverifikasi":
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        case "user tidak ditemukan":
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Link verifikasi sudah dikirim ulang!",
+    })
+}
+
+// ForgotPassword handler untuk POST /api/password/forgot
+// Purpose: Mengirim link reset password (response sama walau email tidak terdaftar)
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+    var req models.ForgotPasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    if err := services.RequestPasswordReset(c.Request.Context(), h.DB, h.Mailer, req.Email); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Jika email terdaftar, link reset password sudah dikirim.",
+    })
+}
+
+// ResetPassword handler untuk POST /api/password/reset
+// Purpose: Mengganti password dengan token dari link email (logout semua device)
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+    var req models.ResetPasswordRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    if err := services.ResetPassword(c.Request.Context(), h.DB, req); err != nil {
+        if err.Error() == "token tidak valid atau sudah expired" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Password berhasil diganti! Silakan login ulang.",
+    })
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/services"
+)
+
+// ClassroomHandler mengelola endpoint kelas (teacher) dan keanggotaan kelas (student)
+type ClassroomHandler struct {
+    DB *pgxpool.Pool
+}
+
+// NewClassroomHandler membuat instance ClassroomHandler baru
+func NewClassroomHandler(db *pgxpool.Pool) *ClassroomHandler {
+    return &ClassroomHandler{DB: db}
+}
+
+// respondClassroomError memetakan error service classroom ke HTTP status
+func respondClassroomError(c *gin.Context, err error) {
+    switch {
+    case err.Error() == "classroom tidak ditemukan", err.Error() == "lesson tidak ditemukan",
+        err.Error() == "student tidak ditemukan di classroom ini",
+        err.Error() == "lesson tidak di-assign ke classroom ini":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case services.IsPermissionDenied(err):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case err.Error() == "lesson tidak aktif":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case err.Error() == "lesson sudah di-assign ke classroom ini":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// ═══════════════════════════════════════════════════════════
+// TEACHER ENDPOINTS
+// ═══════════════════════════════════════════════════════════
+
+// CreateClassroom handler untuk POST /api/classrooms (teacher)
+// Purpose: Membuat kelas baru, join code di-generate otomatis
+func (h *ClassroomHandler) CreateClassroom(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.CreateClassroomRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    classroom, err := services.CreateClassroom(c.Request.Context(), h.DB, userID.(int), req)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":   "Classroom berhasil dibuat!",
+        "classroom": classroom,
+    })
+}
+
+// GetTeacherClassrooms handler untuk GET /api/classrooms (teacher)
+// Purpose: Melihat semua kelas milik teacher
+func (h *ClassroomHandler) GetTeacherClassrooms(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    classrooms, err := services.GetClassroomsByTeacher(c.Request.Context(), h.DB, userID.(int))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "classrooms": classrooms,
+        "count":      len(classrooms),
+    })
+}
+
+// GetClassroomDetail handler untuk GET /api/classrooms/:id (teacher kelas)
+// Purpose: Melihat detail kelas beserta roster student dan lesson yang di-assign
+func (h *ClassroomHandler) GetClassroomDetail(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    detail, err := services.GetClassroomDetail(c.Request.Context(), h.DB, actor.(services.Actor), classroomID)
+    if err != nil {
+        respondClassroomError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"classroom": detail})
+}
+
+// UpdateClassroom handler untuk PUT /api/classrooms/:id (teacher kelas)
+// Purpose: Mengubah nama / deskripsi kelas atau menonaktifkan kelas
+func (h *ClassroomHandler) UpdateClassroom(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.UpdateClassroomRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    classroom, err := services.UpdateClassroom(c.Request.Context(), h.DB, actor.(services.Actor), classroomID, req)
+    if err != nil {
+        respondClassroomError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":   "Classroom berhasil diupdate!",
+        "classroom": classroom,
+    })
+}
+
+// RegenerateJoinCode handler untuk POST /api/classrooms/:id/join-code (teacher kelas)
+// Purpose: Mengganti join code (misal code lama tersebar ke luar kelas)
+func (h *ClassroomHandler) RegenerateJoinCode(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    classroom, err := services.RegenerateJoinCode(c.Request.Context(), h.DB, actor.(services.Actor), classroomID)
+    if err != nil {
+        respondClassroomError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":   "Join code berhasil diganti!",
+        "classroom": classroom,
+    })
+}
+
+// AssignLesson handler untuk POST /api/classrooms/:id/lessons (teacher kelas)
+// Purpose: Assign lesson ke kelas dan enroll semua student di roster
+func (h *ClassroomHandler) AssignLesson(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.AssignLessonRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    enrolled, err := services.AssignLessonToClassroom(c.Request.Context(), h.DB, actor.(services.Actor), classroomID, req.LessonID)
+    if err != nil {
+        respondClassroomError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":        "Lesson berhasil di-assign ke classroom!",
+        "enrolled_count": enrolled,
+    })
+}
+
+// UnassignLesson handler untuk DELETE /api/classrooms/:id/lessons/:lesson_id (teacher kelas)
+// Purpose: Menghapus lesson dari kelas (enrollment & progress student tetap ada)
+func (h *ClassroomHandler) UnassignLesson(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    lessonID, err := strconv.Atoi(c.Param("lesson_id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    err = services.UnassignLessonFromClassroom(c.Request.Context(), h.DB, actor.(services.Actor), classroomID, lessonID)
+    if err != nil {
+        respondClassroomError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Lesson berhasil dihapus dari classroom!"})
+}
+
+// RemoveMember handler untuk DELETE /api/classrooms/:id/members/:user_id (teacher kelas)
+// Purpose: Mengeluarkan student dari roster kelas
+func (h *ClassroomHandler) RemoveMember(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    userID, err := strconv.Atoi(c.Param("user_id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    err = services.RemoveClassroomMember(c.Request.Context(), h.DB, actor.(services.Actor), classroomID, userID)
+    if err != nil {
+        respondClassroomError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Student berhasil dikeluarkan dari classroom!"})
+}
+
+// ═══════════════════════════════════════════════════════════
+// STUDENT ENDPOINTS
+// ═══════════════════════════════════════════════════════════
+
+// JoinClassroom handler untuk POST /api/classrooms/join (student)
+// Purpose: Bergabung ke kelas dengan join code, otomatis enroll ke lesson kelas
+func (h *ClassroomHandler) JoinClassroom(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.JoinClassroomRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    classroom, err := services.JoinClassroom(c.Request.Context(), h.DB, userID.(int), req.JoinCode)
+    if err != nil {
+        switch err.Error() {
+        case "join code tidak valid":
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        case "classroom tidak aktif":
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        case "anda sudah bergabung di classroom ini":
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":   "Berhasil bergabung ke classroom!",
+        "classroom": classroom,
+    })
+}
+
+// GetMyClassrooms handler untuk GET /api/my-classrooms (student)
+// Purpose: Melihat semua kelas yang diikuti
+func (h *ClassroomHandler) GetMyClassrooms(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    classrooms, err := services.GetMyClassrooms(c.Request.Context(), h.DB, userID.(int))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "classrooms": classrooms,
+        "count":      len(classrooms),
+    })
+}
+
+// LeaveClassroom handler untuk DELETE /api/my-classrooms/:id (student)
+// Purpose: Keluar dari kelas (enrollment & progress lesson tetap ada)
+func (h *ClassroomHandler) LeaveClassroom(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    if err := services.LeaveClassroom(c.Request.Context(), h.DB, userID.(int), classroomID); err != nil {
+        if err.Error() == "anda bukan member classroom ini" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Berhasil keluar dari classroom!"})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/services"
+)
+
+// CollaboratorHandler mengelola endpoint co-teacher (collaborator lesson)
+type CollaboratorHandler struct {
+    DB *pgxpool.Pool
+}
+
+// NewCollaboratorHandler membuat instance CollaboratorHandler baru
+func NewCollaboratorHandler(db *pgxpool.Pool) *CollaboratorHandler {
+    return &CollaboratorHandler{DB: db}
+}
+
+// InviteCollaborator handler untuk POST /api/lessons/:id/collaborators (owner lesson)
+// Purpose: Mengundang teacher lain sebagai owner / editor / viewer
+func (h *CollaboratorHandler) InviteCollaborator(c *gin.Context) {
+    lessonID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.InviteCollaboratorRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    collaborator, err := services.InviteCollaborator(c.Request.Context(), h.DB, actor.(services.Actor), lessonID, req)
+    if err != nil {
+        switch {
+        case err.Error() == "lesson tidak ditemukan", err.Error() == "user tidak ditemukan":
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        case services.IsPermissionDenied(err):
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        case err.Error() == "tidak bisa mengundang diri sendiri",
+            err.Error() == "collaborator harus teacher atau admin":
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        case err.Error() == "user sudah menjadi collaborator lesson ini":
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":      "Undangan collaborator berhasil dikirim!",
+        "collaborator": collaborator,
+    })
+}
+
+// GetCollaborators handler untuk GET /api/lessons/:id/collaborators (collaborator lesson)
+// Purpose: Melihat semua collaborator lesson beserta undangan yang belum diterima
+func (h *CollaboratorHandler) GetCollaborators(c *gin.Context) {
+    lessonID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    collaborators, err := services.GetCollaborators(c.Request.Context(), h.DB, actor.(services.Actor), lessonID)
+    if err != nil {
+        if err.Error() == "lesson tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "collaborators": collaborators,
+        "count":         len(collaborators),
+    })
+}
+
+// GetMyInvites handler untuk GET /api/collaborator-invites (teacher)
+// Purpose: Melihat undangan collaborator yang belum diterima
+func (h *CollaboratorHandler) GetMyInvites(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    invites, err := services.GetMyCollaboratorInvites(c.Request.Context(), h.DB, userID.(int))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "invites": invites,
+        "count":   len(invites),
+    })
+}
+
+// AcceptInvite handler untuk POST /api/lessons/:id/collaborators/accept (teacher)
+// Purpose: Menerima undangan collaborator
+func (h *CollaboratorHandler) AcceptInvite(c *gin.Context) {
+    lessonID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    collaborator, err := services.AcceptCollaboratorInvite(c.Request.Context(), h.DB, userID.(int), lessonID)
+    if err != nil {
+        if err.Error() == "undangan tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":      "Undangan berhasil diterima!",
+        "collaborator": collaborator,
+    })
+}
+
+// RemoveCollaborator handler untuk DELETE /api/lessons/:id/collaborators/:user_id
+// Purpose: Owner menghapus collaborator / membatalkan undangan,
+// atau collaborator keluar sendiri / menolak undangan (user_id = diri sendiri)
+func (h *CollaboratorHandler) RemoveCollaborator(c *gin.Context) {
+    lessonID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    userID, err := strconv.Atoi(c.Param("user_id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    err = services.RemoveCollaborator(c.Request.Context(), h.DB, actor.(services.Actor), lessonID, userID)
+    if err != nil {
+        switch {
+        case err.Error() == "lesson tidak ditemukan", err.Error() == "collaborator tidak ditemukan":
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        case services.IsPermissionDenied(err):
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        case err.Error() == "lesson harus memiliki minimal satu owner":
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        default:
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Collaborator berhasil dihapus!"})
+}
@@ -5,18 +5,18 @@ import (
     "strconv"
 
     "github.com/gin-gonic/gin"
-    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
     "primmfy_db/models"
     "primmfy_db/services"
 )
 
 // CourseHandler mengelola endpoint course (Sub-topic dalam Lesson)
 type CourseHandler struct {
-    DB *pgx.Conn
+    DB *pgxpool.Pool
 }
 
 // NewCourseHandler membuat instance CourseHandler baru
-func NewCourseHandler(db *pgx.Conn) *CourseHandler {
+func NewCourseHandler(db *pgxpool.Pool) *CourseHandler {
     return &CourseHandler{DB: db}
 }
 
@@ -27,7 +27,7 @@ func NewCourseHandler(db *pgx.Conn) *CourseHandler {
 // CreateCourse handler untuk POST /api/courses (teacher only)
 // Purpose: Teacher membuat course baru dalam lesson
 func (h *CourseHandler) CreateCourse(c *gin.Context) {
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -39,9 +39,15 @@ func (h *CourseHandler) CreateCourse(c *gin.Context) {
         return
     }
 
-    course, err := services.CreateCourse(h.DB, teacherID.(int), req)
+    course, err := services.CreateCourse(c.Request.Context(), h.DB, actor.(services.Actor), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "lesson tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -61,7 +67,7 @@ func (h *CourseHandler) GetCourseByID(c *gin.Context) {
     }
 
     // Get course with all PRIMM stages
-    course, stages, err := services.GetCourseWithStages(h.DB, courseID)
+    course, stages, err := services.GetCourseWithStages(c.Request.Context(), h.DB, courseID)
     if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
         return
@@ -82,7 +88,7 @@ func (h *CourseHandler) UpdateCourse(c *gin.Context) {
         return
     }
 
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -94,11 +100,11 @@ func (h *CourseHandler) UpdateCourse(c *gin.Context) {
         return
     }
 
-    course, err := services.UpdateCourse(h.DB, courseID, teacherID.(int), req)
+    course, err := services.UpdateCourse(c.Request.Context(), h.DB, courseID, actor.(services.Actor), req)
     if err != nil {
         if err.Error() == "course tidak ditemukan" {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-        } else if err.Error() == "anda tidak memiliki akses untuk mengupdate course ini" {
+        } else if services.IsPermissionDenied(err) {
             c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -120,17 +126,17 @@ func (h *CourseHandler) DeleteCourse(c *gin.Context) {
         return
     }
 
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
     }
 
-    err = services.DeleteCourse(h.DB, courseID, teacherID.(int))
+    err = services.DeleteCourse(c.Request.Context(), h.DB, courseID, actor.(services.Actor))
     if err != nil {
         if err.Error() == "course tidak ditemukan" {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-        } else if err.Error() == "anda tidak memiliki akses untuk menghapus course ini" {
+        } else if services.IsPermissionDenied(err) {
             c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -151,7 +157,7 @@ func (h *CourseHandler) GetStagesInCourse(c *gin.Context) {
     }
 
     // 2. Get stages via service
-    stages, err := services.GetStagesByCourse(h.DB, courseID)
+    stages, err := services.GetStagesByCourse(c.Request.Context(), h.DB, courseID)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
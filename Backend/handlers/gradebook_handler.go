@@ -0,0 +1,189 @@
+package handlers
+
+import (
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/services"
+    "primmfy_db/spreadsheet"
+)
+
+// GradebookHandler mengelola endpoint gradebook & dashboard analytics teacher
+type GradebookHandler struct {
+    DB *pgxpool.Pool
+}
+
+// NewGradebookHandler membuat instance GradebookHandler baru
+func NewGradebookHandler(db *pgxpool.Pool) *GradebookHandler {
+    return &GradebookHandler{DB: db}
+}
+
+// respondGradebookError memetakan error service gradebook ke HTTP status
+func respondGradebookError(c *gin.Context, err error) {
+    switch {
+    case err.Error() == "lesson tidak ditemukan", err.Error() == "classroom tidak ditemukan",
+        err.Error() == "course tidak ditemukan", err.Error() == "lesson tidak di-assign ke classroom ini":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case services.IsPermissionDenied(err):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// GetTeacherDashboard handler untuk GET /api/teacher-dashboard (teacher)
+// Purpose: Ringkasan progress semua lesson (enrolled, selesai, stuck) dan kelas teacher
+func (h *GradebookHandler) GetTeacherDashboard(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    dashboard, err := services.GetTeacherDashboard(c.Request.Context(), h.DB, userID.(int))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, dashboard)
+}
+
+// GetLessonGradebook handler untuk GET /api/lessons/:id/gradebook (owner / collaborator lesson)
+// Purpose: Matrix student × stage lesson
+// Query: classroom_id, search, status, course_id, sort, order, page, limit
+func (h *GradebookHandler) GetLessonGradebook(c *gin.Context) {
+    lessonID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var query models.GradebookQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Query tidak valid: " + err.Error()})
+        return
+    }
+
+    gradebook, err := services.GetGradebook(c.Request.Context(), h.DB, actor.(services.Actor), lessonID, query)
+    if err != nil {
+        respondGradebookError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gradebook)
+}
+
+// GetClassroomGradebook handler untuk GET /api/classrooms/:id/gradebook?lesson_id= (teacher kelas)
+// Purpose: Matrix student × stage untuk member kelas di satu lesson yang di-assign
+// Query lain sama dengan GET /api/lessons/:id/gradebook
+func (h *GradebookHandler) GetClassroomGradebook(c *gin.Context) {
+    classroomID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Classroom ID tidak valid"})
+        return
+    }
+
+    lessonID, err := strconv.Atoi(c.Query("lesson_id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "lesson_id wajib diisi"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var query models.GradebookQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Query tidak valid: " + err.Error()})
+        return
+    }
+    query.ClassroomID = classroomID
+
+    gradebook, err := services.GetGradebook(c.Request.Context(), h.DB, actor.(services.Actor), lessonID, query)
+    if err != nil {
+        respondGradebookError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gradebook)
+}
+
+// ExportGradebookCSV handler untuk GET /api/lessons/:id/gradebook.csv (owner / collaborator lesson)
+// Purpose: Download gradebook lesson sebagai CSV (satu baris per student)
+// Query: sama dengan GET /api/lessons/:id/gradebook (tanpa pagination)
+func (h *GradebookHandler) ExportGradebookCSV(c *gin.Context) {
+    h.exportGradebook(c, "csv")
+}
+
+// ExportGradebookXLSX handler untuk GET /api/lessons/:id/gradebook.xlsx (owner / collaborator lesson)
+// Purpose: Download gradebook lesson sebagai file Excel (satu baris per student)
+// Query: sama dengan GET /api/lessons/:id/gradebook (tanpa pagination)
+func (h *GradebookHandler) ExportGradebookXLSX(c *gin.Context) {
+    h.exportGradebook(c, "xlsx")
+}
+
+// exportGradebook mengecek akses lalu men-stream gradebook ke response
+// Setelah header terkirim, error hanya bisa di-log (status code sudah 200)
+func (h *GradebookHandler) exportGradebook(c *gin.Context, format string) {
+    lessonID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Lesson ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var query models.GradebookQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Query tidak valid: " + err.Error()})
+        return
+    }
+
+    gradebook, err := services.PrepareGradebookExport(c.Request.Context(), h.DB, actor.(services.Actor), lessonID, query)
+    if err != nil {
+        respondGradebookError(c, err)
+        return
+    }
+
+    c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="gradebook-lesson-%d.%s"`, lessonID, format))
+
+    var writer spreadsheet.Writer
+    if format == "xlsx" {
+        c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+        c.Status(http.StatusOK)
+        writer, err = spreadsheet.NewXLSXWriter(c.Writer, gradebook.LessonTitle)
+        if err != nil {
+            log.Printf("⚠️ Gagal export gradebook lesson %d: %v", lessonID, err)
+            return
+        }
+    } else {
+        c.Header("Content-Type", "text/csv; charset=utf-8")
+        c.Status(http.StatusOK)
+        // BOM agar Excel membaca CSV sebagai UTF-8
+        c.Writer.WriteString("\uFEFF")
+        writer = spreadsheet.NewCSVWriter(c.Writer)
+    }
+
+    if err := services.WriteGradebook(c.Request.Context(), h.DB, gradebook, query, writer); err != nil {
+        log.Printf("⚠️ Gagal export gradebook lesson %d: %v", lessonID, err)
+    }
+}
@@ -5,18 +5,18 @@ import (
     "strconv"
 
     "github.com/gin-gonic/gin"
-    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
     "primmfy_db/models"
     "primmfy_db/services"
 )
 
 // LessonHandler mengelola endpoint lesson (Big Topic)
 type LessonHandler struct {
-    DB *pgx.Conn
+    DB *pgxpool.Pool
 }
 
 // NewLessonHandler membuat instance LessonHandler baru
-func NewLessonHandler(db *pgx.Conn) *LessonHandler {
+func NewLessonHandler(db *pgxpool.Pool) *LessonHandler {
     return &LessonHandler{DB: db}
 }
 
@@ -42,7 +42,7 @@ func (h *LessonHandler) CreateLesson(c *gin.Context) {
     }
 
     // 3. Create lesson via service
-    lesson, err := services.CreateLesson(h.DB, teacherID.(int), req)
+    lesson, err := services.CreateLesson(c.Request.Context(), h.DB, teacherID.(int), req)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -57,7 +57,7 @@ func (h *LessonHandler) CreateLesson(c *gin.Context) {
 // GetAllLessons handler untuk GET /api/lessons (public)
 // Purpose: Menampilkan semua lesson yang aktif
 func (h *LessonHandler) GetAllLessons(c *gin.Context) {
-    lessons, err := services.GetAllLessons(h.DB)
+    lessons, err := services.GetAllLessons(c.Request.Context(), h.DB)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -79,7 +79,7 @@ func (h *LessonHandler) GetLessonByID(c *gin.Context) {
     }
 
     // Get lesson with all courses
-    lesson, courses, err := services.GetLessonWithCourses(h.DB, lessonID)
+    lesson, courses, err := services.GetLessonWithCourses(c.Request.Context(), h.DB, lessonID)
     if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
         return
@@ -101,7 +101,7 @@ func (h *LessonHandler) GetMyLessons(c *gin.Context) {
         return
     }
 
-    lessons, err := services.GetLessonsByTeacher(h.DB, teacherID.(int))
+    lessons, err := services.GetLessonsByTeacher(c.Request.Context(), h.DB, teacherID.(int))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -121,7 +121,7 @@ func (h *LessonHandler) UpdateLesson(c *gin.Context) {
         return
     }
 
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -133,11 +133,11 @@ func (h *LessonHandler) UpdateLesson(c *gin.Context) {
         return
     }
 
-    lesson, err := services.UpdateLesson(h.DB, lessonID, teacherID.(int), req)
+    lesson, err := services.UpdateLesson(c.Request.Context(), h.DB, lessonID, actor.(services.Actor), req)
     if err != nil {
         if err.Error() == "lesson tidak ditemukan" {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-        } else if err.Error() == "anda tidak memiliki akses untuk mengupdate lesson ini" {
+        } else if services.IsPermissionDenied(err) {
             c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -159,17 +159,17 @@ func (h *LessonHandler) DeleteLesson(c *gin.Context) {
         return
     }
 
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
     }
 
-    err = services.DeleteLesson(h.DB, lessonID, teacherID.(int))
+    err = services.DeleteLesson(c.Request.Context(), h.DB, lessonID, actor.(services.Actor))
     if err != nil {
         if err.Error() == "lesson tidak ditemukan" {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-        } else if err.Error() == "anda tidak memiliki akses untuk menghapus lesson ini" {
+        } else if services.IsPermissionDenied(err) {
             c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -199,7 +199,7 @@ func (h *LessonHandler) EnrollLesson(c *gin.Context) {
         return
     }
 
-    enrollment, err := services.EnrollLesson(h.DB, userID.(int), lessonID)
+    enrollment, err := services.EnrollLesson(c.Request.Context(), h.DB, userID.(int), lessonID)
     if err != nil {
         if err.Error() == "lesson tidak ditemukan" || err.Error() == "lesson tidak aktif" {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -226,7 +226,7 @@ func (h *LessonHandler) GetMyEnrolledLessons(c *gin.Context) {
         return
     }
 
-    lessons, err := services.GetMyEnrolledLessons(h.DB, userID.(int))
+    lessons, err := services.GetMyEnrolledLessons(c.Request.Context(), h.DB, userID.(int))
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -254,7 +254,7 @@ func (h *LessonHandler) GetCoursesInLesson(c *gin.Context) {
 
     if hasAuth {
         // User authenticated - return courses dengan progress
-        courses, err := services.GetCoursesByLessonWithProgress(h.DB, lessonID, userID.(int))
+        courses, err := services.GetCoursesByLessonWithProgress(c.Request.Context(), h.DB, lessonID, userID.(int))
         if err != nil {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
             return
@@ -262,7 +262,7 @@ func (h *LessonHandler) GetCoursesInLesson(c *gin.Context) {
         result = gin.H{"courses": courses}
     } else {
         // User not authenticated - return courses tanpa progress
-        courses, err := services.GetCoursesByLesson(h.DB, lessonID)
+        courses, err := services.GetCoursesByLesson(c.Request.Context(), h.DB, lessonID)
         if err != nil {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
             return
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -8,17 +9,18 @@ import (
 	"primmfy_db/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // PRIMMStageHandler mengelola endpoint PRIMM stages
 type PRIMMStageHandler struct {
-    DB *pgx.Conn
+    DB    *pgxpool.Pool
+    Queue *services.GradingQueue
 }
 
 // NewPRIMMStageHandler membuat instance PRIMMStageHandler baru
-func NewPRIMMStageHandler(db *pgx.Conn) *PRIMMStageHandler {
-    return &PRIMMStageHandler{DB: db}
+func NewPRIMMStageHandler(db *pgxpool.Pool, queue *services.GradingQueue) *PRIMMStageHandler {
+    return &PRIMMStageHandler{DB: db, Queue: queue}
 }
 
 // ═══════════════════════════════════════════════════════════
@@ -27,7 +29,7 @@ func NewPRIMMStageHandler(db *pgx.Conn) *PRIMMStageHandler {
 
 // CreatePredictStage handler untuk POST /api/stages/predict (teacher only)
 func (h *PRIMMStageHandler) CreatePredictStage(c *gin.Context) {
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -39,9 +41,15 @@ func (h *PRIMMStageHandler) CreatePredictStage(c *gin.Context) {
         return
     }
 
-    stage, err := services.CreatePredictStage(h.DB, teacherID.(int), req)
+    stage, err := services.CreatePredictStage(c.Request.Context(), h.DB, actor.(services.Actor), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "course tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -53,7 +61,7 @@ func (h *PRIMMStageHandler) CreatePredictStage(c *gin.Context) {
 
 // CreateRunStage handler untuk POST /api/stages/run (teacher only)
 func (h *PRIMMStageHandler) CreateRunStage(c *gin.Context) {
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -65,9 +73,15 @@ func (h *PRIMMStageHandler) CreateRunStage(c *gin.Context) {
         return
     }
 
-    stage, err := services.CreateRunStage(h.DB, teacherID.(int), req)
+    stage, err := services.CreateRunStage(c.Request.Context(), h.DB, actor.(services.Actor), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "course tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -79,7 +93,7 @@ func (h *PRIMMStageHandler) CreateRunStage(c *gin.Context) {
 
 // CreateInvestigateStage handler untuk POST /api/stages/investigate (teacher only)
 func (h *PRIMMStageHandler) CreateInvestigateStage(c *gin.Context) {
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -91,9 +105,15 @@ func (h *PRIMMStageHandler) CreateInvestigateStage(c *gin.Context) {
         return
     }
 
-    stage, err := services.CreateInvestigateStage(h.DB, teacherID.(int), req)
+    stage, err := services.CreateInvestigateStage(c.Request.Context(), h.DB, actor.(services.Actor), req)
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        if err.Error() == "course tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -105,7 +125,7 @@ func (h *PRIMMStageHandler) CreateInvestigateStage(c *gin.Context) {
 
 // CreateModifyStage handler untuk POST /api/stages/modify (teacher only)
 func (h *PRIMMStageHandler) CreateModifyStage(c *gin.Context) {
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -117,9 +137,15 @@ func (h *PRIMMStageHandler) CreateModifyStage(c *gin.Context) {
         return
     }
 
-    stage, err := services.CreateModifyStage(h.DB, teacherID.(int), req)
+    stage, err := services.CreateModifyStage(c.Request.Context(), h.DB, actor.(services.Actor), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "course tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -131,7 +157,7 @@ func (h *PRIMMStageHandler) CreateModifyStage(c *gin.Context) {
 
 // CreateMakeStage handler untuk POST /api/stages/make (teacher only)
 func (h *PRIMMStageHandler) CreateMakeStage(c *gin.Context) {
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
@@ -143,9 +169,15 @@ func (h *PRIMMStageHandler) CreateMakeStage(c *gin.Context) {
         return
     }
 
-    stage, err := services.CreateMakeStage(h.DB, teacherID.(int), req)
+    stage, err := services.CreateMakeStage(c.Request.Context(), h.DB, actor.(services.Actor), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "course tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsPermissionDenied(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -167,7 +199,7 @@ func (h *PRIMMStageHandler) GetStageByID(c *gin.Context) {
         return
     }
 
-    stage, err := services.GetStageByID(h.DB, stageID)
+    stage, err := services.GetStageByID(c.Request.Context(), h.DB, stageID)
     if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
         return
@@ -184,17 +216,17 @@ func (h *PRIMMStageHandler) DeleteStage(c *gin.Context) {
         return
     }
 
-    teacherID, exists := c.Get("user_id")
+    actor, exists := c.Get("actor")
     if !exists {
         c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
         return
     }
 
-    err = services.DeleteStage(h.DB, stageID, teacherID.(int))
+    err = services.DeleteStage(c.Request.Context(), h.DB, stageID, actor.(services.Actor))
     if err != nil {
         if err.Error() == "stage tidak ditemukan" {
             c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-        } else if err.Error() == "anda tidak memiliki akses untuk menghapus stage ini" {
+        } else if services.IsPermissionDenied(err) {
             c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
         } else {
             c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -206,6 +238,7 @@ func (h *PRIMMStageHandler) DeleteStage(c *gin.Context) {
 }
 
 // SubmitStage handler untuk POST /api/stages/:id/submit
+// MODIFY & MAKE tidak dinilai di request ini, tapi masuk grading queue (202 + status_url)
 func (h *PRIMMStageHandler) SubmitStage(c *gin.Context) {
     // 1. Parse stage ID
     stageID, err := strconv.Atoi(c.Param("id"))
@@ -228,14 +261,45 @@ func (h *PRIMMStageHandler) SubmitStage(c *gin.Context) {
         return
     }
 
-    // 4. Submit via service
-    submission, err := services.SubmitStage(h.DB, userID.(int), stageID, req)
+    // 4. Code MODIFY/MAKE dijalankan di grading queue, client polling GET /api/submissions/:id
+    if req.SubmissionType == "modify" || req.SubmissionType == "make" {
+        code := req.ModifiedCode
+        if req.SubmissionType == "make" {
+            code = req.Code
+        }
+
+        queued, err := h.Queue.Enqueue(c.Request.Context(), userID.(int), stageID, req.SubmissionType, code)
+        if err != nil {
+            if err.Error() == req.SubmissionType+" stage tidak ditemukan" {
+                c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+            } else if services.IsSubmissionWindowClosed(err) {
+                c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+            } else {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            }
+            return
+        }
+
+        c.JSON(http.StatusAccepted, gin.H{
+            "message":    "Submission diterima dan sedang dinilai",
+            "submission": queued,
+            "status_url": fmt.Sprintf("/api/submissions/%d", queued.ID),
+        })
+        return
+    }
+
+    // 5. Submit via service
+    submission, err := services.SubmitStage(c.Request.Context(), h.DB, userID.(int), stageID, req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if services.IsSubmissionWindowClosed(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
-    // 5. Return response
+    // 6. Return response
     c.JSON(http.StatusCreated, gin.H{
         "message":    "Jawaban berhasil disubmit!",
         "is_correct": submission.IsCorrect,
@@ -1,23 +1,31 @@
 package handlers
 
 import (
+    "fmt"
+    "io"
     "net/http"
     "strconv"
+    "time"
 
     "github.com/gin-gonic/gin"
-    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
     "primmfy_db/models"
     "primmfy_db/services"
 )
 
 // ProgressHandler mengelola endpoint student progress & submissions
 type ProgressHandler struct {
-    DB *pgx.Conn
+    DB    *pgxpool.Pool
+    Queue *services.GradingQueue
 }
 
+// sseHeartbeat adalah interval ping SSE sekaligus pengecekan ulang status di DB
+// (untuk submission yang di-grade oleh instance server lain)
+const sseHeartbeat = 15 * time.Second
+
 // NewProgressHandler membuat instance ProgressHandler baru
-func NewProgressHandler(db *pgx.Conn) *ProgressHandler {
-    return &ProgressHandler{DB: db}
+func NewProgressHandler(db *pgxpool.Pool, queue *services.GradingQueue) *ProgressHandler {
+    return &ProgressHandler{DB: db, Queue: queue}
 }
 
 // ═══════════════════════════════════════════════════════════
@@ -51,9 +59,13 @@ func (h *ProgressHandler) SubmitPredictStage(c *gin.Context) {
     req.StageID = stageID
 
     // Process submission
-    response, err := services.SubmitPredictStage(h.DB, userID.(int), req)
+    response, err := services.SubmitPredictStage(c.Request.Context(), h.DB, userID.(int), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if services.IsSubmissionWindowClosed(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -83,9 +95,13 @@ func (h *ProgressHandler) SubmitRunStage(c *gin.Context) {
 
     req.StageID = stageID
 
-    response, err := services.SubmitRunStage(h.DB, userID.(int), req)
+    response, err := services.SubmitRunStage(c.Request.Context(), h.DB, userID.(int), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if services.IsSubmissionWindowClosed(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -93,7 +109,7 @@ func (h *ProgressHandler) SubmitRunStage(c *gin.Context) {
 }
 
 // SubmitInvestigateStage handler untuk POST /api/stages/:id/submit-investigate (student only)
-// Purpose: Siswa submit refleksi di INVESTIGATE stage
+// Purpose: Siswa submit refleksi di INVESTIGATE stage (masuk review queue teacher)
 func (h *ProgressHandler) SubmitInvestigateStage(c *gin.Context) {
     userID, exists := c.Get("user_id")
     if !exists {
@@ -115,9 +131,13 @@ func (h *ProgressHandler) SubmitInvestigateStage(c *gin.Context) {
 
     req.StageID = stageID
 
-    response, err := services.SubmitInvestigateStage(h.DB, userID.(int), req)
+    response, err := services.SubmitInvestigateStage(c.Request.Context(), h.DB, userID.(int), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if services.IsSubmissionWindowClosed(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
@@ -125,7 +145,7 @@ func (h *ProgressHandler) SubmitInvestigateStage(c *gin.Context) {
 }
 
 // SubmitModifyStage handler untuk POST /api/stages/:id/submit-modify (student only)
-// Purpose: Siswa submit modified code dengan test case validation
+// Purpose: Siswa submit modified code, dinilai asynchronous oleh grading queue
 func (h *ProgressHandler) SubmitModifyStage(c *gin.Context) {
     userID, exists := c.Get("user_id")
     if !exists {
@@ -147,17 +167,28 @@ func (h *ProgressHandler) SubmitModifyStage(c *gin.Context) {
 
     req.StageID = stageID
 
-    response, err := services.SubmitModifyStage(h.DB, userID.(int), req)
+    // Code dijalankan di grading queue, client polling GET /api/submissions/:id
+    submission, err := h.Queue.Enqueue(c.Request.Context(), userID.(int), req.StageID, "modify", req.SubmittedCode)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "modify stage tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsSubmissionWindowClosed(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
-    c.JSON(http.StatusOK, response)
+    c.JSON(http.StatusAccepted, gin.H{
+        "message":    "Submission diterima dan sedang dinilai",
+        "submission": submission,
+        "status_url": fmt.Sprintf("/api/submissions/%d", submission.ID),
+    })
 }
 
 // SubmitMakeStage handler untuk POST /api/stages/:id/submit-make (student only)
-// Purpose: Siswa submit original code dari scratch dengan test case validation
+// Purpose: Siswa submit original code dari scratch, dinilai asynchronous oleh grading queue
 func (h *ProgressHandler) SubmitMakeStage(c *gin.Context) {
     userID, exists := c.Get("user_id")
     if !exists {
@@ -179,13 +210,132 @@ func (h *ProgressHandler) SubmitMakeStage(c *gin.Context) {
 
     req.StageID = stageID
 
-    response, err := services.SubmitMakeStage(h.DB, userID.(int), req)
+    // Code dijalankan di grading queue, client polling GET /api/submissions/:id
+    submission, err := h.Queue.Enqueue(c.Request.Context(), userID.(int), req.StageID, "make", req.SubmittedCode)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        if err.Error() == "make stage tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else if services.IsSubmissionWindowClosed(err) {
+            c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
         return
     }
 
-    c.JSON(http.StatusOK, response)
+    c.JSON(http.StatusAccepted, gin.H{
+        "message":    "Submission diterima dan sedang dinilai",
+        "submission": submission,
+        "status_url": fmt.Sprintf("/api/submissions/%d", submission.ID),
+    })
+}
+
+// GetSubmission handler untuk GET /api/submissions/:id
+// Purpose: Polling status submission (pending -> running -> graded)
+func (h *ProgressHandler) GetSubmission(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    submissionID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Submission ID tidak valid"})
+        return
+    }
+
+    submission, err := services.GetSubmission(c.Request.Context(), h.DB, submissionID, userID.(int))
+    if err != nil {
+        if err.Error() == "submission tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"submission": submission})
+}
+
+// StreamSubmissionEvents handler untuk GET /api/submissions/:id/events (protected)
+// Purpose: Stream perubahan status & hasil per test case via Server-Sent Events
+// Event: "status" (pending/running/graded/failed), "test_result", "ping"
+// Stream ditutup setelah submission graded/failed
+func (h *ProgressHandler) StreamSubmissionEvents(c *gin.Context) {
+    userID, exists := c.Get("user_id")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    submissionID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Submission ID tidak valid"})
+        return
+    }
+
+    // Subscribe sebelum membaca status agar tidak ada event yang terlewat
+    events, unsubscribe := h.Queue.Events().Subscribe(submissionID)
+    defer unsubscribe()
+
+    submission, err := services.GetSubmission(c.Request.Context(), h.DB, submissionID, userID.(int))
+    if err != nil {
+        if err.Error() == "submission tidak ditemukan" {
+            c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+        } else {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        }
+        return
+    }
+
+    c.Header("Cache-Control", "no-cache")
+    c.Header("Connection", "keep-alive")
+    c.Header("X-Accel-Buffering", "no")
+
+    // Status saat ini langsung dikirim sebagai event pertama
+    c.SSEvent(services.SubmissionEventStatus, services.SubmissionEvent{
+        Type:         services.SubmissionEventStatus,
+        SubmissionID: submission.ID,
+        Status:       submission.Status,
+        Submission:   submission,
+    })
+    c.Writer.Flush()
+
+    if services.IsSubmissionFinished(submission.Status) {
+        return
+    }
+
+    ticker := time.NewTicker(sseHeartbeat)
+    defer ticker.Stop()
+
+    c.Stream(func(w io.Writer) bool {
+        select {
+        case <-c.Request.Context().Done():
+            return false
+
+        case event := <-events:
+            c.SSEvent(event.Type, event)
+            return !(event.Type == services.SubmissionEventStatus && services.IsSubmissionFinished(event.Status))
+
+        case <-ticker.C:
+            latest, err := services.GetSubmission(c.Request.Context(), h.DB, submissionID, userID.(int))
+            if err != nil {
+                return false
+            }
+            if services.IsSubmissionFinished(latest.Status) {
+                c.SSEvent(services.SubmissionEventStatus, services.SubmissionEvent{
+                    Type:         services.SubmissionEventStatus,
+                    SubmissionID: latest.ID,
+                    Status:       latest.Status,
+                    Submission:   latest,
+                })
+                return false
+            }
+            c.SSEvent("ping", gin.H{"status": latest.Status})
+            return true
+        }
+    })
 }
 
 // ═══════════════════════════════════════════════════════════
@@ -207,7 +357,7 @@ func (h *ProgressHandler) GetMyProgress(c *gin.Context) {
         return
     }
 
-    progress, err := services.GetMyProgress(h.DB, userID.(int), lessonID)
+    progress, err := services.GetMyProgress(c.Request.Context(), h.DB, userID.(int), lessonID)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
@@ -220,7 +370,7 @@ func (h *ProgressHandler) GetMyProgress(c *gin.Context) {
 }
 
 // GetStageCompletion handler untuk GET /api/stages/:id/my-completion (student only)
-// Purpose: Siswa melihat submission history mereka di stage tertentu
+// Purpose: Siswa melihat submission history mereka di stage tertentu beserta feedback teacher
 func (h *ProgressHandler) GetStageCompletion(c *gin.Context) {
     userID, exists := c.Get("user_id")
     if !exists {
@@ -243,7 +393,7 @@ func (h *ProgressHandler) GetStageCompletion(c *gin.Context) {
                investigate_reflection, investigate_completed,
                modify_submitted_code, modify_output, modify_is_correct, modify_attempts,
                make_submitted_code, make_output, make_is_correct, make_attempts,
-               is_completed, completed_at, created_at, updated_at
+               COALESCE(score, 0), is_completed, completed_at, created_at, updated_at
         FROM user_stage_completions
         WHERE user_id = $1 AND stage_id = $2`,
         userID, stageID).Scan(
@@ -255,7 +405,7 @@ func (h *ProgressHandler) GetStageCompletion(c *gin.Context) {
         &completion.ModifyIsCorrect, &completion.ModifyAttempts,
         &completion.MakeSubmittedCode, &completion.MakeOutput,
         &completion.MakeIsCorrect, &completion.MakeAttempts,
-        &completion.IsCompleted, &completion.CompletedAt,
+        &completion.Score, &completion.IsCompleted, &completion.CompletedAt,
         &completion.CreatedAt, &completion.UpdatedAt)
 
     if err != nil {
@@ -268,8 +418,77 @@ func (h *ProgressHandler) GetStageCompletion(c *gin.Context) {
         return
     }
 
+    feedback, err := services.GetMyStageFeedback(c.Request.Context(), h.DB, userID.(int), stageID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Status review refleksi INVESTIGATE (nil untuk stage lain)
+    review, err := services.GetMyReflectionReview(c.Request.Context(), h.DB, userID.(int), stageID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
     c.JSON(http.StatusOK, gin.H{
         "completion": completion,
+        "feedback":   feedback, // Feedback teacher per attempt (line_number = komentar per baris)
+        "review":     review,
+    })
+}
+
+// GetMyAttempts handler untuk GET /api/stages/:id/my-attempts (protected)
+// Purpose: Riwayat semua attempt di stage (code, output, verdict, waktu submit)
+// Student melihat attempt miliknya sendiri. Attempt student lain lewat query
+// ?user_id= butuh permission submission:view_all pada lesson stage ini
+func (h *ProgressHandler) GetMyAttempts(c *gin.Context) {
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    stageID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Stage ID tidak valid"})
+        return
+    }
+
+    targetUserID := actor.(services.Actor).UserID
+    if raw := c.Query("user_id"); raw != "" {
+        targetUserID, err = strconv.Atoi(raw)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+            return
+        }
+
+        if targetUserID != actor.(services.Actor).UserID {
+            resource := services.Resource{Type: services.ResourceStage, ID: stageID}
+            if err := services.Authorize(c.Request.Context(), h.DB, actor.(services.Actor), services.PermSubmissionViewAll, resource); err != nil {
+                if err.Error() == "stage tidak ditemukan" {
+                    c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+                } else if services.IsPermissionDenied(err) {
+                    c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+                } else {
+                    c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                }
+                return
+            }
+        }
+    }
+
+    attempts, err := services.GetStageAttempts(c.Request.Context(), h.DB, stageID, targetUserID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "stage_id": stageID,
+        "user_id":  targetUserID,
+        "total":    len(attempts),
+        "attempts": attempts,
     })
 }
 
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+    "log"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/oidc"
+    "primmfy_db/services"
+)
+
+// SSOHandler mengelola login lewat OIDC identity provider
+type SSOHandler struct {
+    DB       *pgxpool.Pool
+    Provider *oidc.Provider // nil = SSO tidak dikonfigurasi
+}
+
+// NewSSOHandler membuat instance SSOHandler baru
+func NewSSOHandler(db *pgxpool.Pool, provider *oidc.Provider) *SSOHandler {
+    return &SSOHandler{DB: db, Provider: provider}
+}
+
+// Login handler untuk GET /api/auth/oidc/login
+// Purpose: Redirect user ke halaman login identity provider (authorization code + PKCE)
+func (h *SSOHandler) Login(c *gin.Context) {
+    if h.Provider == nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "SSO tidak dikonfigurasi"})
+        return
+    }
+
+    authURL, state, err := services.StartOIDCLogin(c.Request.Context(), h.DB, h.Provider)
+    if err != nil {
+        c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+        return
+    }
+
+    // State diikat ke browser ini, dicek lagi di callback
+    h.setStateCookie(c, state, int(services.OIDCStateTTL.Seconds()))
+    c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback handler untuk GET /api/auth/oidc/callback
+// Purpose: Menyelesaikan login dari identity provider lalu redirect ke frontend
+// (/oidc/callback#token=...&refresh_token=...&expires_in=... atau #error=...)
+func (h *SSOHandler) Callback(c *gin.Context) {
+    if h.Provider == nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "SSO tidak dikonfigurasi"})
+        return
+    }
+
+    // Cookie state sekali pakai, langsung dihapus apa pun hasilnya
+    stateCookie, _ := c.Cookie(services.OIDCStateCookie)
+    h.setStateCookie(c, "", -1)
+
+    // User membatalkan login / provider menolak request
+    if providerError := c.Query("error"); providerError != "" {
+        message := providerError
+        if description := c.Query("error_description"); description != "" {
+            message += ": " + description
+        }
+        c.Redirect(http.StatusFound, services.OIDCFrontendRedirect(url.Values{"error": {message}}))
+        return
+    }
+
+    code := c.Query("code")
+    state := c.Query("state")
+    if code == "" || state == "" {
+        c.Redirect(http.StatusFound, services.OIDCFrontendRedirect(url.Values{"error": {"code atau state tidak ditemukan"}}))
+        return
+    }
+
+    client := services.ClientInfo{UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+    response, err := services.CompleteOIDCLogin(c.Request.Context(), h.DB, h.Provider, code, state, stateCookie, client)
+    if err != nil {
+        log.Printf("⚠️ Login SSO gagal: %v", err)
+        c.Redirect(http.StatusFound, services.OIDCFrontendRedirect(url.Values{"error": {err.Error()}}))
+        return
+    }
+
+    c.Redirect(http.StatusFound, services.OIDCFrontendRedirect(url.Values{
+        "token":         {response.Token},
+        "refresh_token": {response.RefreshToken},
+        "expires_in":    {strconv.Itoa(response.ExpiresIn)},
+    }))
+}
+
+// setStateCookie menyimpan (maxAge -1 = menghapus) cookie state login SSO
+// SameSite=Lax agar cookie tetap terkirim saat provider redirect kembali ke callback
+func (h *SSOHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+    secure := strings.HasPrefix(h.Provider.RedirectURL, "https://")
+    c.SetSameSite(http.SameSiteLaxMode)
+    c.SetCookie(services.OIDCStateCookie, state, maxAge, "/api/auth/oidc", "", secure, true)
+}
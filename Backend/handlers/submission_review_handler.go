@@ -0,0 +1,285 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/services"
+)
+
+// SubmissionReviewHandler mengelola endpoint review submission student, feedback teacher
+// dan review queue refleksi INVESTIGATE (rubric)
+type SubmissionReviewHandler struct {
+    DB *pgxpool.Pool
+}
+
+// NewSubmissionReviewHandler membuat instance SubmissionReviewHandler baru
+func NewSubmissionReviewHandler(db *pgxpool.Pool) *SubmissionReviewHandler {
+    return &SubmissionReviewHandler{DB: db}
+}
+
+// respondReviewError memetakan error service submission review ke HTTP status
+func respondReviewError(c *gin.Context, err error) {
+    switch {
+    case err.Error() == "stage tidak ditemukan", err.Error() == "attempt tidak ditemukan",
+        err.Error() == "feedback tidak ditemukan", err.Error() == "student tidak terdaftar di lesson ini",
+        err.Error() == "lesson tidak ditemukan", err.Error() == "review tidak ditemukan":
+        c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+    case services.IsPermissionDenied(err):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case err.Error() == "line_number melebihi jumlah baris submission",
+        err.Error() == "rubric hanya untuk INVESTIGATE stage",
+        err.Error() == "score wajib diisi untuk stage tanpa rubric",
+        err.Error() == "rubric_scores harus diisi untuk setiap kriteria rubric",
+        err.Error() == "poin rubric melebihi max_points kriteria":
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case err.Error() == "refleksi sudah direview":
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+// GetStageSubmissions handler untuk GET /api/stages/:id/submissions (owner / collaborator lesson)
+// Purpose: Daftar student yang sudah submit di stage (attempt terakhir, score terbaik, jumlah feedback)
+func (h *SubmissionReviewHandler) GetStageSubmissions(c *gin.Context) {
+    stageID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Stage ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    submissions, err := services.GetStageSubmissions(c.Request.Context(), h.DB, actor.(services.Actor), stageID)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "stage_id":    stageID,
+        "submissions": submissions,
+        "count":       len(submissions),
+    })
+}
+
+// GetStudentSubmissions handler untuk GET /api/stages/:id/submissions/:user_id (owner / collaborator lesson)
+// Purpose: Membuka semua attempt satu student di stage beserta feedback per attempt
+func (h *SubmissionReviewHandler) GetStudentSubmissions(c *gin.Context) {
+    stageID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Stage ID tidak valid"})
+        return
+    }
+
+    userID, err := strconv.Atoi(c.Param("user_id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "User ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    submissions, err := services.GetStudentSubmissions(c.Request.Context(), h.DB, actor.(services.Actor), stageID, userID)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, submissions)
+}
+
+// AddFeedback handler untuk POST /api/attempts/:id/feedback (owner / editor lesson)
+// Purpose: Memberi feedback umum atau komentar pada baris code (line_number) di satu attempt
+func (h *SubmissionReviewHandler) AddFeedback(c *gin.Context) {
+    attemptID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Attempt ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.CreateFeedbackRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    feedback, err := services.AddSubmissionFeedback(c.Request.Context(), h.DB, actor.(services.Actor), attemptID, req)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusCreated, gin.H{
+        "message":  "Feedback berhasil ditambahkan!",
+        "feedback": feedback,
+    })
+}
+
+// UpdateFeedback handler untuk PUT /api/feedback/:id (penulis feedback)
+// Purpose: Mengubah isi feedback
+func (h *SubmissionReviewHandler) UpdateFeedback(c *gin.Context) {
+    feedbackID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Feedback ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.UpdateFeedbackRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    feedback, err := services.UpdateSubmissionFeedback(c.Request.Context(), h.DB, actor.(services.Actor), feedbackID, req)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message":  "Feedback berhasil diupdate!",
+        "feedback": feedback,
+    })
+}
+
+// DeleteFeedback handler untuk DELETE /api/feedback/:id (penulis feedback)
+// Purpose: Menghapus feedback
+func (h *SubmissionReviewHandler) DeleteFeedback(c *gin.Context) {
+    feedbackID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Feedback ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    if err := services.DeleteSubmissionFeedback(c.Request.Context(), h.DB, actor.(services.Actor), feedbackID); err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Feedback berhasil dihapus!"})
+}
+
+// UpdateRubric handler untuk PUT /api/stages/:id/rubric (owner / editor lesson)
+// Purpose: Mengganti rubric penilaian refleksi INVESTIGATE stage (rubric kosong = dihapus)
+func (h *SubmissionReviewHandler) UpdateRubric(c *gin.Context) {
+    stageID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Stage ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.UpdateRubricRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    stage, err := services.UpdateInvestigateRubric(c.Request.Context(), h.DB, actor.(services.Actor), stageID, req.Rubric)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Rubric berhasil diupdate!",
+        "stage":   stage,
+    })
+}
+
+// GetReviewQueue handler untuk GET /api/review-queue (owner / editor lesson)
+// Purpose: Refleksi INVESTIGATE yang menunggu dinilai (paling lama menunggu di atas)
+// Query: lesson_id, stage_id, status (default pending_review)
+func (h *SubmissionReviewHandler) GetReviewQueue(c *gin.Context) {
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var query models.ReviewQueueQuery
+    if err := c.ShouldBindQuery(&query); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Query tidak valid: " + err.Error()})
+        return
+    }
+
+    reviews, err := services.GetReviewQueue(c.Request.Context(), h.DB, actor.(services.Actor), query)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "reviews": reviews,
+        "count":   len(reviews),
+    })
+}
+
+// ScoreReflection handler untuk POST /api/reviews/:id/score (owner / editor lesson)
+// Purpose: Menilai refleksi dengan rubric lalu approve (stage complete + reward) atau reject
+func (h *SubmissionReviewHandler) ScoreReflection(c *gin.Context) {
+    reviewID, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Review ID tidak valid"})
+        return
+    }
+
+    actor, exists := c.Get("actor")
+    if !exists {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID tidak ditemukan"})
+        return
+    }
+
+    var req models.ScoreReflectionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Data tidak valid: " + err.Error()})
+        return
+    }
+
+    review, err := services.ScoreReflection(c.Request.Context(), h.DB, actor.(services.Actor), reviewID, req)
+    if err != nil {
+        respondReviewError(c, err)
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Refleksi berhasil dinilai!",
+        "review":  review,
+    })
+}
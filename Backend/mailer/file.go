@@ -0,0 +1,43 @@
+package mailer
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+// FileMailer menyimpan setiap email sebagai file .eml (untuk development & testing)
+type FileMailer struct {
+    Dir string
+}
+
+// Send menulis email ke Dir/<timestamp>_<penerima>.eml
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+    if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+        return errors.New("gagal membuat folder mail: " + err.Error())
+    }
+
+    recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+    name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+
+    err := os.WriteFile(filepath.Join(m.Dir, name), buildMessage("primmfy@localhost", msg), 0o644)
+    if err != nil {
+        return errors.New("gagal menyimpan email: " + err.Error())
+    }
+
+    return nil
+}
+
+// LogMailer hanya menulis email ke log server (MAILER=log, khusus development)
+type LogMailer struct{}
+
+// Send menulis email ke log
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+    log.Printf("📧 Email ke %s: %s\n%s\n", msg.To, msg.Subject, msg.Body)
+    return nil
+}
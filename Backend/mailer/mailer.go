@@ -0,0 +1,68 @@
+package mailer
+
+import (
+    "context"
+    "errors"
+    "os"
+    "strconv"
+)
+
+// ═══════════════════════════════════════════════════════════
+// MAILER
+// Pengiriman email (verifikasi email, reset password, dll)
+// Implementasi dipilih lewat MAILER: smtp | file | log (wajib di-set)
+// file & log menyimpan link verifikasi / reset password apa adanya, hanya untuk development
+// ═══════════════════════════════════════════════════════════
+
+// Message adalah satu email plain text
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+}
+
+// Mailer mengirim email
+type Mailer interface {
+    Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv membuat Mailer berdasarkan environment variables
+// MAILER=smtp -> SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+// MAILER=file -> MAIL_DIR (default: ./mail), development
+// MAILER=log  -> email hanya ditulis ke log server, development
+// MAILER kosong / tidak dikenal = error (server tidak boleh diam-diam menulis token ke log)
+func FromEnv() (Mailer, error) {
+    switch os.Getenv("MAILER") {
+    case "smtp":
+        host := os.Getenv("SMTP_HOST")
+        if host == "" {
+            return nil, errors.New("SMTP_HOST wajib diisi untuk MAILER=smtp")
+        }
+        port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+        if err != nil || port <= 0 {
+            port = 587
+        }
+        return &SMTPMailer{
+            Host:     host,
+            Port:     port,
+            Username: os.Getenv("SMTP_USERNAME"),
+            Password: os.Getenv("SMTP_PASSWORD"),
+            From:     os.Getenv("SMTP_FROM"),
+        }, nil
+
+    case "file":
+        dir := os.Getenv("MAIL_DIR")
+        if dir == "" {
+            dir = "mail"
+        }
+        return &FileMailer{Dir: dir}, nil
+
+    case "log":
+        return &LogMailer{}, nil
+
+    case "":
+        return nil, errors.New("MAILER wajib di-set: smtp (production), atau file / log khusus development")
+    }
+
+    return nil, errors.New("MAILER tidak dikenal: " + os.Getenv("MAILER") + " (smtp | file | log)")
+}
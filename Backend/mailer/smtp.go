@@ -0,0 +1,64 @@
+package mailer
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "net/mail"
+    "net/smtp"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// SMTPMailer mengirim email lewat server SMTP (STARTTLS otomatis jika didukung server)
+type SMTPMailer struct {
+    Host     string
+    Port     int
+    Username string
+    Password string
+    From     string
+}
+
+// Send mengirim email lewat SMTP
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+    if m.Host == "" || m.From == "" {
+        return errors.New("SMTP_HOST dan SMTP_FROM wajib diisi")
+    }
+    if err := ctx.Err(); err != nil {
+        return err
+    }
+
+    var auth smtp.Auth
+    if m.Username != "" {
+        auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+    }
+
+    // SMTP_FROM boleh berformat "Nama <alamat@domain>", envelope hanya butuh alamatnya
+    sender, err := mail.ParseAddress(m.From)
+    if err != nil {
+        return errors.New("SMTP_FROM tidak valid: " + err.Error())
+    }
+
+    addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+    if err := smtp.SendMail(addr, auth, sender.Address, []string{msg.To}, buildMessage(m.From, msg)); err != nil {
+        return errors.New("gagal mengirim email: " + err.Error())
+    }
+
+    return nil
+}
+
+// buildMessage menyusun header + body email (RFC 5322)
+func buildMessage(from string, msg Message) []byte {
+    var b strings.Builder
+    fmt.Fprintf(&b, "From: %s\r\n", from)
+    fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+    fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+    fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+    b.WriteString("\r\n")
+    b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+    return []byte(b.String())
+}
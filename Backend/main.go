@@ -1,14 +1,23 @@
 package main
 
 import (
+    "context"
     "log"
     "os"
+    "runtime"
+    "strconv"
+    "strings"
+    "time"
 
     "github.com/gin-contrib/cors"
     "github.com/gin-gonic/gin"
     "github.com/joho/godotenv"
+    "primmfy_db/executor"
     "primmfy_db/handlers"
+    "primmfy_db/mailer"
     "primmfy_db/middleware"
+    "primmfy_db/oidc"
+    "primmfy_db/services"
 )
 
 func main() {
@@ -22,10 +31,85 @@ func main() {
     InitDB()
     defer CloseDB()
 
-    // 3. Initialize Gin router
+    // Subcommand: go run . migrate up | down [n] | status
+    if isMigrateCommand() {
+        if err := runMigrateCommand(os.Args[2:]); err != nil {
+            CloseDB()
+            log.Fatal("❌ Migration gagal: ", err)
+        }
+        return
+    }
+
+    // Subcommand: go run . create-invite teacher|admin [jam]
+    if isCreateInviteCommand() {
+        if err := runCreateInviteCommand(os.Args[2:]); err != nil {
+            CloseDB()
+            log.Fatal("❌ Gagal membuat invite: ", err)
+        }
+        return
+    }
+
+    // Migration otomatis saat startup (set AUTO_MIGRATE=false untuk menonaktifkan)
+    if os.Getenv("AUTO_MIGRATE") != "false" {
+        if err := migrateUp(context.Background()); err != nil {
+            CloseDB()
+            log.Fatal("❌ Migration gagal: ", err)
+        }
+    }
+
+    // 3. Start grading queue (worker pool untuk eksekusi code student)
+    // Code student ditolak dijalankan selama sandbox belum dikonfigurasi
+    if err := executor.CheckSandbox(); err != nil {
+        log.Printf("⚠️  Eksekusi code student dinonaktifkan: %v\n", err)
+    }
+    workers, err := strconv.Atoi(os.Getenv("GRADING_WORKERS"))
+    if err != nil || workers < 1 {
+        workers = runtime.NumCPU()
+    }
+    // Proses student (grading queue + RUN stage di request) dibatasi GRADING_WORKERS sekaligus
+    executor.SetMaxConcurrent(workers)
+    gradingQueue := services.NewGradingQueue(DB, workers)
+    if err := gradingQueue.Start(context.Background()); err != nil {
+        log.Fatal("Gagal menjalankan grading queue:", err)
+    }
+    defer gradingQueue.Stop()
+
+    // 4. Initialize Gin router
     router := gin.Default()
 
-    // 4. Setup CORS
+    // X-Forwarded-For hanya dipercaya dari reverse proxy di TRUSTED_PROXIES (dipisah koma)
+    // Default: tidak ada, c.ClientIP() = IP koneksi (dipakai login limiter & session)
+    var trustedProxies []string
+    if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+        for _, proxy := range strings.Split(v, ",") {
+            trustedProxies = append(trustedProxies, strings.TrimSpace(proxy))
+        }
+    }
+    if err := router.SetTrustedProxies(trustedProxies); err != nil {
+        log.Fatal("TRUSTED_PROXIES tidak valid: ", err)
+    }
+
+    // Request timeout (REQUEST_TIMEOUT, contoh: 60s) membatalkan query & eksekusi code
+    // Stream SSE dikecualikan karena memang berjalan lama, export gradebook memakai
+    // EXPORT_TIMEOUT (default 10m) agar file kelas besar tidak terpotong di tengah stream
+    requestTimeout := 60 * time.Second
+    if d, err := time.ParseDuration(os.Getenv("REQUEST_TIMEOUT")); err == nil {
+        requestTimeout = d
+    }
+    exportTimeout := 10 * time.Minute
+    if d, err := time.ParseDuration(os.Getenv("EXPORT_TIMEOUT")); err == nil {
+        exportTimeout = d
+    }
+    router.Use(middleware.RequestTimeout(requestTimeout,
+        "/api/submissions/:id/events",
+        "/api/lessons/:id/gradebook.csv",
+        "/api/lessons/:id/gradebook.xlsx",
+    ))
+    if longest := executor.MaxRunWallTime(); requestTimeout <= longest {
+        log.Printf("⚠️  REQUEST_TIMEOUT (%s) tidak lebih lama dari eksekusi RUN stage terlama (%s)\n", requestTimeout, longest)
+    }
+
+    // 5. Setup CORS
     router.Use(cors.New(cors.Config{
         AllowOrigins:     []string{"http://localhost:3000", "http://localhost:5173"},
         AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -34,14 +118,29 @@ func main() {
         AllowCredentials: true,
     }))
 
-    // 5. Initialize handlers
-    authHandler := handlers.NewAuthHandler(DB)
+    // 6. Initialize handlers
+    // Login limiter (brute-force protection, store: LOGIN_LIMITER_STORE=postgres|memory)
+    loginLimiter := services.LoginLimiterFromEnv(DB)
+    // Mailer wajib dikonfigurasi eksplisit (MAILER=smtp | file | log)
+    mail, err := mailer.FromEnv()
+    if err != nil {
+        log.Fatal("Konfigurasi email tidak valid: ", err)
+    }
+    authHandler := handlers.NewAuthHandler(DB, mail, loginLimiter)
     lessonHandler := handlers.NewLessonHandler(DB)
     courseHandler := handlers.NewCourseHandler(DB)
-    stageHandler := handlers.NewPRIMMStageHandler(DB)
-    progressHandler := handlers.NewProgressHandler(DB)
+    stageHandler := handlers.NewPRIMMStageHandler(DB, gradingQueue)
+    progressHandler := handlers.NewProgressHandler(DB, gradingQueue)
+    adminHandler := handlers.NewAdminHandler(DB, loginLimiter)
+    collaboratorHandler := handlers.NewCollaboratorHandler(DB)
+    classroomHandler := handlers.NewClassroomHandler(DB)
+    assignmentHandler := handlers.NewAssignmentHandler(DB)
+    gradebookHandler := handlers.NewGradebookHandler(DB)
+    reviewHandler := handlers.NewSubmissionReviewHandler(DB)
+    // SSO (OIDC) aktif jika OIDC_ISSUER diisi
+    ssoHandler := handlers.NewSSOHandler(DB, oidc.FromEnv())
 
-    // 6. Setup routes
+    // 7. Setup routes
     api := router.Group("/api")
     {
         // ═══════════════════════════════════════════════════
@@ -49,6 +148,12 @@ func main() {
         // ═══════════════════════════════════════════════════
         api.POST("/register", authHandler.Register)
         api.POST("/login", authHandler.Login)
+        api.POST("/token/refresh", authHandler.RefreshToken)
+        api.POST("/email/verify", authHandler.VerifyEmail)
+        api.POST("/password/forgot", authHandler.ForgotPassword)
+        api.POST("/password/reset", authHandler.ResetPassword)
+        api.GET("/auth/oidc/login", ssoHandler.Login)
+        api.GET("/auth/oidc/callback", ssoHandler.Callback)
 
         // ═══════════════════════════════════════════════════
         // PUBLIC LESSON ROUTES (No authentication required)
@@ -61,8 +166,13 @@ func main() {
         // PROTECTED ROUTES (Require Authentication)
         // ═══════════════════════════════════════════════════
         protected := api.Group("")
-        protected.Use(middleware.AuthMiddleware())
+        protected.Use(middleware.AuthMiddleware(DB))
         {
+            // Logout (mencabut session)
+            protected.POST("/logout", authHandler.Logout)
+            protected.POST("/logout/all", authHandler.LogoutAll)
+            protected.POST("/email/resend-verification", authHandler.ResendVerification)
+
             // Profile endpoint
             protected.GET("/profile", func(c *gin.Context) {
                 userID, _ := c.Get("user_id")
@@ -89,42 +199,89 @@ func main() {
             // PRIMM STAGE ROUTES (All authenticated users - view only)
             // ═══════════════════════════════════════════════════
             protected.GET("/stages/:id", stageHandler.GetStageByID)
+            protected.GET("/stages/:id/my-attempts", progressHandler.GetMyAttempts) // Teacher: ?user_id=
+
+            // ═══════════════════════════════════════════════════
+            // SUBMISSION STATUS (Async grading MODIFY & MAKE)
+            // ═══════════════════════════════════════════════════
+            protected.GET("/submissions/:id", progressHandler.GetSubmission)
+            protected.GET("/submissions/:id/events", progressHandler.StreamSubmissionEvents)
 
             // ═══════════════════════════════════════════════════
             // TEACHER ONLY ROUTES
             // ═══════════════════════════════════════════════════
             teacher := protected.Group("")
-            teacher.Use(middleware.RequireRole("teacher", "admin"))
             {
+                // Setiap route mendeklarasikan permission yang dibutuhkan
+                // (ownership lesson/course/stage dicek di service, admin bisa mengelola semua lesson)
+
                 // Lesson Management
-                teacher.POST("/lessons", lessonHandler.CreateLesson)
-                teacher.GET("/lessons/my", lessonHandler.GetMyLessons)
-                teacher.PUT("/lessons/:id", lessonHandler.UpdateLesson)
-                teacher.DELETE("/lessons/:id", lessonHandler.DeleteLesson)
+                teacher.POST("/lessons", middleware.RequirePermission(services.PermLessonCreate), lessonHandler.CreateLesson)
+                teacher.GET("/lessons/my", middleware.RequirePermission(services.PermLessonCreate), lessonHandler.GetMyLessons)
+                teacher.PUT("/lessons/:id", middleware.RequirePermission(services.PermLessonEdit), lessonHandler.UpdateLesson)
+                teacher.DELETE("/lessons/:id", middleware.RequirePermission(services.PermLessonDelete), lessonHandler.DeleteLesson)
+
+                // Co-teacher (collaborator owner / editor / viewer)
+                teacher.POST("/lessons/:id/collaborators", middleware.RequirePermission(services.PermLessonManageCollaborators), collaboratorHandler.InviteCollaborator)
+                teacher.GET("/lessons/:id/collaborators", middleware.RequirePermission(services.PermLessonView), collaboratorHandler.GetCollaborators)
+                teacher.POST("/lessons/:id/collaborators/accept", middleware.RequirePermission(services.PermLessonView), collaboratorHandler.AcceptInvite)
+                teacher.DELETE("/lessons/:id/collaborators/:user_id", middleware.RequirePermission(services.PermLessonView), collaboratorHandler.RemoveCollaborator)
+                teacher.GET("/collaborator-invites", middleware.RequirePermission(services.PermLessonView), collaboratorHandler.GetMyInvites)
+
+                // Classroom (roster + join code, lesson di-assign ke semua member)
+                teacher.POST("/classrooms", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.CreateClassroom)
+                teacher.GET("/classrooms", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.GetTeacherClassrooms)
+                teacher.GET("/classrooms/:id", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.GetClassroomDetail)
+                teacher.PUT("/classrooms/:id", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.UpdateClassroom)
+                teacher.POST("/classrooms/:id/join-code", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.RegenerateJoinCode)
+                teacher.POST("/classrooms/:id/lessons", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.AssignLesson)
+                teacher.DELETE("/classrooms/:id/lessons/:lesson_id", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.UnassignLesson)
+                teacher.DELETE("/classrooms/:id/members/:user_id", middleware.RequirePermission(services.PermClassroomManage), classroomHandler.RemoveMember)
+
+                // Assignment (deadline + late policy per kelas)
+                teacher.POST("/classrooms/:id/assignments", middleware.RequirePermission(services.PermClassroomManage), assignmentHandler.CreateAssignment)
+                teacher.GET("/classrooms/:id/assignments", middleware.RequirePermission(services.PermClassroomManage), assignmentHandler.GetClassroomAssignments)
+                teacher.PUT("/assignments/:id", middleware.RequirePermission(services.PermClassroomManage), assignmentHandler.UpdateAssignment)
+                teacher.DELETE("/assignments/:id", middleware.RequirePermission(services.PermClassroomManage), assignmentHandler.DeleteAssignment)
 
                 // Course Management
-                teacher.POST("/courses", courseHandler.CreateCourse)
-                teacher.PUT("/courses/:id", courseHandler.UpdateCourse)
-                teacher.DELETE("/courses/:id", courseHandler.DeleteCourse)
+                teacher.POST("/courses", middleware.RequirePermission(services.PermLessonEdit), courseHandler.CreateCourse)
+                teacher.PUT("/courses/:id", middleware.RequirePermission(services.PermLessonEdit), courseHandler.UpdateCourse)
+                teacher.DELETE("/courses/:id", middleware.RequirePermission(services.PermLessonEdit), courseHandler.DeleteCourse)
 
                 // PRIMM Stage Management
-                teacher.POST("/stages/predict", stageHandler.CreatePredictStage)
-                teacher.POST("/stages/run", stageHandler.CreateRunStage)
-                teacher.POST("/stages/investigate", stageHandler.CreateInvestigateStage)
-                teacher.POST("/stages/modify", stageHandler.CreateModifyStage)
-                teacher.POST("/stages/make", stageHandler.CreateMakeStage)
-                teacher.DELETE("/stages/:id", stageHandler.DeleteStage)
-
-                teacher.GET("/teacher-dashboard", func(c *gin.Context) {
-                    c.JSON(200, gin.H{"message": "Welcome to teacher dashboard!"})
-                })
+                teacher.POST("/stages/predict", middleware.RequirePermission(services.PermLessonEdit), stageHandler.CreatePredictStage)
+                teacher.POST("/stages/run", middleware.RequirePermission(services.PermLessonEdit), stageHandler.CreateRunStage)
+                teacher.POST("/stages/investigate", middleware.RequirePermission(services.PermLessonEdit), stageHandler.CreateInvestigateStage)
+                teacher.POST("/stages/modify", middleware.RequirePermission(services.PermLessonEdit), stageHandler.CreateModifyStage)
+                teacher.POST("/stages/make", middleware.RequirePermission(services.PermLessonEdit), stageHandler.CreateMakeStage)
+                teacher.DELETE("/stages/:id", middleware.RequirePermission(services.PermLessonEdit), stageHandler.DeleteStage)
+
+                // Gradebook & analytics (progress student per lesson / kelas)
+                teacher.GET("/teacher-dashboard", middleware.RequirePermission(services.PermLessonCreate), gradebookHandler.GetTeacherDashboard)
+                teacher.GET("/lessons/:id/gradebook", middleware.RequirePermission(services.PermSubmissionViewAll), gradebookHandler.GetLessonGradebook)
+                teacher.GET("/lessons/:id/gradebook.csv", middleware.RequestTimeout(exportTimeout), middleware.RequirePermission(services.PermSubmissionViewAll), gradebookHandler.ExportGradebookCSV)
+                teacher.GET("/lessons/:id/gradebook.xlsx", middleware.RequestTimeout(exportTimeout), middleware.RequirePermission(services.PermSubmissionViewAll), gradebookHandler.ExportGradebookXLSX)
+                teacher.GET("/classrooms/:id/gradebook", middleware.RequirePermission(services.PermClassroomManage), gradebookHandler.GetClassroomGradebook) // ?lesson_id=
+
+                // Submission review (baca attempt student + feedback per baris)
+                teacher.GET("/stages/:id/submissions", middleware.RequirePermission(services.PermSubmissionViewAll), reviewHandler.GetStageSubmissions)
+                teacher.GET("/stages/:id/submissions/:user_id", middleware.RequirePermission(services.PermSubmissionViewAll), reviewHandler.GetStudentSubmissions)
+                teacher.POST("/attempts/:id/feedback", middleware.RequirePermission(services.PermSubmissionFeedback), reviewHandler.AddFeedback)
+                teacher.PUT("/feedback/:id", middleware.RequirePermission(services.PermSubmissionFeedback), reviewHandler.UpdateFeedback)
+                teacher.DELETE("/feedback/:id", middleware.RequirePermission(services.PermSubmissionFeedback), reviewHandler.DeleteFeedback)
+
+                // Review refleksi INVESTIGATE (rubric + queue pending_review)
+                teacher.PUT("/stages/:id/rubric", middleware.RequirePermission(services.PermLessonEdit), reviewHandler.UpdateRubric)
+                teacher.GET("/review-queue", middleware.RequirePermission(services.PermSubmissionFeedback), reviewHandler.GetReviewQueue) // ?lesson_id=&stage_id=&status=
+                teacher.POST("/reviews/:id/score", middleware.RequirePermission(services.PermSubmissionFeedback), reviewHandler.ScoreReflection)
             }
 
             // ═══════════════════════════════════════════════════
             // STUDENT ROUTES (Progress & Submissions)
             // ═══════════════════════════════════════════════════
             student := protected.Group("")
-            student.Use(middleware.RequireRole("student"))
+            student.Use(middleware.RequirePermission(services.PermStageSubmit))
             {
                 // Submit answers to PRIMM stages
                 student.POST("/stages/:id/submit-predict", progressHandler.SubmitPredictStage)
@@ -134,28 +291,46 @@ func main() {
                 student.POST("/stages/:id/submit-make", progressHandler.SubmitMakeStage)
                 student.POST("/stages/:id/submit", stageHandler.SubmitStage)
 
-
                 // View progress
                 student.GET("/stages/:id/my-completion", progressHandler.GetStageCompletion)
                 student.GET("/courses/:id/my-progress", progressHandler.GetCourseProgress)
                 student.GET("/my-progress/:lesson_id", progressHandler.GetMyProgress)
                 student.GET("/courses/:id/stages", courseHandler.GetStagesInCourse)
+
+                // Classroom (bergabung dengan join code)
+                student.POST("/classrooms/join", middleware.RequirePermission(services.PermClassroomJoin), classroomHandler.JoinClassroom)
+                student.GET("/my-classrooms", middleware.RequirePermission(services.PermClassroomJoin), classroomHandler.GetMyClassrooms)
+                student.DELETE("/my-classrooms/:id", middleware.RequirePermission(services.PermClassroomJoin), classroomHandler.LeaveClassroom)
+                student.GET("/my-assignments", middleware.RequirePermission(services.PermClassroomJoin), assignmentHandler.GetMyAssignments)
             }
 
             // ═══════════════════════════════════════════════════
             // ADMIN ONLY ROUTES
             // ═══════════════════════════════════════════════════
             admin := protected.Group("")
-            admin.Use(middleware.RequireRole("admin"))
+            admin.Use(middleware.RequirePermission(services.PermUserManage))
             {
                 admin.GET("/admin-dashboard", func(c *gin.Context) {
                     c.JSON(200, gin.H{"message": "Welcome to admin dashboard!"})
                 })
+
+                // Invite code untuk registrasi teacher/admin
+                admin.POST("/admin/invites", adminHandler.CreateInvite)
+                admin.GET("/admin/invites", adminHandler.GetInvites)
+                admin.DELETE("/admin/invites/:id", adminHandler.RevokeInvite)
+
+                // Role user (promote / demote + audit trail)
+                admin.PUT("/admin/users/:id/role", adminHandler.UpdateUserRole)
+                admin.GET("/admin/role-audit", adminHandler.GetRoleAuditLog) // ?user_id=
+
+                // Buka kunci login (terlalu banyak login gagal)
+                admin.POST("/admin/users/:id/unlock", adminHandler.UnlockUser)
+                admin.POST("/admin/unlock-ip", adminHandler.UnlockIP)
             }
         }
     }
 
-    // 7. Start server
+    // 8. Start server
     port := os.Getenv("PORT")
     if port == "" {
         port = "8080"
@@ -165,27 +340,74 @@ func main() {
     log.Printf("\n📚 API DOCUMENTATION - PRIMMFY Platform\n")
     log.Printf("═══════════════════════════════════════════════════════════\n")
     log.Printf("🔓 PUBLIC ENDPOINTS:\n")
-    log.Printf("  POST   /api/register                          - Register user\n")
+    log.Printf("  POST   /api/register                          - Register student (teacher/admin: invite_code)\n")
     log.Printf("  POST   /api/login                             - Login user\n")
+    log.Printf("  POST   /api/token/refresh                     - Rotate refresh token\n")
+    log.Printf("  POST   /api/email/verify                      - Verify email (token from email)\n")
+    log.Printf("  POST   /api/password/forgot                   - Send password reset link\n")
+    log.Printf("  POST   /api/password/reset                    - Reset password (token from email)\n")
+    log.Printf("  GET    /api/auth/oidc/login                   - Login via SSO (redirect to provider)\n")
+    log.Printf("  GET    /api/auth/oidc/callback                - SSO callback (redirect to frontend)\n")
     log.Printf("  GET    /api/lessons                           - Get all lessons\n")
     log.Printf("  GET    /api/lessons/:id                       - Get lesson detail\n")
     log.Printf("  GET    /api/lessons/:id/courses               - Get courses in lesson\n")
     log.Printf("\n🔒 AUTHENTICATED ENDPOINTS (All users):\n")
     log.Printf("  GET    /api/profile                           - Get profile\n")
+    log.Printf("  POST   /api/logout                            - Logout (current session)\n")
+    log.Printf("  POST   /api/logout/all                        - Logout from all devices\n")
+    log.Printf("  POST   /api/email/resend-verification         - Resend verification email\n")
     log.Printf("  GET    /api/courses/:id                       - Get course detail with stages\n")
     log.Printf("  GET    /api/stages/:id                        - Get stage detail\n")
+    log.Printf("  GET    /api/stages/:id/my-attempts            - Attempt history (teacher: ?user_id=)\n")
     log.Printf("  POST   /api/lessons/:id/enroll                - Enroll to lesson\n")
     log.Printf("  GET    /api/my-lessons                        - Get enrolled lessons\n")
+    log.Printf("  GET    /api/submissions/:id                   - Poll submission status\n")
+    log.Printf("  GET    /api/submissions/:id/events            - Stream submission status (SSE)\n")
     log.Printf("\n👨‍🏫 TEACHER ONLY:\n")
     log.Printf("  ┌─ Lesson Management\n")
     log.Printf("  │  POST   /api/lessons                        - Create lesson\n")
     log.Printf("  │  GET    /api/lessons/my                     - Get my lessons\n")
     log.Printf("  │  PUT    /api/lessons/:id                    - Update lesson\n")
     log.Printf("  │  DELETE /api/lessons/:id                    - Delete lesson\n")
+    log.Printf("  ├─ Co-teacher Collaboration\n")
+    log.Printf("  │  POST   /api/lessons/:id/collaborators      - Invite collaborator (owner)\n")
+    log.Printf("  │  GET    /api/lessons/:id/collaborators      - List collaborators\n")
+    log.Printf("  │  POST   /api/lessons/:id/collaborators/accept - Accept invite\n")
+    log.Printf("  │  DELETE /api/lessons/:id/collaborators/:user_id - Remove / leave / decline\n")
+    log.Printf("  │  GET    /api/collaborator-invites           - My pending invites\n")
+    log.Printf("  ├─ Classroom Management\n")
+    log.Printf("  │  POST   /api/classrooms                     - Create classroom (join code)\n")
+    log.Printf("  │  GET    /api/classrooms                     - Get my classrooms\n")
+    log.Printf("  │  GET    /api/classrooms/:id                 - Classroom detail (roster + lessons)\n")
+    log.Printf("  │  PUT    /api/classrooms/:id                 - Update classroom\n")
+    log.Printf("  │  POST   /api/classrooms/:id/join-code       - Regenerate join code\n")
+    log.Printf("  │  POST   /api/classrooms/:id/lessons         - Assign lesson (enroll all members)\n")
+    log.Printf("  │  DELETE /api/classrooms/:id/lessons/:lesson_id - Unassign lesson\n")
+    log.Printf("  │  DELETE /api/classrooms/:id/members/:user_id - Remove student\n")
+    log.Printf("  ├─ Assignments (Deadline & Late Policy)\n")
+    log.Printf("  │  POST   /api/classrooms/:id/assignments     - Create assignment (lesson / course)\n")
+    log.Printf("  │  GET    /api/classrooms/:id/assignments     - List classroom assignments\n")
+    log.Printf("  │  PUT    /api/assignments/:id                - Update schedule / late policy\n")
+    log.Printf("  │  DELETE /api/assignments/:id                - Delete assignment\n")
     log.Printf("  ├─ Course Management\n")
     log.Printf("  │  POST   /api/courses                        - Create course\n")
     log.Printf("  │  PUT    /api/courses/:id                    - Update course\n")
     log.Printf("  │  DELETE /api/courses/:id                    - Delete course\n")
+    log.Printf("  ├─ Gradebook & Analytics\n")
+    log.Printf("  │  GET    /api/teacher-dashboard              - Lesson & classroom summary (stuck students)\n")
+    log.Printf("  │  GET    /api/lessons/:id/gradebook          - Students × stages (?classroom_id=&status=&sort=&page=)\n")
+    log.Printf("  │  GET    /api/lessons/:id/gradebook.csv      - Export gradebook (CSV, same filters)\n")
+    log.Printf("  │  GET    /api/lessons/:id/gradebook.xlsx     - Export gradebook (Excel, same filters)\n")
+    log.Printf("  │  GET    /api/classrooms/:id/gradebook       - Classroom gradebook (?lesson_id=)\n")
+    log.Printf("  ├─ Submission Review\n")
+    log.Printf("  │  GET    /api/stages/:id/submissions         - Students who submitted (latest attempt)\n")
+    log.Printf("  │  GET    /api/stages/:id/submissions/:user_id - Student attempts + feedback\n")
+    log.Printf("  │  POST   /api/attempts/:id/feedback          - Add feedback (line_number optional)\n")
+    log.Printf("  │  PUT    /api/feedback/:id                   - Update own feedback\n")
+    log.Printf("  │  DELETE /api/feedback/:id                   - Delete own feedback\n")
+    log.Printf("  │  PUT    /api/stages/:id/rubric              - Set INVESTIGATE rubric\n")
+    log.Printf("  │  GET    /api/review-queue                   - Reflections pending review (?lesson_id=&stage_id=)\n")
+    log.Printf("  │  POST   /api/reviews/:id/score              - Score reflection (approve / reject)\n")
     log.Printf("  └─ PRIMM Stage Management\n")
     log.Printf("     POST   /api/stages/predict                 - Create PREDICT stage\n")
     log.Printf("     POST   /api/stages/run                     - Create RUN stage\n")
@@ -197,13 +419,26 @@ func main() {
     log.Printf("  ┌─ Submit Answers\n")
     log.Printf("  │  POST   /api/stages/:id/submit-predict     - Submit PREDICT answer\n")
     log.Printf("  │  POST   /api/stages/:id/submit-run         - Submit RUN code\n")
-    log.Printf("  │  POST   /api/stages/:id/submit-investigate - Submit INVESTIGATE reflection\n")
-    log.Printf("  │  POST   /api/stages/:id/submit-modify      - Submit MODIFY code\n")
-    log.Printf("  │  POST   /api/stages/:id/submit-make        - Submit MAKE code\n")
+    log.Printf("  │  POST   /api/stages/:id/submit-investigate - Submit INVESTIGATE reflection (pending review)\n")
+    log.Printf("  │  POST   /api/stages/:id/submit-modify      - Submit MODIFY code (async)\n")
+    log.Printf("  │  POST   /api/stages/:id/submit-make        - Submit MAKE code (async)\n")
+    log.Printf("  ├─ Classroom\n")
+    log.Printf("  │  POST   /api/classrooms/join               - Join classroom with join code\n")
+    log.Printf("  │  GET    /api/my-classrooms                 - Get joined classrooms\n")
+    log.Printf("  │  DELETE /api/my-classrooms/:id             - Leave classroom\n")
+    log.Printf("  │  GET    /api/my-assignments                - Assignments & due dates\n")
     log.Printf("  └─ View Progress\n")
-    log.Printf("     GET    /api/stages/:id/my-completion       - Get stage completion\n")
+    log.Printf("     GET    /api/stages/:id/my-completion       - Get stage completion + teacher feedback\n")
     log.Printf("     GET    /api/courses/:id/my-progress        - Get course progress\n")
     log.Printf("     GET    /api/my-progress/:lesson_id         - Get lesson progress\n")
+    log.Printf("\n🛡️  ADMIN ONLY:\n")
+    log.Printf("  POST   /api/admin/invites                     - Create teacher/admin invite code\n")
+    log.Printf("  GET    /api/admin/invites                     - List invites\n")
+    log.Printf("  DELETE /api/admin/invites/:id                 - Revoke unused invite\n")
+    log.Printf("  PUT    /api/admin/users/:id/role              - Promote / demote user\n")
+    log.Printf("  GET    /api/admin/role-audit                  - Role change audit trail (?user_id=)\n")
+    log.Printf("  POST   /api/admin/users/:id/unlock            - Unlock account after failed logins\n")
+    log.Printf("  POST   /api/admin/unlock-ip                   - Unlock IP after failed logins\n")
     log.Printf("═══════════════════════════════════════════════════════════\n")
     log.Printf("\n🎯 PRIMM Methodology Flow:\n")
     log.Printf("  Lesson (Big Topic)\n")
@@ -5,12 +5,14 @@ import (
     "strings"
 
     "github.com/gin-gonic/gin"
+    "github.com/jackc/pgx/v5/pgxpool"
     "primmfy_db/services"
 )
 
 // AuthMiddleware memvalidasi JWT token dari request header
+// dan menolak token dari session yang sudah logout / dicabut
 // Header format: Authorization: Bearer <token>
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(db *pgxpool.Pool) gin.HandlerFunc {
     return func(c *gin.Context) {
         // 1. Ambil token dari Authorization header
         authHeader := c.GetHeader("Authorization")
@@ -48,18 +50,47 @@ func AuthMiddleware() gin.HandlerFunc {
         // MapClaims diakses seperti map, bukan struct
         userID := int(claims["user_id"].(float64)) // JWT number selalu float64
         userRole := claims["role"].(string)
-        
+
+        // 5. Cek session masih aktif (token lama tanpa "sid" ditolak)
+        sid, ok := claims["sid"].(float64)
+        if !ok {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "error": "Token tidak memiliki session, silakan login ulang",
+            })
+            c.Abort()
+            return
+        }
+        sessionID := int64(sid)
+
+        active, err := services.IsSessionActive(c.Request.Context(), db, userID, sessionID)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            c.Abort()
+            return
+        }
+        if !active {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "error": "Session sudah berakhir, silakan login ulang",
+            })
+            c.Abort()
+            return
+        }
+
         c.Set("user_id", userID)
         c.Set("user_role", userRole)
+        c.Set("session_id", sessionID)
+        c.Set("actor", services.Actor{UserID: userID, Role: userRole})
 
-        // 5. Lanjutkan ke handler berikutnya
+        // 6. Lanjutkan ke handler berikutnya
         c.Next()
     }
 }
 
-// RequireRole middleware untuk membatasi akses berdasarkan role
-// Contoh: RequireRole("admin", "teacher")
-func RequireRole(allowedRoles ...string) gin.HandlerFunc {
+// RequirePermission middleware untuk membatasi akses berdasarkan permission role
+// (lihat rolePermissions di services/authorization.go)
+// Contoh: RequirePermission(services.PermLessonCreate)
+// Ownership resource (lesson milik siapa) tetap dicek di service lewat services.Authorize
+func RequirePermission(perm services.Permission) gin.HandlerFunc {
     return func(c *gin.Context) {
         // Ambil role dari context (di-set oleh AuthMiddleware)
         userRole, exists := c.Get("user_role")
@@ -71,17 +102,7 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
             return
         }
 
-        // Cek apakah role user diizinkan
-        roleStr := userRole.(string)
-        allowed := false
-        for _, role := range allowedRoles {
-            if roleStr == role {
-                allowed = true
-                break
-            }
-        }
-
-        if !allowed {
+        if !services.HasPermission(userRole.(string), perm) {
             c.JSON(http.StatusForbidden, gin.H{
                 "error": "Anda tidak memiliki akses ke resource ini",
             })
@@ -91,4 +112,4 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 
         c.Next()
     }
-}
\ No newline at end of file
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+    "context"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+// RequestTimeout membatasi durasi request lewat context deadline
+// Query database & eksekusi code yang memakai c.Request.Context() otomatis
+// dibatalkan saat timeout atau saat client disconnect
+// exemptPaths berisi route Gin yang tidak dibatasi (contoh: stream SSE)
+func RequestTimeout(timeout time.Duration, exemptPaths ...string) gin.HandlerFunc {
+    exempt := make(map[string]bool, len(exemptPaths))
+    for _, path := range exemptPaths {
+        exempt[path] = true
+    }
+
+    return func(c *gin.Context) {
+        if timeout <= 0 || exempt[c.FullPath()] {
+            c.Next()
+            return
+        }
+
+        ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+        defer cancel()
+
+        c.Request = c.Request.WithContext(ctx)
+        c.Next()
+    }
+}
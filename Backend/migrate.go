@@ -0,0 +1,90 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+
+    "primmfy_db/migrations"
+)
+
+// ═══════════════════════════════════════════════════════════
+// MIGRATE SUBCOMMAND
+// go run . migrate up          -> jalankan semua migration yang belum diterapkan
+// go run . migrate down [n]    -> rollback n migration terakhir (default 1)
+// go run . migrate status      -> tampilkan status semua migration
+// ═══════════════════════════════════════════════════════════
+
+// runMigrateCommand menjalankan subcommand migrate lalu mengembalikan error (jika ada)
+func runMigrateCommand(args []string) error {
+    if len(args) == 0 {
+        return errors.New("usage: migrate up | down [n] | status")
+    }
+
+    ctx := context.Background()
+
+    switch args[0] {
+    case "up":
+        return migrateUp(ctx)
+
+    case "down":
+        steps := 1
+        if len(args) > 1 {
+            n, err := strconv.Atoi(args[1])
+            if err != nil || n < 1 {
+                return errors.New("jumlah step harus angka >= 1")
+            }
+            steps = n
+        }
+
+        reverted, err := migrations.Down(ctx, DB, steps)
+        for _, m := range reverted {
+            fmt.Printf("⏪ Rollback %04d_%s\n", m.Version, m.Name)
+        }
+        if err != nil {
+            return err
+        }
+        if len(reverted) == 0 {
+            fmt.Println("ℹ️  Tidak ada migration untuk di-rollback")
+        }
+        return nil
+
+    case "status":
+        statuses, err := migrations.Status(ctx, DB)
+        if err != nil {
+            return err
+        }
+        for _, s := range statuses {
+            if s.AppliedAt != nil {
+                fmt.Printf("✅ %04d_%-30s applied %s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+            } else {
+                fmt.Printf("⏳ %04d_%-30s pending\n", s.Version, s.Name)
+            }
+        }
+        return nil
+    }
+
+    return errors.New("subcommand migrate tidak dikenal: " + args[0])
+}
+
+// migrateUp menjalankan semua migration yang belum diterapkan
+func migrateUp(ctx context.Context) error {
+    applied, err := migrations.Up(ctx, DB)
+    for _, m := range applied {
+        fmt.Printf("⏩ Migrated %04d_%s\n", m.Version, m.Name)
+    }
+    if err != nil {
+        return err
+    }
+    if len(applied) == 0 {
+        fmt.Println("✅ Schema database sudah up to date")
+    }
+    return nil
+}
+
+// isMigrateCommand mengecek apakah binary dijalankan sebagai "migrate ..."
+func isMigrateCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "migrate"
+}
@@ -0,0 +1,12 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK BASELINE SCHEMA (SEMUA DATA HILANG)
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS user_stage_completions CASCADE;
+DROP TABLE IF EXISTS user_course_completions CASCADE;
+DROP TABLE IF EXISTS user_lessons CASCADE;
+DROP TABLE IF EXISTS primm_stages CASCADE;
+DROP TABLE IF EXISTS courses CASCADE;
+DROP TABLE IF EXISTS lessons CASCADE;
+DROP TABLE IF EXISTS users CASCADE;
+
+DROP FUNCTION IF EXISTS update_updated_at_column();
@@ -1,197 +1,245 @@
 -- ═══════════════════════════════════════════════════════════
--- DROP EXISTING TABLES (CASCADE untuk hapus dependencies)
+-- BASELINE SCHEMA
+-- Sesuai dengan yang di-query code (menggantikan Database/schema_v2.sql)
+-- Aman untuk database lama yang dibuat dari schema_v2.sql:
+-- tabel dibuat jika belum ada, kolom yang kurang ditambahkan
 -- ═══════════════════════════════════════════════════════════
 
-DROP TABLE IF EXISTS user_stage_completions CASCADE;
-DROP TABLE IF EXISTS user_course_completions CASCADE;
-DROP TABLE IF EXISTS user_lessons CASCADE;
-DROP TABLE IF EXISTS primm_stages CASCADE;
-DROP TABLE IF EXISTS courses CASCADE;
-DROP TABLE IF EXISTS lessons CASCADE;
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ═══════════════════════════════════════════════════════════
+-- USERS
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('student', 'teacher', 'admin')),
+    level INTEGER DEFAULT 1,
+    total_coins INTEGER DEFAULT 0,
+    experience_points INTEGER DEFAULT 0,
+    profile_picture TEXT,
+    bio TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS level INTEGER DEFAULT 1;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS experience_points INTEGER DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_picture TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT;
 
--- Drop function juga
-DROP FUNCTION IF EXISTS update_updated_at_column() CASCADE;
+-- Database lama memakai kolom 'coins', code memakai 'total_coins'
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'coins')
+       AND NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'total_coins') THEN
+        ALTER TABLE users RENAME COLUMN coins TO total_coins;
+    END IF;
+END $$;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS total_coins INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
 
 -- ═══════════════════════════════════════════════════════════
--- LEVEL 1: LESSONS (Big Topic - Collection of Courses)
+-- LEVEL 1: LESSONS
 -- ═══════════════════════════════════════════════════════════
-CREATE TABLE lessons (
+CREATE TABLE IF NOT EXISTS lessons (
     id SERIAL PRIMARY KEY,
     teacher_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     title VARCHAR(200) NOT NULL,
     description TEXT NOT NULL,
-    category VARCHAR(50) NOT NULL, -- 'python', 'javascript', 'html', 'c', dll
+    category VARCHAR(50) NOT NULL, -- 'python', 'javascript', 'golang', 'java', 'cpp', dll
     difficulty VARCHAR(20) NOT NULL CHECK (difficulty IN ('beginner', 'intermediate', 'advanced')),
-    thumbnail_url TEXT, -- Optional: gambar lesson
+    thumbnail_url TEXT,
     is_active BOOLEAN DEFAULT true,
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW()
 );
 
-CREATE INDEX idx_lessons_teacher ON lessons(teacher_id);
-CREATE INDEX idx_lessons_category ON lessons(category);
-CREATE INDEX idx_lessons_active ON lessons(is_active);
+CREATE INDEX IF NOT EXISTS idx_lessons_teacher ON lessons(teacher_id);
+CREATE INDEX IF NOT EXISTS idx_lessons_category ON lessons(category);
+CREATE INDEX IF NOT EXISTS idx_lessons_active ON lessons(is_active);
 
 -- ═══════════════════════════════════════════════════════════
--- LEVEL 2: COURSES (Sub-topic dalam Lesson, ada 5 PRIMM stages)
+-- LEVEL 2: COURSES
 -- ═══════════════════════════════════════════════════════════
-CREATE TABLE courses (
+CREATE TABLE IF NOT EXISTS courses (
     id SERIAL PRIMARY KEY,
     lesson_id INTEGER NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
     title VARCHAR(200) NOT NULL,
     description TEXT NOT NULL,
-    order_index INTEGER NOT NULL, -- Urutan course dalam lesson (1, 2, 3, ...)
-    coin_reward INTEGER NOT NULL DEFAULT 100, -- Coins setelah complete course
+    order_index INTEGER NOT NULL,
+    coin_reward INTEGER NOT NULL DEFAULT 100,
     is_active BOOLEAN DEFAULT true,
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
-    
-    UNIQUE(lesson_id, order_index) -- Tidak boleh ada 2 course dengan order sama dalam 1 lesson
+
+    UNIQUE(lesson_id, order_index)
 );
 
-CREATE INDEX idx_courses_lesson ON courses(lesson_id);
-CREATE INDEX idx_courses_order ON courses(lesson_id, order_index);
+CREATE INDEX IF NOT EXISTS idx_courses_lesson ON courses(lesson_id);
 
 -- ═══════════════════════════════════════════════════════════
--- LEVEL 3: PRIMM STAGES (5 stages per course)
+-- LEVEL 3: PRIMM STAGES
 -- ═══════════════════════════════════════════════════════════
-CREATE TABLE primm_stages (
+CREATE TABLE IF NOT EXISTS primm_stages (
     id SERIAL PRIMARY KEY,
     course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
     stage_type VARCHAR(20) NOT NULL CHECK (stage_type IN ('predict', 'run', 'investigate', 'modify', 'make')),
     title VARCHAR(200) NOT NULL,
     description TEXT NOT NULL,
     order_index INTEGER NOT NULL CHECK (order_index BETWEEN 1 AND 5), -- 1=Predict, 2=Run, 3=Investigate, 4=Modify, 5=Make
-    
+    is_active BOOLEAN DEFAULT true,
+
+    -- Common Fields
+    code_snippet TEXT,
+    task_description TEXT,
+
     -- PREDICT Stage Fields
-    code_snippet TEXT, -- Code yang ditampilkan
-    predict_options JSONB, -- Multiple choice options: {"A": "output1", "B": "output2", ...}
-    correct_answer VARCHAR(10), -- Correct option key: "A", "B", "C", "D"
-    
+    predict_options JSONB, -- {"A": "output1", "B": "output2", ...}
+    correct_answer VARCHAR(10),
+
     -- RUN Stage Fields
-    run_code_template TEXT, -- Code template yang harus ditulis ulang
-    
+    run_code_template TEXT,
+
     -- INVESTIGATE Stage Fields
-    reflection_prompt TEXT, -- Pertanyaan refleksi untuk student
-    video_embed_url TEXT, -- URL video penjelasan (YouTube embed, Vimeo, dll)
-    explanation_text TEXT, -- Penjelasan line-by-line dalam text/markdown
-    
+    reflection_prompt TEXT,
+    video_embed_url TEXT,
+    explanation_text TEXT,
+    guiding_questions JSONB, -- ["pertanyaan 1", "pertanyaan 2", ...]
+
     -- MODIFY Stage Fields
-    modify_challenge TEXT, -- Deskripsi tantangan modifikasi
-    modify_code_template TEXT, -- Code awal untuk dimodifikasi
-    modify_expected_output TEXT, -- Expected output setelah modifikasi
-    modify_test_cases JSONB, -- Test cases untuk validasi: [{"input": "...", "output": "..."}, ...]
-    
+    modify_challenge TEXT,
+    modify_code_template TEXT,
+    modify_expected_output TEXT,
+    modify_test_cases JSONB, -- [{"input", "expected_output", "description", "hidden", "weight", "comparison", "tolerance"}, ...]
+
     -- MAKE Stage Fields
-    make_challenge TEXT, -- Deskripsi tantangan membuat dari scratch
-    make_hints TEXT, -- Hints untuk membantu student
-    make_expected_output TEXT, -- Expected output
-    make_test_cases JSONB, -- Test cases untuk validasi
-    
+    make_challenge TEXT,
+    make_hints TEXT,
+    make_expected_output TEXT,
+    make_test_cases JSONB, -- Format sama dengan modify_test_cases
+
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
-    
-    UNIQUE(course_id, order_index) -- Setiap course harus punya 5 stages (order 1-5)
+
+    UNIQUE(course_id, order_index)
 );
 
-CREATE INDEX idx_stages_course ON primm_stages(course_id);
-CREATE INDEX idx_stages_type ON primm_stages(stage_type);
+ALTER TABLE primm_stages ADD COLUMN IF NOT EXISTS is_active BOOLEAN DEFAULT true;
+ALTER TABLE primm_stages ADD COLUMN IF NOT EXISTS task_description TEXT;
+ALTER TABLE primm_stages ADD COLUMN IF NOT EXISTS guiding_questions JSONB;
+ALTER TABLE primm_stages ADD COLUMN IF NOT EXISTS make_hints TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_stages_course ON primm_stages(course_id);
+CREATE INDEX IF NOT EXISTS idx_stages_type ON primm_stages(stage_type);
 
 -- ═══════════════════════════════════════════════════════════
 -- STUDENT PROGRESS: Enrollment ke Lesson
 -- ═══════════════════════════════════════════════════════════
-CREATE TABLE user_lessons (
+CREATE TABLE IF NOT EXISTS user_lessons (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     lesson_id INTEGER NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
     enrolled_at TIMESTAMP DEFAULT NOW(),
-    
-    UNIQUE(user_id, lesson_id) -- Student tidak boleh enroll 2x ke lesson yang sama
+    last_accessed_at TIMESTAMP,
+
+    UNIQUE(user_id, lesson_id)
 );
 
-CREATE INDEX idx_user_lessons_user ON user_lessons(user_id);
-CREATE INDEX idx_user_lessons_lesson ON user_lessons(lesson_id);
+ALTER TABLE user_lessons ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_user_lessons_user ON user_lessons(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_lessons_lesson ON user_lessons(lesson_id);
 
 -- ═══════════════════════════════════════════════════════════
 -- STUDENT PROGRESS: Completion per Course
 -- ═══════════════════════════════════════════════════════════
-CREATE TABLE user_course_completions (
+CREATE TABLE IF NOT EXISTS user_course_completions (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     course_id INTEGER NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
     is_completed BOOLEAN DEFAULT false,
     completed_at TIMESTAMP,
-    coins_earned INTEGER DEFAULT 0, -- Coins yang didapat dari course ini
-    
+    coins_earned INTEGER DEFAULT 0,
+
     UNIQUE(user_id, course_id)
 );
 
-CREATE INDEX idx_course_completions_user ON user_course_completions(user_id);
-CREATE INDEX idx_course_completions_course ON user_course_completions(course_id);
+CREATE INDEX IF NOT EXISTS idx_course_completions_user ON user_course_completions(user_id);
+CREATE INDEX IF NOT EXISTS idx_course_completions_course ON user_course_completions(course_id);
 
 -- ═══════════════════════════════════════════════════════════
 -- STUDENT PROGRESS: Completion per PRIMM Stage
 -- ═══════════════════════════════════════════════════════════
-CREATE TABLE user_stage_completions (
+CREATE TABLE IF NOT EXISTS user_stage_completions (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     stage_id INTEGER NOT NULL REFERENCES primm_stages(id) ON DELETE CASCADE,
-    
+
     -- PREDICT Stage Data
-    predict_selected_answer VARCHAR(10), -- Option yang dipilih: "A", "B", "C", "D"
+    predict_selected_answer VARCHAR(10),
     predict_is_correct BOOLEAN,
-    
+
     -- RUN Stage Data
-    run_submitted_code TEXT, -- Code yang ditulis student
-    run_output TEXT, -- Output hasil run code
-    
+    run_submitted_code TEXT,
+    run_output TEXT,
+
     -- INVESTIGATE Stage Data
-    investigate_reflection TEXT, -- Jawaban refleksi student
+    investigate_reflection TEXT,
     investigate_completed BOOLEAN DEFAULT false,
-    
+
     -- MODIFY Stage Data
-    modify_submitted_code TEXT, -- Code hasil modifikasi
-    modify_output TEXT, -- Output hasil run
-    modify_is_correct BOOLEAN, -- Apakah passed all test cases
-    modify_attempts INTEGER DEFAULT 0, -- Jumlah percobaan
-    
+    modify_submitted_code TEXT,
+    modify_output TEXT,
+    modify_is_correct BOOLEAN,
+    modify_attempts INTEGER DEFAULT 0,
+
     -- MAKE Stage Data
-    make_submitted_code TEXT, -- Code yang dibuat student
-    make_output TEXT, -- Output hasil run
-    make_is_correct BOOLEAN, -- Apakah passed all test cases
+    make_submitted_code TEXT,
+    make_output TEXT,
+    make_is_correct BOOLEAN,
     make_attempts INTEGER DEFAULT 0,
-    
+
     is_completed BOOLEAN DEFAULT false,
     completed_at TIMESTAMP,
-    
+
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
-    
+
     UNIQUE(user_id, stage_id)
 );
 
-CREATE INDEX idx_stage_completions_user ON user_stage_completions(user_id);
-CREATE INDEX idx_stage_completions_stage ON user_stage_completions(stage_id);
+CREATE INDEX IF NOT EXISTS idx_stage_completions_user ON user_stage_completions(user_id);
+CREATE INDEX IF NOT EXISTS idx_stage_completions_stage ON user_stage_completions(stage_id);
 
 -- ═══════════════════════════════════════════════════════════
 -- TRIGGERS: Auto-update updated_at
 -- ═══════════════════════════════════════════════════════════
-CREATE OR REPLACE FUNCTION update_updated_at_column()
-RETURNS TRIGGER AS $$
-BEGIN
-    NEW.updated_at = NOW();
-    RETURN NEW;
-END;
-$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_lessons_updated_at ON lessons;
 CREATE TRIGGER update_lessons_updated_at BEFORE UPDATE ON lessons
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_courses_updated_at ON courses;
 CREATE TRIGGER update_courses_updated_at BEFORE UPDATE ON courses
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_primm_stages_updated_at ON primm_stages;
 CREATE TRIGGER update_primm_stages_updated_at BEFORE UPDATE ON primm_stages
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_user_stage_completions_updated_at ON user_stage_completions;
 CREATE TRIGGER update_user_stage_completions_updated_at BEFORE UPDATE ON user_stage_completions
-    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
\ No newline at end of file
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK SUBMISSION QUEUE
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS submissions;
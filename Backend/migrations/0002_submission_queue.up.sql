@@ -0,0 +1,29 @@
+-- ═══════════════════════════════════════════════════════════
+-- SUBMISSION QUEUE: Grading asynchronous untuk MODIFY & MAKE
+-- Status: pending -> running -> graded (atau failed)
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS submissions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stage_id INTEGER NOT NULL REFERENCES primm_stages(id) ON DELETE CASCADE,
+    stage_type VARCHAR(20) NOT NULL CHECK (stage_type IN ('modify', 'make')),
+    submitted_code TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'graded', 'failed')),
+    is_correct BOOLEAN,
+    score INTEGER,
+    result JSONB, -- SubmitStageResponse lengkap (termasuk test_results)
+    error_message TEXT, -- Diisi jika status = 'failed'
+    created_at TIMESTAMP DEFAULT NOW(),
+    started_at TIMESTAMP,
+    graded_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_submissions_user ON submissions(user_id);
+CREATE INDEX IF NOT EXISTS idx_submissions_stage ON submissions(stage_id);
+-- Worker mengambil submission pending tertua lebih dulu
+CREATE INDEX IF NOT EXISTS idx_submissions_pending ON submissions(id) WHERE status = 'pending';
+
+DROP TRIGGER IF EXISTS update_submissions_updated_at ON submissions;
+CREATE TRIGGER update_submissions_updated_at BEFORE UPDATE ON submissions
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
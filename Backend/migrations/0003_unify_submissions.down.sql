@@ -0,0 +1,19 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK UNIFY SUBMISSIONS
+-- Tabel legacy dikembalikan ke nama lama (data yang masuk setelah merge
+-- tetap berada di user_stage_completions & user_course_completions)
+-- ═══════════════════════════════════════════════════════════
+DO $$
+BEGIN
+    IF to_regclass('public.stage_submissions_legacy') IS NOT NULL
+       AND to_regclass('public.stage_submissions') IS NULL THEN
+        ALTER TABLE stage_submissions_legacy RENAME TO stage_submissions;
+    END IF;
+
+    IF to_regclass('public.user_course_completion_legacy') IS NOT NULL
+       AND to_regclass('public.user_course_completion') IS NULL THEN
+        ALTER TABLE user_course_completion_legacy RENAME TO user_course_completion;
+    END IF;
+END $$;
+
+ALTER TABLE user_stage_completions DROP COLUMN IF EXISTS score;
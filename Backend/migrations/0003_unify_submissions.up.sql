@@ -0,0 +1,93 @@
+-- ═══════════════════════════════════════════════════════════
+-- UNIFY SUBMISSIONS: Satu set tabel progress untuk semua route submit
+-- Canonical: user_stage_completions & user_course_completions
+-- Legacy:    stage_submissions & user_course_completion (di-merge lalu di-rename *_legacy)
+-- Aman dijalankan berulang kali
+-- ═══════════════════════════════════════════════════════════
+
+-- Score terbaik (0-100) untuk route /api/stages/:id/submit
+ALTER TABLE user_stage_completions ADD COLUMN IF NOT EXISTS score INTEGER DEFAULT 0;
+
+-- ═══════════════════════════════════════════════════════════
+-- MERGE: stage_submissions -> user_stage_completions
+-- Data yang sudah ada di canonical table diprioritaskan,
+-- completion tidak pernah turun (is_completed di-OR)
+-- ═══════════════════════════════════════════════════════════
+DO $$
+BEGIN
+    IF to_regclass('public.stage_submissions') IS NOT NULL THEN
+        INSERT INTO user_stage_completions (
+            user_id, stage_id,
+            predict_selected_answer, predict_is_correct,
+            run_submitted_code, run_output,
+            investigate_reflection, investigate_completed,
+            modify_submitted_code, modify_output, modify_is_correct, modify_attempts,
+            make_submitted_code, make_output, make_is_correct, make_attempts,
+            score, is_completed, completed_at, created_at, updated_at
+        )
+        SELECT
+            ss.user_id, ss.stage_id,
+            CASE WHEN ss.submission_type = 'predict' THEN ss.submission_data->>'selected_answer' END,
+            CASE WHEN ss.submission_type = 'predict' THEN ss.is_correct END,
+            CASE WHEN ss.submission_type = 'run' THEN ss.submission_data->>'submitted_code' END,
+            CASE WHEN ss.submission_type = 'run' THEN ss.submission_data->>'code_output' END,
+            CASE WHEN ss.submission_type = 'investigate' THEN ss.submission_data->>'reflection_text' END,
+            ss.submission_type = 'investigate' AND ss.is_correct,
+            CASE WHEN ss.submission_type = 'modify' THEN ss.submission_data->>'modified_code' END,
+            CASE WHEN ss.submission_type = 'modify' THEN ss.submission_data->>'output' END,
+            CASE WHEN ss.submission_type = 'modify' THEN ss.is_correct END,
+            CASE WHEN ss.submission_type = 'modify' THEN 1 ELSE 0 END,
+            CASE WHEN ss.submission_type = 'make' THEN ss.submission_data->>'code' END,
+            CASE WHEN ss.submission_type = 'make' THEN ss.submission_data->>'output' END,
+            CASE WHEN ss.submission_type = 'make' THEN ss.is_correct END,
+            CASE WHEN ss.submission_type = 'make' THEN 1 ELSE 0 END,
+            COALESCE(ss.score, 0),
+            COALESCE(ss.is_correct, false),
+            CASE WHEN ss.is_correct THEN ss.submitted_at END,
+            ss.submitted_at,
+            ss.submitted_at
+        FROM stage_submissions ss
+        ON CONFLICT (user_id, stage_id) DO UPDATE SET
+            predict_selected_answer = COALESCE(user_stage_completions.predict_selected_answer, EXCLUDED.predict_selected_answer),
+            predict_is_correct      = COALESCE(user_stage_completions.predict_is_correct, EXCLUDED.predict_is_correct),
+            run_submitted_code      = COALESCE(user_stage_completions.run_submitted_code, EXCLUDED.run_submitted_code),
+            run_output              = COALESCE(user_stage_completions.run_output, EXCLUDED.run_output),
+            investigate_reflection  = COALESCE(user_stage_completions.investigate_reflection, EXCLUDED.investigate_reflection),
+            investigate_completed   = COALESCE(user_stage_completions.investigate_completed, false) OR EXCLUDED.investigate_completed,
+            modify_submitted_code   = COALESCE(user_stage_completions.modify_submitted_code, EXCLUDED.modify_submitted_code),
+            modify_output           = COALESCE(user_stage_completions.modify_output, EXCLUDED.modify_output),
+            modify_is_correct       = COALESCE(user_stage_completions.modify_is_correct, EXCLUDED.modify_is_correct),
+            modify_attempts         = COALESCE(user_stage_completions.modify_attempts, 0) + EXCLUDED.modify_attempts,
+            make_submitted_code     = COALESCE(user_stage_completions.make_submitted_code, EXCLUDED.make_submitted_code),
+            make_output             = COALESCE(user_stage_completions.make_output, EXCLUDED.make_output),
+            make_is_correct         = COALESCE(user_stage_completions.make_is_correct, EXCLUDED.make_is_correct),
+            make_attempts           = COALESCE(user_stage_completions.make_attempts, 0) + EXCLUDED.make_attempts,
+            score                   = GREATEST(COALESCE(user_stage_completions.score, 0), EXCLUDED.score),
+            is_completed            = COALESCE(user_stage_completions.is_completed, false) OR EXCLUDED.is_completed,
+            completed_at            = COALESCE(user_stage_completions.completed_at, EXCLUDED.completed_at),
+            updated_at              = NOW();
+
+        ALTER TABLE stage_submissions RENAME TO stage_submissions_legacy;
+    END IF;
+END $$;
+
+-- ═══════════════════════════════════════════════════════════
+-- MERGE: user_course_completion -> user_course_completions
+-- ═══════════════════════════════════════════════════════════
+DO $$
+BEGIN
+    IF to_regclass('public.user_course_completion') IS NOT NULL THEN
+        INSERT INTO user_course_completions (user_id, course_id, is_completed, completed_at, coins_earned)
+        SELECT
+            ucc.user_id, ucc.course_id, true,
+            COALESCE((to_jsonb(ucc)->>'completed_at')::timestamp, NOW()),
+            COALESCE(ucc.coins_awarded, 0)
+        FROM user_course_completion ucc
+        ON CONFLICT (user_id, course_id) DO UPDATE SET
+            is_completed = true,
+            completed_at = COALESCE(user_course_completions.completed_at, EXCLUDED.completed_at),
+            coins_earned = GREATEST(COALESCE(user_course_completions.coins_earned, 0), EXCLUDED.coins_earned);
+
+        ALTER TABLE user_course_completion RENAME TO user_course_completion_legacy;
+    END IF;
+END $$;
@@ -0,0 +1,5 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK STAGE ATTEMPTS
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS stage_attempts;
+DROP FUNCTION IF EXISTS prevent_stage_attempt_update();
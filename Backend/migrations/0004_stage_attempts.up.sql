@@ -0,0 +1,69 @@
+-- ═══════════════════════════════════════════════════════════
+-- STAGE ATTEMPTS: Riwayat semua submission (append-only)
+-- user_stage_completions tetap menyimpan jawaban terakhir & status complete,
+-- stage_attempts menyimpan setiap submission apa adanya
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS stage_attempts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stage_id INTEGER NOT NULL REFERENCES primm_stages(id) ON DELETE CASCADE,
+    stage_type VARCHAR(20) NOT NULL CHECK (stage_type IN ('predict', 'run', 'investigate', 'modify', 'make')),
+    answer TEXT NOT NULL, -- Code (run/modify/make), pilihan (predict) atau refleksi (investigate)
+    output TEXT, -- Output eksekusi / ringkasan test cases
+    verdict VARCHAR(20) NOT NULL CHECK (verdict IN ('correct', 'incorrect', 'compile_error')),
+    is_correct BOOLEAN NOT NULL DEFAULT false,
+    score INTEGER NOT NULL DEFAULT 0,
+    test_results JSONB, -- Feedback per test case (hidden test case sudah disensor)
+    submitted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stage_attempts_user_stage ON stage_attempts(user_id, stage_id, id);
+CREATE INDEX IF NOT EXISTS idx_stage_attempts_stage ON stage_attempts(stage_id);
+
+-- Append-only: attempt yang sudah tersimpan tidak boleh diubah
+CREATE OR REPLACE FUNCTION prevent_stage_attempt_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stage_attempts bersifat append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stage_attempts_append_only ON stage_attempts;
+CREATE TRIGGER stage_attempts_append_only BEFORE UPDATE ON stage_attempts
+    FOR EACH ROW EXECUTE FUNCTION prevent_stage_attempt_update();
+
+-- ═══════════════════════════════════════════════════════════
+-- BACKFILL: Jawaban terakhir di user_stage_completions menjadi attempt pertama
+-- (riwayat sebelum tabel ini ada tidak tersimpan)
+-- ═══════════════════════════════════════════════════════════
+INSERT INTO stage_attempts (user_id, stage_id, stage_type, answer, output, verdict, is_correct, score, submitted_at)
+SELECT usc.user_id, usc.stage_id, ps.stage_type,
+       CASE ps.stage_type
+           WHEN 'predict' THEN usc.predict_selected_answer
+           WHEN 'run' THEN usc.run_submitted_code
+           WHEN 'investigate' THEN usc.investigate_reflection
+           WHEN 'modify' THEN usc.modify_submitted_code
+           WHEN 'make' THEN usc.make_submitted_code
+       END,
+       CASE ps.stage_type
+           WHEN 'run' THEN usc.run_output
+           WHEN 'modify' THEN usc.modify_output
+           WHEN 'make' THEN usc.make_output
+       END,
+       CASE WHEN COALESCE(usc.is_completed, false) THEN 'correct' ELSE 'incorrect' END,
+       COALESCE(usc.is_completed, false),
+       COALESCE(usc.score, 0),
+       COALESCE(usc.updated_at, usc.created_at, NOW())
+FROM user_stage_completions usc
+JOIN primm_stages ps ON usc.stage_id = ps.id
+WHERE NOT EXISTS (
+    SELECT 1 FROM stage_attempts sa
+    WHERE sa.user_id = usc.user_id AND sa.stage_id = usc.stage_id
+)
+AND CASE ps.stage_type
+        WHEN 'predict' THEN usc.predict_selected_answer
+        WHEN 'run' THEN usc.run_submitted_code
+        WHEN 'investigate' THEN usc.investigate_reflection
+        WHEN 'modify' THEN usc.modify_submitted_code
+        WHEN 'make' THEN usc.make_submitted_code
+    END IS NOT NULL;
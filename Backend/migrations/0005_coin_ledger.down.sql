@@ -0,0 +1,10 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK COIN LEDGER
+-- Saldo dari ledger disalin kembali ke users.total_coins
+-- ═══════════════════════════════════════════════════════════
+ALTER TABLE users ADD COLUMN IF NOT EXISTS total_coins INTEGER DEFAULT 0;
+
+UPDATE users
+SET total_coins = COALESCE((SELECT SUM(ct.amount) FROM coin_transactions ct WHERE ct.user_id = users.id), 0);
+
+DROP TABLE IF EXISTS coin_transactions;
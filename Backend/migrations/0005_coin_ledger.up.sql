@@ -0,0 +1,54 @@
+-- ═══════════════════════════════════════════════════════════
+-- COIN LEDGER: Semua perubahan coins dicatat sebagai transaksi
+-- Saldo user = SUM(amount), users.total_coins tidak lagi disimpan
+-- Unique (user_id, source_type, source_id) = reward dibayar tepat sekali
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS coin_transactions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source_type VARCHAR(30) NOT NULL, -- 'stage', 'course', 'opening_balance'
+    source_id INTEGER NOT NULL, -- stage_id / course_id / user_id (opening_balance)
+    amount INTEGER NOT NULL, -- Coins (boleh negatif untuk koreksi)
+    xp INTEGER NOT NULL DEFAULT 0, -- XP yang diberikan bersama transaksi ini
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(user_id, source_type, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_transactions_user ON coin_transactions(user_id);
+
+-- ═══════════════════════════════════════════════════════════
+-- MIGRASI SALDO LAMA
+-- 1. Saldo users.total_coins menjadi transaksi 'opening_balance'
+-- 2. Stage & course yang sudah complete dicatat sebagai sudah dibayar
+--    (amount 0, karena coins-nya sudah termasuk di opening_balance)
+-- 3. Kolom users.total_coins di-drop
+-- ═══════════════════════════════════════════════════════════
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'users' AND column_name = 'total_coins'
+    ) THEN
+        INSERT INTO coin_transactions (user_id, source_type, source_id, amount, description)
+        SELECT id, 'opening_balance', id, total_coins, 'saldo sebelum coin ledger'
+        FROM users
+        WHERE COALESCE(total_coins, 0) <> 0
+        ON CONFLICT (user_id, source_type, source_id) DO NOTHING;
+
+        INSERT INTO coin_transactions (user_id, source_type, source_id, amount, description)
+        SELECT user_id, 'stage', stage_id, 0, 'sudah dibayar sebelum coin ledger'
+        FROM user_stage_completions
+        WHERE is_completed = true
+        ON CONFLICT (user_id, source_type, source_id) DO NOTHING;
+
+        INSERT INTO coin_transactions (user_id, source_type, source_id, amount, description)
+        SELECT user_id, 'course', course_id, 0, 'sudah dibayar sebelum coin ledger'
+        FROM user_course_completions
+        WHERE is_completed = true
+        ON CONFLICT (user_id, source_type, source_id) DO NOTHING;
+
+        ALTER TABLE users DROP COLUMN total_coins;
+    END IF;
+END $$;
@@ -0,0 +1,5 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK AUTH SESSIONS
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS auth_sessions;
@@ -0,0 +1,33 @@
+-- ═══════════════════════════════════════════════════════════
+-- AUTH SESSIONS: Satu baris per login (per device)
+-- Access token (JWT) membawa session_id, session yang dicabut
+-- langsung ditolak oleh AuthMiddleware
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_used_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL, -- Mundur setiap refresh token di-rotate
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user ON auth_sessions(user_id) WHERE revoked_at IS NULL;
+
+-- ═══════════════════════════════════════════════════════════
+-- REFRESH TOKENS: Disimpan sebagai SHA-256 hash, sekali pakai
+-- Token yang sudah dipakai lalu dipakai lagi = indikasi dicuri,
+-- seluruh session dicabut
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    session_id BIGINT NOT NULL REFERENCES auth_sessions(id) ON DELETE CASCADE,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP, -- Diisi saat token di-rotate
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session ON refresh_tokens(session_id);
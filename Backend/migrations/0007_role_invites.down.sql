@@ -0,0 +1,5 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK ROLE INVITES
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS role_audit_log;
+DROP TABLE IF EXISTS role_invites;
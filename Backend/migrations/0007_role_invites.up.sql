@@ -0,0 +1,30 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLE INVITES: Registrasi teacher/admin lewat invite code dari admin
+-- Code disimpan sebagai SHA-256 hash, sekali pakai, punya masa berlaku
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS role_invites (
+    id SERIAL PRIMARY KEY,
+    code_hash CHAR(64) UNIQUE NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('teacher', 'admin')),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL, -- NULL = dibuat lewat CLI
+    expires_at TIMESTAMP NOT NULL,
+    used_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ═══════════════════════════════════════════════════════════
+-- ROLE AUDIT LOG: Setiap perubahan role user (append-only)
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS role_audit_log (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    old_role VARCHAR(20), -- NULL = role awal saat registrasi
+    new_role VARCHAR(20) NOT NULL,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    invite_id INTEGER REFERENCES role_invites(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_audit_user ON role_audit_log(user_id, id);
@@ -0,0 +1,4 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK EMAIL VERIFICATION
+-- ═══════════════════════════════════════════════════════════
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
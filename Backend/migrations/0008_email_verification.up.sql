@@ -0,0 +1,11 @@
+-- ═══════════════════════════════════════════════════════════
+-- EMAIL VERIFICATION
+-- User yang sudah ada sebelum fitur ini dianggap sudah terverifikasi
+-- ═══════════════════════════════════════════════════════════
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'email_verified_at') THEN
+        ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+        UPDATE users SET email_verified_at = created_at;
+    END IF;
+END $$;
@@ -0,0 +1,4 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK LOGIN ATTEMPTS
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,12 @@
+-- ═══════════════════════════════════════════════════════════
+-- LOGIN ATTEMPTS: Counter login gagal per akun & per IP
+-- key = 'account:<email>' atau 'ip:<alamat ip>'
+-- Dipakai oleh login limiter (LOGIN_LIMITER_STORE=postgres)
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failed ON login_attempts(last_failed_at);
@@ -0,0 +1,5 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK OIDC SSO
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS oidc_login_states;
@@ -0,0 +1,29 @@
+-- ═══════════════════════════════════════════════════════════
+-- OIDC LOGIN STATES: state, nonce & PKCE code_verifier per percobaan login
+-- Disimpan di database agar callback bisa ditangani instance server mana saja
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state_hash CHAR(64) PRIMARY KEY,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- ═══════════════════════════════════════════════════════════
+-- USER IDENTITIES: Akun identity provider yang terhubung ke user
+-- (issuer, subject) unik per provider
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    last_login_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
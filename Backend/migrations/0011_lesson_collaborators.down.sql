@@ -0,0 +1,4 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK LESSON COLLABORATORS
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS lesson_collaborators;
@@ -0,0 +1,24 @@
+-- ═══════════════════════════════════════════════════════════
+-- LESSON COLLABORATORS: Co-teacher per lesson
+-- role: owner (kelola semua + collaborator), editor (ubah isi), viewer (lihat saja)
+-- accepted_at NULL = undangan belum diterima
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS lesson_collaborators (
+    id SERIAL PRIMARY KEY,
+    lesson_id INTEGER NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    invited_at TIMESTAMP DEFAULT NOW(),
+    accepted_at TIMESTAMP,
+
+    UNIQUE(lesson_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_collaborators_user ON lesson_collaborators(user_id);
+
+-- Pembuat lesson yang sudah ada menjadi owner
+INSERT INTO lesson_collaborators (lesson_id, user_id, role, invited_at, accepted_at)
+SELECT id, teacher_id, 'owner', created_at, created_at
+FROM lessons
+ON CONFLICT (lesson_id, user_id) DO NOTHING;
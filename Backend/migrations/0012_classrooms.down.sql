@@ -0,0 +1,7 @@
+-- ═══════════════════════════════════════════════════════════
+-- ROLLBACK CLASSROOMS
+-- (enrollment user_lessons hasil assign kelas tetap dipertahankan)
+-- ═══════════════════════════════════════════════════════════
+DROP TABLE IF EXISTS classroom_lessons;
+DROP TABLE IF EXISTS classroom_members;
+DROP TABLE IF EXISTS classrooms;
@@ -0,0 +1,50 @@
+-- ═══════════════════════════════════════════════════════════
+-- CLASSROOMS: Kelas milik teacher, student bergabung dengan join code
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS classrooms (
+    id SERIAL PRIMARY KEY,
+    teacher_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    join_code VARCHAR(16) UNIQUE NOT NULL,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_classrooms_teacher ON classrooms(teacher_id);
+
+-- ═══════════════════════════════════════════════════════════
+-- CLASSROOM MEMBERS: Roster student per kelas
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS classroom_members (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(classroom_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_classroom_members_user ON classroom_members(user_id);
+
+-- ═══════════════════════════════════════════════════════════
+-- CLASSROOM LESSONS: Lesson yang di-assign ke kelas
+-- (semua member otomatis di-enroll ke lesson ini)
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS classroom_lessons (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id) ON DELETE CASCADE,
+    lesson_id INTEGER NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    assigned_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    assigned_at TIMESTAMP DEFAULT NOW(),
+
+    UNIQUE(classroom_id, lesson_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_classroom_lessons_lesson ON classroom_lessons(lesson_id);
+
+-- Trigger updated_at
+DROP TRIGGER IF EXISTS update_classrooms_updated_at ON classrooms;
+CREATE TRIGGER update_classrooms_updated_at BEFORE UPDATE ON classrooms
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+ALTER TABLE stage_attempts DROP COLUMN IF EXISTS is_late;
+
+DROP TRIGGER IF EXISTS update_assignments_updated_at ON assignments;
+DROP TABLE IF EXISTS assignments;
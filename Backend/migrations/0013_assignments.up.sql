@@ -0,0 +1,38 @@
+-- ═══════════════════════════════════════════════════════════
+-- ASSIGNMENTS: Deadline lesson / course untuk satu kelas
+-- Submission di luar jendela open_at - due_at dicek di submission engine
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS assignments (
+    id SERIAL PRIMARY KEY,
+    classroom_id INTEGER NOT NULL REFERENCES classrooms(id) ON DELETE CASCADE,
+    lesson_id INTEGER REFERENCES lessons(id) ON DELETE CASCADE,
+    course_id INTEGER REFERENCES courses(id) ON DELETE CASCADE,
+    title VARCHAR(200) NOT NULL,
+    instructions TEXT,
+    open_at TIMESTAMP, -- NULL = langsung dibuka
+    due_at TIMESTAMP NOT NULL,
+    late_policy VARCHAR(20) NOT NULL DEFAULT 'allow' CHECK (late_policy IN ('allow', 'penalize', 'block')),
+    late_penalty_percent INTEGER NOT NULL DEFAULT 0 CHECK (late_penalty_percent BETWEEN 0 AND 100),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    -- Assignment menunjuk tepat satu lesson ATAU satu course
+    CHECK ((lesson_id IS NULL) <> (course_id IS NULL)),
+    CHECK (open_at IS NULL OR open_at < due_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignments_classroom ON assignments(classroom_id);
+CREATE INDEX IF NOT EXISTS idx_assignments_lesson ON assignments(lesson_id);
+CREATE INDEX IF NOT EXISTS idx_assignments_course ON assignments(course_id);
+
+-- Trigger updated_at
+DROP TRIGGER IF EXISTS update_assignments_updated_at ON assignments;
+CREATE TRIGGER update_assignments_updated_at BEFORE UPDATE ON assignments
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- ═══════════════════════════════════════════════════════════
+-- STAGE ATTEMPTS: Tandai submission yang masuk setelah due_at
+-- (tanpa FK ke assignments: stage_attempts append-only, ON DELETE SET NULL akan ditolak)
+-- ═══════════════════════════════════════════════════════════
+ALTER TABLE stage_attempts ADD COLUMN IF NOT EXISTS is_late BOOLEAN NOT NULL DEFAULT false;
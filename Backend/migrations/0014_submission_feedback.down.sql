@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_submission_feedback_updated_at ON submission_feedback;
+DROP TABLE IF EXISTS submission_feedback;
@@ -0,0 +1,20 @@
+-- ═══════════════════════════════════════════════════════════
+-- SUBMISSION FEEDBACK: Komentar teacher pada satu attempt student
+-- line_number NULL = feedback umum, terisi = komentar pada baris code tersebut
+-- ═══════════════════════════════════════════════════════════
+CREATE TABLE IF NOT EXISTS submission_feedback (
+    id SERIAL PRIMARY KEY,
+    attempt_id INTEGER NOT NULL REFERENCES stage_attempts(id) ON DELETE CASCADE,
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    line_number INTEGER CHECK (line_number >= 1),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_submission_feedback_attempt ON submission_feedback(attempt_id);
+
+-- Trigger updated_at
+DROP TRIGGER IF EXISTS update_submission_feedback_updated_at ON submission_feedback;
+CREATE TRIGGER update_submission_feedback_updated_at BEFORE UPDATE ON submission_feedback
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS update_reflection_reviews_updated_at ON reflection_reviews;
+DROP TABLE IF EXISTS reflection_reviews;
+
+-- Attempt pending_review dikembalikan menjadi incorrect (append-only trigger dimatikan sementara)
+ALTER TABLE stage_attempts DISABLE TRIGGER stage_attempts_append_only;
+UPDATE stage_attempts SET verdict = 'incorrect' WHERE verdict = 'pending_review';
+ALTER TABLE stage_attempts ENABLE TRIGGER stage_attempts_append_only;
+
+ALTER TABLE stage_attempts DROP CONSTRAINT IF EXISTS stage_attempts_verdict_check;
+ALTER TABLE stage_attempts ADD CONSTRAINT stage_attempts_verdict_check
+    CHECK (verdict IN ('correct', 'incorrect', 'compile_error'));
+
+ALTER TABLE primm_stages DROP COLUMN IF EXISTS investigate_rubric;
@@ -0,0 +1,40 @@
+-- ═══════════════════════════════════════════════════════════
+-- REFLECTION REVIEWS: Refleksi INVESTIGATE dinilai manual oleh teacher
+-- Rubric (kriteria + poin maksimal) disimpan di primm_stages.investigate_rubric dan
+-- di-snapshot ke reflection_reviews.rubric saat refleksi masuk review queue
+-- Completion, coins & XP baru diberikan saat refleksi di-approve
+-- ═══════════════════════════════════════════════════════════
+ALTER TABLE primm_stages ADD COLUMN IF NOT EXISTS investigate_rubric JSONB;
+
+-- Attempt INVESTIGATE disimpan dengan verdict pending_review (hasil review ada di reflection_reviews)
+ALTER TABLE stage_attempts DROP CONSTRAINT IF EXISTS stage_attempts_verdict_check;
+ALTER TABLE stage_attempts ADD CONSTRAINT stage_attempts_verdict_check
+    CHECK (verdict IN ('correct', 'incorrect', 'compile_error', 'pending_review'));
+
+CREATE TABLE IF NOT EXISTS reflection_reviews (
+    id SERIAL PRIMARY KEY,
+    attempt_id INTEGER NOT NULL UNIQUE REFERENCES stage_attempts(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stage_id INTEGER NOT NULL REFERENCES primm_stages(id) ON DELETE CASCADE,
+    -- superseded = student mengirim refleksi baru sebelum yang lama dinilai
+    status VARCHAR(20) NOT NULL DEFAULT 'pending_review'
+        CHECK (status IN ('pending_review', 'approved', 'rejected', 'superseded')),
+    late_penalty_percent INTEGER NOT NULL DEFAULT 0 CHECK (late_penalty_percent BETWEEN 0 AND 100),
+    -- Snapshot rubric stage saat refleksi masuk antrian: teacher yang mengubah rubric
+    -- tidak mengubah review yang sudah antri / dinilai
+    rubric JSONB,
+    rubric_scores JSONB, -- Poin per kriteria rubric (urutan & max_points sama dengan kolom rubric)
+    score INTEGER CHECK (score BETWEEN 0 AND 100),
+    reviewer_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reflection_reviews_stage_status ON reflection_reviews(stage_id, status);
+CREATE INDEX IF NOT EXISTS idx_reflection_reviews_user_stage ON reflection_reviews(user_id, stage_id);
+
+-- Trigger updated_at
+DROP TRIGGER IF EXISTS update_reflection_reviews_updated_at ON reflection_reviews;
+CREATE TRIGGER update_reflection_reviews_updated_at BEFORE UPDATE ON reflection_reviews
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,301 @@
+package migrations
+
+import (
+    "context"
+    "embed"
+    "errors"
+    "fmt"
+    "io/fs"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ═══════════════════════════════════════════════════════════
+// DATABASE MIGRATIONS
+// File: NNNN_nama.up.sql & NNNN_nama.down.sql (di-embed ke binary)
+// Versi yang sudah dijalankan dicatat di tabel schema_migrations
+// ═══════════════════════════════════════════════════════════
+
+//go:embed *.sql
+var files embed.FS
+
+// advisoryLockID mencegah beberapa instance server menjalankan migration bersamaan
+const advisoryLockID = 72461001
+
+const createMigrationsTable = `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version BIGINT PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at TIMESTAMP DEFAULT NOW()
+    )`
+
+// Migration adalah satu versi schema beserta SQL up & down
+type Migration struct {
+    Version int64
+    Name    string
+    Up      string
+    Down    string
+}
+
+// MigrationStatus adalah status satu migration di database
+type MigrationStatus struct {
+    Version   int64
+    Name      string
+    AppliedAt *time.Time // nil jika belum dijalankan
+}
+
+// Load membaca semua migration yang di-embed, urut berdasarkan versi
+func Load() ([]Migration, error) {
+    entries, err := fs.ReadDir(files, ".")
+    if err != nil {
+        return nil, errors.New("gagal membaca migrations: " + err.Error())
+    }
+
+    byVersion := make(map[int64]*Migration)
+    for _, entry := range entries {
+        fileName := entry.Name()
+
+        var direction string
+        switch {
+        case strings.HasSuffix(fileName, ".up.sql"):
+            direction = "up"
+        case strings.HasSuffix(fileName, ".down.sql"):
+            direction = "down"
+        default:
+            return nil, errors.New("nama file migration tidak valid: " + fileName)
+        }
+
+        base := strings.TrimSuffix(fileName, "."+direction+".sql")
+        versionPart, name, found := strings.Cut(base, "_")
+        if !found {
+            return nil, errors.New("nama file migration tidak valid: " + fileName)
+        }
+        version, err := strconv.ParseInt(versionPart, 10, 64)
+        if err != nil || version <= 0 {
+            return nil, errors.New("versi migration tidak valid: " + fileName)
+        }
+
+        content, err := files.ReadFile(fileName)
+        if err != nil {
+            return nil, errors.New("gagal membaca " + fileName + ": " + err.Error())
+        }
+
+        m := byVersion[version]
+        if m == nil {
+            m = &Migration{Version: version, Name: name}
+            byVersion[version] = m
+        }
+        if m.Name != name {
+            return nil, fmt.Errorf("versi %d dipakai oleh dua migration berbeda", version)
+        }
+
+        if direction == "up" {
+            m.Up = string(content)
+        } else {
+            m.Down = string(content)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" {
+            return nil, fmt.Errorf("migration %d_%s tidak punya file .up.sql", m.Version, m.Name)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool {
+        return migrations[i].Version < migrations[j].Version
+    })
+
+    return migrations, nil
+}
+
+// Up menjalankan semua migration yang belum diterapkan
+// Return migration yang baru saja dijalankan
+func Up(ctx context.Context, db *pgxpool.Pool) ([]Migration, error) {
+    migrations, err := Load()
+    if err != nil {
+        return nil, err
+    }
+
+    var applied []Migration
+    err = withLock(ctx, db, func(conn *pgxpool.Conn) error {
+        versions, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for _, m := range migrations {
+            if _, ok := versions[m.Version]; ok {
+                continue
+            }
+
+            err := runInTx(ctx, conn, m.Up, func(tx pgx.Tx) error {
+                _, err := tx.Exec(ctx,
+                    "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+                    m.Version, m.Name)
+                return err
+            })
+            if err != nil {
+                return fmt.Errorf("gagal menjalankan migration %d_%s: %w", m.Version, m.Name, err)
+            }
+
+            applied = append(applied, m)
+        }
+
+        return nil
+    })
+
+    return applied, err
+}
+
+// Down me-rollback sejumlah migration terakhir (urut dari versi terbaru)
+// Return migration yang di-rollback
+func Down(ctx context.Context, db *pgxpool.Pool, steps int) ([]Migration, error) {
+    if steps < 1 {
+        return nil, errors.New("jumlah step minimal 1")
+    }
+
+    migrations, err := Load()
+    if err != nil {
+        return nil, err
+    }
+
+    var reverted []Migration
+    err = withLock(ctx, db, func(conn *pgxpool.Conn) error {
+        versions, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+            m := migrations[i]
+            if _, ok := versions[m.Version]; !ok {
+                continue
+            }
+            if m.Down == "" {
+                return fmt.Errorf("migration %d_%s tidak punya file .down.sql", m.Version, m.Name)
+            }
+
+            err := runInTx(ctx, conn, m.Down, func(tx pgx.Tx) error {
+                _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+                return err
+            })
+            if err != nil {
+                return fmt.Errorf("gagal rollback migration %d_%s: %w", m.Version, m.Name, err)
+            }
+
+            reverted = append(reverted, m)
+        }
+
+        return nil
+    })
+
+    return reverted, err
+}
+
+// Status mengambil status semua migration (sudah / belum dijalankan)
+func Status(ctx context.Context, db *pgxpool.Pool) ([]MigrationStatus, error) {
+    migrations, err := Load()
+    if err != nil {
+        return nil, err
+    }
+
+    if _, err := db.Exec(ctx, createMigrationsTable); err != nil {
+        return nil, errors.New("gagal membuat tabel schema_migrations: " + err.Error())
+    }
+
+    rows, err := db.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+    if err != nil {
+        return nil, errors.New("gagal mengambil schema_migrations: " + err.Error())
+    }
+    defer rows.Close()
+
+    appliedAt := make(map[int64]time.Time)
+    for rows.Next() {
+        var version int64
+        var at time.Time
+        if err := rows.Scan(&version, &at); err != nil {
+            return nil, errors.New("gagal scan schema_migrations: " + err.Error())
+        }
+        appliedAt[version] = at
+    }
+    if err := rows.Err(); err != nil {
+        return nil, errors.New("gagal mengambil schema_migrations: " + err.Error())
+    }
+
+    statuses := make([]MigrationStatus, 0, len(migrations))
+    for _, m := range migrations {
+        status := MigrationStatus{Version: m.Version, Name: m.Name}
+        if at, ok := appliedAt[m.Version]; ok {
+            status.AppliedAt = &at
+        }
+        statuses = append(statuses, status)
+    }
+
+    return statuses, nil
+}
+
+// withLock menjalankan fn di satu koneksi yang memegang advisory lock
+func withLock(ctx context.Context, db *pgxpool.Pool, fn func(conn *pgxpool.Conn) error) error {
+    conn, err := db.Acquire(ctx)
+    if err != nil {
+        return errors.New("gagal mengambil koneksi database: " + err.Error())
+    }
+    defer conn.Release()
+
+    if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+        return errors.New("gagal mengunci migration: " + err.Error())
+    }
+    defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+
+    if _, err := conn.Exec(ctx, createMigrationsTable); err != nil {
+        return errors.New("gagal membuat tabel schema_migrations: " + err.Error())
+    }
+
+    return fn(conn)
+}
+
+// appliedVersions mengambil versi migration yang sudah dijalankan
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]struct{}, error) {
+    rows, err := conn.Query(ctx, "SELECT version FROM schema_migrations")
+    if err != nil {
+        return nil, errors.New("gagal mengambil schema_migrations: " + err.Error())
+    }
+    defer rows.Close()
+
+    versions := make(map[int64]struct{})
+    for rows.Next() {
+        var version int64
+        if err := rows.Scan(&version); err != nil {
+            return nil, errors.New("gagal scan schema_migrations: " + err.Error())
+        }
+        versions[version] = struct{}{}
+    }
+
+    return versions, rows.Err()
+}
+
+// runInTx menjalankan SQL migration + pencatatan versi dalam satu transaction
+// (Exec tanpa argumen memakai simple protocol, jadi satu file boleh berisi banyak statement)
+func runInTx(ctx context.Context, conn *pgxpool.Conn, sql string, record func(tx pgx.Tx) error) error {
+    tx, err := conn.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, sql); err != nil {
+        return err
+    }
+    if err := record(tx); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
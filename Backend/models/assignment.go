@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Late policy assignment (submission setelah due_at)
+const (
+    LatePolicyAllow    = "allow"    // Diterima, hanya ditandai terlambat
+    LatePolicyPenalize = "penalize" // Diterima, score dikurangi late_penalty_percent
+    LatePolicyBlock    = "block"    // Ditolak
+)
+
+// Status assignment dilihat dari waktu sekarang
+const (
+    AssignmentStatusUpcoming = "upcoming" // Belum dibuka (sebelum open_at)
+    AssignmentStatusOpen     = "open"
+    AssignmentStatusPastDue  = "past_due"
+)
+
+// Assignment model (deadline lesson / course untuk satu kelas)
+type Assignment struct {
+    ID                 int        `json:"id"`
+    ClassroomID        int        `json:"classroom_id"`
+    ClassroomName      string     `json:"classroom_name"`
+    LessonID           *int       `json:"lesson_id,omitempty"`
+    CourseID           *int       `json:"course_id,omitempty"`
+    TargetTitle        string     `json:"target_title"` // Judul lesson / course
+    Title              string     `json:"title"`
+    Instructions       string     `json:"instructions"`
+    OpenAt             *time.Time `json:"open_at,omitempty"`
+    DueAt              time.Time  `json:"due_at"`
+    LatePolicy         string     `json:"late_policy"`
+    LatePenaltyPercent int        `json:"late_penalty_percent"`
+    Status             string     `json:"status"` // upcoming, open, past_due
+    CreatedBy          *int       `json:"created_by,omitempty"`
+    CreatedAt          time.Time  `json:"created_at"`
+    UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// CreateAssignmentRequest untuk membuat assignment (isi lesson_id ATAU course_id)
+type CreateAssignmentRequest struct {
+    LessonID           *int       `json:"lesson_id"`
+    CourseID           *int       `json:"course_id"`
+    Title              string     `json:"title" binding:"required,min=3,max=200"`
+    Instructions       string     `json:"instructions"`
+    OpenAt             *time.Time `json:"open_at"`
+    DueAt              time.Time  `json:"due_at" binding:"required"`
+    LatePolicy         string     `json:"late_policy" binding:"omitempty,oneof=allow penalize block"`
+    LatePenaltyPercent int        `json:"late_penalty_percent" binding:"min=0,max=100"`
+}
+
+// UpdateAssignmentRequest untuk update jadwal / late policy assignment
+type UpdateAssignmentRequest struct {
+    Title              string     `json:"title" binding:"omitempty,min=3,max=200"`
+    Instructions       *string    `json:"instructions"`
+    OpenAt             *time.Time `json:"open_at"`
+    DueAt              *time.Time `json:"due_at"`
+    LatePolicy         string     `json:"late_policy" binding:"omitempty,oneof=allow penalize block"`
+    LatePenaltyPercent *int       `json:"late_penalty_percent" binding:"omitempty,min=0,max=100"`
+}
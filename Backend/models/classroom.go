@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Classroom model (kelas milik teacher)
+type Classroom struct {
+    ID          int       `json:"id"`
+    TeacherID   int       `json:"teacher_id"`
+    TeacherName string    `json:"teacher_name"`
+    Name        string    `json:"name"`
+    Description string    `json:"description"`
+    JoinCode    string    `json:"join_code"`
+    IsActive    bool      `json:"is_active"`
+    MemberCount int       `json:"member_count"`
+    LessonCount int       `json:"lesson_count"`
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ClassroomMember adalah student di roster kelas
+type ClassroomMember struct {
+    UserID   int       `json:"user_id"`
+    FullName string    `json:"full_name"`
+    Email    string    `json:"email"`
+    JoinedAt time.Time `json:"joined_at"`
+}
+
+// ClassroomLesson adalah lesson yang di-assign ke kelas
+type ClassroomLesson struct {
+    LessonID   int       `json:"lesson_id"`
+    Title      string    `json:"title"`
+    Category   string    `json:"category"`
+    Difficulty string    `json:"difficulty"`
+    AssignedAt time.Time `json:"assigned_at"`
+}
+
+// ClassroomDetail untuk response detail kelas (roster + lesson)
+type ClassroomDetail struct {
+    Classroom
+    Members []ClassroomMember `json:"members"`
+    Lessons []ClassroomLesson `json:"lessons"`
+}
+
+// CreateClassroomRequest untuk membuat kelas baru
+type CreateClassroomRequest struct {
+    Name        string `json:"name" binding:"required,min=3,max=200"`
+    Description string `json:"description"`
+}
+
+// UpdateClassroomRequest untuk update kelas existing
+type UpdateClassroomRequest struct {
+    Name        string `json:"name" binding:"omitempty,min=3,max=200"`
+    Description string `json:"description"`
+    IsActive    *bool  `json:"is_active"`
+}
+
+// JoinClassroomRequest untuk student bergabung ke kelas
+type JoinClassroomRequest struct {
+    JoinCode string `json:"join_code" binding:"required"`
+}
+
+// AssignLessonRequest untuk assign lesson ke kelas
+type AssignLessonRequest struct {
+    LessonID int `json:"lesson_id" binding:"required"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// SubmissionFeedback adalah komentar teacher pada satu attempt student
+// LineNumber nil = feedback umum, terisi = komentar pada baris code (mulai 1)
+type SubmissionFeedback struct {
+    ID         int       `json:"id"`
+    AttemptID  int       `json:"attempt_id"`
+    AuthorID   *int      `json:"author_id,omitempty"`
+    AuthorName string    `json:"author_name"`
+    Body       string    `json:"body"`
+    LineNumber *int      `json:"line_number,omitempty"`
+    CreatedAt  time.Time `json:"created_at"`
+    UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CreateFeedbackRequest untuk menambah feedback ke attempt
+type CreateFeedbackRequest struct {
+    Body       string `json:"body" binding:"required,min=1,max=5000"`
+    LineNumber *int   `json:"line_number" binding:"omitempty,min=1"`
+}
+
+// UpdateFeedbackRequest untuk mengubah isi feedback (baris tidak bisa dipindah)
+type UpdateFeedbackRequest struct {
+    Body string `json:"body" binding:"required,min=1,max=5000"`
+}
+
+// StageSubmissionSummary adalah ringkasan submission satu student di satu stage
+type StageSubmissionSummary struct {
+    UserID          int       `json:"user_id"`
+    FullName        string    `json:"full_name"`
+    Email           string    `json:"email"`
+    Attempts        int       `json:"attempts"`
+    LatestAttemptID int       `json:"latest_attempt_id"`
+    LatestVerdict   string    `json:"latest_verdict"`
+    BestScore       int       `json:"best_score"`
+    IsCompleted     bool      `json:"is_completed"`
+    HasLateAttempt  bool      `json:"has_late_attempt"`
+    FeedbackCount   int       `json:"feedback_count"`
+    LastSubmittedAt time.Time `json:"last_submitted_at"`
+}
+
+// StudentSubmissions adalah semua attempt satu student di satu stage (beserta feedback)
+type StudentSubmissions struct {
+    StageID   int            `json:"stage_id"`
+    StageType string         `json:"stage_type"`
+    UserID    int            `json:"user_id"`
+    FullName  string         `json:"full_name"`
+    Email     string         `json:"email"`
+    Attempts  []StageAttempt `json:"attempts"`
+}
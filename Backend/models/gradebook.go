@@ -0,0 +1,110 @@
+package models
+
+import "time"
+
+// Status student / cell di gradebook
+const (
+    GradebookStatusCompleted  = "completed"
+    GradebookStatusInProgress = "in_progress"
+    GradebookStatusNotStarted = "not_started"
+    GradebookStatusStuck      = "stuck" // Hanya untuk filter student (lihat IsStuck)
+)
+
+// GradebookQuery adalah filter, sorting & pagination gradebook (query string)
+type GradebookQuery struct {
+    ClassroomID int    `form:"classroom_id"` // 0 = semua student yang enroll lesson
+    Search      string `form:"search"`       // Nama / email student
+    Status      string `form:"status" binding:"omitempty,oneof=completed in_progress not_started stuck"`
+    CourseID    int    `form:"course_id"` // Hanya kolom stage dari course ini
+    Sort        string `form:"sort" binding:"omitempty,oneof=name progress score last_activity attempts"`
+    Order       string `form:"order" binding:"omitempty,oneof=asc desc"`
+    Page        int    `form:"page" binding:"omitempty,min=1"`
+    Limit       int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// GradebookCourse adalah kolom course di gradebook
+type GradebookCourse struct {
+    CourseID   int    `json:"course_id"`
+    Title      string `json:"title"`
+    OrderIndex int    `json:"order_index"`
+    CoinReward int    `json:"coin_reward"`
+}
+
+// GradebookStage adalah kolom stage di gradebook
+type GradebookStage struct {
+    StageID    int    `json:"stage_id"`
+    CourseID   int    `json:"course_id"`
+    StageType  string `json:"stage_type"`
+    Title      string `json:"title"`
+    OrderIndex int    `json:"order_index"`
+}
+
+// GradebookCell adalah progress satu student di satu stage
+type GradebookCell struct {
+    StageID        int        `json:"stage_id"`
+    Status         string     `json:"status"` // completed, in_progress, not_started
+    Attempts       int        `json:"attempts"`
+    Score          int        `json:"score"` // Score terbaik
+    HasLateAttempt bool       `json:"has_late_attempt"`
+    CompletedAt    *time.Time `json:"completed_at,omitempty"`
+    LastActivityAt *time.Time `json:"last_activity_at,omitempty"`
+}
+
+// GradebookCourseCell adalah status course satu student
+type GradebookCourseCell struct {
+    CourseID    int        `json:"course_id"`
+    IsCompleted bool       `json:"is_completed"`
+    CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// GradebookRow adalah satu baris student di gradebook
+type GradebookRow struct {
+    UserID           int                   `json:"user_id"`
+    FullName         string                `json:"full_name"`
+    Email            string                `json:"email"`
+    Status           string                `json:"status"` // completed, in_progress, not_started
+    IsStuck          bool                  `json:"is_stuck"`
+    CompletedStages  int                   `json:"completed_stages"`
+    TotalStages      int                   `json:"total_stages"`
+    ProgressPercent  float64               `json:"progress_percent"`
+    TotalScore       int                   `json:"total_score"`
+    TotalAttempts    int                   `json:"total_attempts"`
+    CompletedCourses int                   `json:"completed_courses"`
+    CoinsEarned      int                   `json:"coins_earned"` // Coins dari stage & course di lesson ini
+    LastActivityAt   *time.Time            `json:"last_activity_at,omitempty"`
+    Stages           []GradebookCell       `json:"stages"`
+    Courses          []GradebookCourseCell `json:"courses"`
+}
+
+// Gradebook adalah matrix student × stage untuk satu lesson
+type Gradebook struct {
+    LessonID    int               `json:"lesson_id"`
+    LessonTitle string            `json:"lesson_title"`
+    ClassroomID *int              `json:"classroom_id,omitempty"`
+    Courses     []GradebookCourse `json:"courses"`
+    Stages      []GradebookStage  `json:"stages"`
+    Students    []GradebookRow    `json:"students"`
+    Total       int               `json:"total"` // Jumlah student setelah filter (sebelum pagination)
+    Page        int               `json:"page"`
+    Limit       int               `json:"limit"`
+}
+
+// LessonAnalytics adalah ringkasan progress kelas untuk satu lesson (teacher dashboard)
+type LessonAnalytics struct {
+    LessonID          int        `json:"lesson_id"`
+    Title             string     `json:"title"`
+    IsActive          bool       `json:"is_active"`
+    TotalStages       int        `json:"total_stages"`
+    EnrolledStudents  int        `json:"enrolled_students"`
+    CompletedStudents int        `json:"completed_students"`
+    StuckStudents     int        `json:"stuck_students"`
+    AverageProgress   float64    `json:"average_progress"` // Persen (0-100)
+    AverageScore      float64    `json:"average_score"`    // Rata-rata score per stage yang dikerjakan
+    LastActivityAt    *time.Time `json:"last_activity_at,omitempty"`
+}
+
+// TeacherDashboard adalah ringkasan semua lesson & kelas teacher
+type TeacherDashboard struct {
+    Lessons    []LessonAnalytics `json:"lessons"`
+    Classrooms []Classroom       `json:"classrooms"`
+}
@@ -58,4 +58,37 @@ type UpdateLessonRequest struct {
     Difficulty   string `json:"difficulty" binding:"omitempty,oneof=beginner intermediate advanced"`
     ThumbnailURL string `json:"thumbnail_url" binding:"omitempty,url"`
     IsActive     *bool  `json:"is_active"`
-}
\ No newline at end of file
+}
+// Role collaborator lesson
+const (
+    CollaboratorOwner  = "owner"  // Kelola isi, hapus lesson & kelola collaborator
+    CollaboratorEditor = "editor" // Ubah lesson, course & stage
+    CollaboratorViewer = "viewer" // Lihat isi & progress student saja
+)
+
+// LessonCollaborator adalah teacher yang ikut mengelola lesson
+type LessonCollaborator struct {
+    LessonID   int        `json:"lesson_id"`
+    UserID     int        `json:"user_id"`
+    FullName   string     `json:"full_name"`
+    Email      string     `json:"email"`
+    Role       string     `json:"role"`
+    InvitedBy  *int       `json:"invited_by"`
+    InvitedAt  time.Time  `json:"invited_at"`
+    AcceptedAt *time.Time `json:"accepted_at"` // nil = undangan belum diterima
+}
+
+// CollaboratorInvite adalah undangan collaborator untuk user yang login
+type CollaboratorInvite struct {
+    LessonID      int       `json:"lesson_id"`
+    LessonTitle   string    `json:"lesson_title"`
+    Role          string    `json:"role"`
+    InvitedByName *string   `json:"invited_by_name"`
+    InvitedAt     time.Time `json:"invited_at"`
+}
+
+// InviteCollaboratorRequest untuk mengundang teacher lain ke lesson
+type InviteCollaboratorRequest struct {
+    Email string `json:"email" binding:"required,email"`
+    Role  string `json:"role" binding:"required,oneof=owner editor viewer"`
+}
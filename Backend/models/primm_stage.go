@@ -30,10 +30,11 @@ type PRIMMStage struct {
     RunCodeTemplate *string `json:"run_code_template,omitempty"`
 
     // INVESTIGATE specific fields
-    VideoEmbedURL     *string  `json:"video_embed_url,omitempty"`
-    ExplanationText   *string  `json:"explanation_text,omitempty"`
-    GuidingQuestions  []string `json:"guiding_questions,omitempty"`
-    ReflectionPrompt  *string  `json:"reflection_prompt,omitempty"`
+    VideoEmbedURL     *string           `json:"video_embed_url,omitempty"`
+    ExplanationText   *string           `json:"explanation_text,omitempty"`
+    GuidingQuestions  []string          `json:"guiding_questions,omitempty"`
+    ReflectionPrompt  *string           `json:"reflection_prompt,omitempty"`
+    InvestigateRubric []RubricCriterion `json:"investigate_rubric,omitempty"` // Kriteria penilaian refleksi
 
     // MODIFY specific fields
     ModifyChallenge      *string    `json:"modify_challenge,omitempty"`
@@ -50,9 +51,33 @@ type PRIMMStage struct {
 
 // TestCase untuk validasi output code
 type TestCase struct {
-    Input          string `json:"input"`
-    ExpectedOutput string `json:"expected_output"`
-    Description    string `json:"description,omitempty"`
+    Input          string  `json:"input"`
+    ExpectedOutput string  `json:"expected_output"`
+    Description    string  `json:"description,omitempty"`
+    Hidden         bool    `json:"hidden,omitempty"` // Dinilai tapi tidak ditampilkan ke student
+    Weight         int     `json:"weight,omitempty" binding:"omitempty,min=1,max=100"` // Bobot nilai (default 1)
+    Comparison     string  `json:"comparison,omitempty" binding:"omitempty,oneof=exact trim case_insensitive regex float"` // Default: trim
+    Tolerance      float64 `json:"tolerance,omitempty" binding:"omitempty,min=0"` // Untuk comparison float (default 1e-6)
+}
+
+// Comparison modes untuk TestCase
+const (
+    ComparisonExact           = "exact"            // Output harus sama persis
+    ComparisonTrim            = "trim"             // Abaikan trailing whitespace (default)
+    ComparisonCaseInsensitive = "case_insensitive" // Abaikan huruf besar/kecil
+    ComparisonRegex           = "regex"            // ExpectedOutput adalah regex (full match)
+    ComparisonFloat           = "float"            // Angka dibandingkan dengan toleransi
+)
+
+// VisibleTestCases mengembalikan test case yang boleh dilihat student
+func VisibleTestCases(testCases []TestCase) []TestCase {
+    var visible []TestCase
+    for _, tc := range testCases {
+        if !tc.Hidden {
+            visible = append(visible, tc)
+        }
+    }
+    return visible
 }
 
 // ═══════════════════════════════════════════════════════════
@@ -80,13 +105,14 @@ type CreateRunStageRequest struct {
 
 // CreateInvestigateStageRequest untuk membuat INVESTIGATE stage
 type CreateInvestigateStageRequest struct {
-    CourseID          int      `json:"course_id" binding:"required"`
-    Title             string   `json:"title" binding:"required"`
-    Description       string   `json:"description" binding:"required"`
-    VideoURL          string   `json:"video_url" binding:"required,url"`
-    ExplanationText   string   `json:"explanation_text"` // ← HAPUS 'required', jadi OPTIONAL
-    GuidingQuestions  []string `json:"guiding_questions" binding:"required,min=1"`
-    ReflectionPrompt  string   `json:"reflection_prompt"` // ← HAPUS 'required', jadi OPTIONAL
+    CourseID         int               `json:"course_id" binding:"required"`
+    Title            string            `json:"title" binding:"required"`
+    Description      string            `json:"description" binding:"required"`
+    VideoURL         string            `json:"video_url" binding:"required,url"`
+    ExplanationText  string            `json:"explanation_text"` // ← HAPUS 'required', jadi OPTIONAL
+    GuidingQuestions []string          `json:"guiding_questions" binding:"required,min=1"`
+    ReflectionPrompt string            `json:"reflection_prompt"` // ← HAPUS 'required', jadi OPTIONAL
+    Rubric           []RubricCriterion `json:"rubric" binding:"max=20,dive"` // Opsional, bisa diganti lewat PUT /stages/:id/rubric
 }
 
 // CreateModifyStageRequest untuk membuat MODIFY stage
@@ -117,7 +143,8 @@ type CreateMakeStageRequest struct {
 type StageSubmissionRequest struct {
     SubmissionType string                 `json:"submission_type" binding:"required,oneof=predict run investigate modify make"`
     SelectedAnswer string                 `json:"selected_answer,omitempty"` // For PREDICT
-    CodeOutput     string                 `json:"code_output,omitempty"`     // For RUN
+    SubmittedCode  string                 `json:"submitted_code,omitempty"`  // For RUN (code yang ditulis ulang)
+    CodeOutput     string                 `json:"code_output,omitempty"`     // Deprecated: output dihitung server
     ReflectionText string                 `json:"reflection_text,omitempty"` // For INVESTIGATE
     ModifiedCode   string                 `json:"modified_code,omitempty"`   // For MODIFY
     Code           string                 `json:"code,omitempty"`            // For MAKE
@@ -133,5 +160,7 @@ type StageSubmission struct {
     SubmissionData map[string]interface{} `json:"submission_data"`
     IsCorrect      bool                   `json:"is_correct"`
     Score          int                    `json:"score"`
+    IsLate         bool                   `json:"is_late"` // Submit setelah due_at assignment kelas
+    PendingReview  bool                   `json:"pending_review,omitempty"` // INVESTIGATE: menunggu dinilai teacher
     SubmittedAt    time.Time              `json:"submitted_at"`
 }
@@ -56,6 +56,7 @@ type UserStageCompletion struct {
     MakeIsCorrect     bool   `json:"make_is_correct"`
     MakeAttempts      int    `json:"make_attempts"`
     
+    Score       int        `json:"score"` // Score terbaik (0-100)
     IsCompleted bool       `json:"is_completed"`
     CompletedAt *time.Time `json:"completed_at,omitempty"`
     
@@ -82,7 +83,7 @@ type SubmitRunRequest struct {
 // SubmitInvestigateRequest untuk submit refleksi INVESTIGATE stage
 type SubmitInvestigateRequest struct {
     StageID    int    `json:"stage_id" binding:"required"`
-    Reflection string `json:"reflection" binding:"required,min=20"`
+    Reflection string `json:"reflection" binding:"required"` // Dinilai manual oleh teacher (rubric)
 }
 
 // SubmitModifyRequest untuk submit modified code
@@ -108,8 +109,41 @@ type SubmitStageResponse struct {
     Message        string `json:"message"`
     CoinsEarned    int    `json:"coins_earned"`
     XPEarned       int    `json:"xp_earned"`
+    Score          int    `json:"score"` // 0-100, dari bobot test case yang passed
+    IsLate         bool   `json:"is_late"` // Submit setelah due_at assignment kelas
+    LatePenalty    int    `json:"late_penalty,omitempty"` // Persen score yang dipotong karena terlambat
+    AttemptID      int    `json:"attempt_id,omitempty"` // ID di riwayat stage_attempts
+    PendingReview  bool   `json:"pending_review,omitempty"` // INVESTIGATE: menunggu dinilai teacher
     Output         string `json:"output,omitempty"`
     ExpectedOutput string `json:"expected_output,omitempty"`
+
+    // Feedback per test case (MODIFY & MAKE stage)
+    CompileError string           `json:"compile_error,omitempty"`
+    TestResults  []TestCaseResult `json:"test_results,omitempty"`
+}
+
+// Status hasil eksekusi satu test case
+const (
+    TestStatusPassed       = "passed"
+    TestStatusWrongAnswer  = "wrong_answer"
+    TestStatusRuntimeError = "runtime_error"
+    TestStatusTimeout      = "timeout"
+    TestStatusCompileError = "compile_error"
+)
+
+// TestCaseResult adalah feedback untuk satu test case
+// Untuk test case hidden, output & diff tidak dikirim ke student
+type TestCaseResult struct {
+    Index           int    `json:"index"`
+    Description     string `json:"description,omitempty"`
+    Hidden          bool   `json:"hidden"`
+    Status          string `json:"status"` // passed, wrong_answer, runtime_error, timeout, compile_error
+    Input           string `json:"input,omitempty"`
+    ActualOutput    string `json:"actual_output,omitempty"`
+    ExpectedOutput  string `json:"expected_output,omitempty"`
+    Diff            string `json:"diff,omitempty"`
+    Stderr          string `json:"stderr,omitempty"`
+    ExecutionTimeMs int64  `json:"execution_time_ms"`
 }
 
 // ProgressSummary adalah ringkasan progress siswa di lesson
@@ -119,4 +153,34 @@ type ProgressSummary struct {
     ProgressPercent  float64 `json:"progress_percent"`
     TotalCoinsEarned int     `json:"total_coins_earned"`
     TotalXPEarned    int     `json:"total_xp_earned"`
-}
\ No newline at end of file
+}
+// ═══════════════════════════════════════════════════════════
+// ATTEMPT HISTORY
+// ═══════════════════════════════════════════════════════════
+
+// Verdict satu attempt
+const (
+    VerdictCorrect       = "correct"
+    VerdictIncorrect     = "incorrect"
+    VerdictCompileError  = "compile_error"
+    VerdictPendingReview = "pending_review" // Refleksi INVESTIGATE menunggu review teacher
+)
+
+// StageAttempt adalah satu submission student (riwayat append-only)
+type StageAttempt struct {
+    ID            int                  `json:"id"`
+    AttemptNumber int                  `json:"attempt_number"` // Urutan attempt user di stage (mulai 1)
+    UserID        int                  `json:"user_id"`
+    StageID       int                  `json:"stage_id"`
+    StageType     string               `json:"stage_type"`
+    Answer        string               `json:"answer"` // Code, pilihan jawaban atau refleksi
+    Output        *string              `json:"output,omitempty"`
+    Verdict       string               `json:"verdict"` // correct, incorrect, compile_error, pending_review
+    IsCorrect     bool                 `json:"is_correct"`
+    Score         int                  `json:"score"`
+    IsLate        bool                 `json:"is_late"` // Submit setelah due_at assignment kelas
+    ReviewStatus  *string              `json:"review_status,omitempty"` // INVESTIGATE: status review teacher
+    TestResults   []TestCaseResult     `json:"test_results,omitempty"`
+    SubmittedAt   time.Time            `json:"submitted_at"`
+    Feedback      []SubmissionFeedback `json:"feedback,omitempty"` // Feedback teacher (detail submission)
+}
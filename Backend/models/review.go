@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Status review refleksi INVESTIGATE
+const (
+    ReviewStatusPending    = "pending_review" // Menunggu dinilai teacher
+    ReviewStatusApproved   = "approved"       // Stage complete, reward diberikan
+    ReviewStatusRejected   = "rejected"       // Student perlu mengirim refleksi baru
+    ReviewStatusSuperseded = "superseded"     // Diganti refleksi yang lebih baru sebelum dinilai
+)
+
+// Keputusan teacher saat menilai refleksi
+const (
+    ReviewDecisionApprove = "approve"
+    ReviewDecisionReject  = "reject"
+)
+
+// RubricCriterion adalah satu kriteria penilaian refleksi INVESTIGATE
+type RubricCriterion struct {
+    Title       string `json:"title" binding:"required,max=200"`
+    Description string `json:"description,omitempty"`
+    MaxPoints   int    `json:"max_points" binding:"required,min=1,max=100"`
+}
+
+// UpdateRubricRequest untuk mengganti rubric INVESTIGATE stage (kosong = hapus rubric)
+type UpdateRubricRequest struct {
+    Rubric []RubricCriterion `json:"rubric" binding:"max=20,dive"`
+}
+
+// ReflectionReview adalah satu refleksi INVESTIGATE di review queue
+type ReflectionReview struct {
+    ID                 int               `json:"id"`
+    AttemptID          int               `json:"attempt_id"`
+    UserID             int               `json:"user_id"`
+    StudentName        string            `json:"student_name"`
+    StudentEmail       string            `json:"student_email"`
+    StageID            int               `json:"stage_id"`
+    StageTitle         string            `json:"stage_title"`
+    LessonID           int               `json:"lesson_id"`
+    LessonTitle        string            `json:"lesson_title"`
+    Reflection         string            `json:"reflection"`
+    Rubric             []RubricCriterion `json:"rubric,omitempty"` // Snapshot rubric saat refleksi dikirim
+    Status             string            `json:"status"` // pending_review, approved, rejected, superseded
+    IsLate             bool              `json:"is_late"`
+    LatePenaltyPercent int               `json:"late_penalty_percent"` // Dipotong dari score saat dinilai
+    RubricScores       []int             `json:"rubric_scores,omitempty"`
+    Score              *int              `json:"score,omitempty"` // 0-100 setelah dinilai
+    ReviewerID         *int              `json:"reviewer_id,omitempty"`
+    ReviewedAt         *time.Time        `json:"reviewed_at,omitempty"`
+    SubmittedAt        time.Time         `json:"submitted_at"`
+}
+
+// ReviewQueueQuery adalah filter review queue (query string)
+type ReviewQueueQuery struct {
+    LessonID int    `form:"lesson_id"`
+    StageID  int    `form:"stage_id"`
+    Status   string `form:"status" binding:"omitempty,oneof=pending_review approved rejected"` // Default: pending_review
+}
+
+// ScoreReflectionRequest untuk menilai refleksi
+// Stage dengan rubric: isi rubric_scores (poin per kriteria, urutan sama dengan rubric)
+// Stage tanpa rubric: isi score (0-100)
+type ScoreReflectionRequest struct {
+    Decision     string `json:"decision" binding:"required,oneof=approve reject"`
+    RubricScores []int  `json:"rubric_scores" binding:"omitempty,dive,min=0"`
+    Score        *int   `json:"score" binding:"omitempty,min=0,max=100"`
+    Comment      string `json:"comment" binding:"max=5000"` // Disimpan sebagai feedback untuk student
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ═══════════════════════════════════════════════════════════
+// ASYNC SUBMISSION MODELS
+// ═══════════════════════════════════════════════════════════
+
+// Status submission di grading queue
+const (
+    SubmissionStatusPending = "pending" // Menunggu worker
+    SubmissionStatusRunning = "running" // Sedang dijalankan di sandbox
+    SubmissionStatusGraded  = "graded"  // Selesai dinilai
+    SubmissionStatusFailed  = "failed"  // Error sistem (bukan code student salah)
+)
+
+// Submission merepresentasikan satu submission code di grading queue
+type Submission struct {
+    ID            int                  `json:"id"`
+    UserID        int                  `json:"user_id"`
+    StageID       int                  `json:"stage_id"`
+    StageType     string               `json:"stage_type"`
+    SubmittedCode string               `json:"submitted_code"`
+    Status        string               `json:"status"`
+    IsCorrect     *bool                `json:"is_correct,omitempty"`
+    Score         *int                 `json:"score,omitempty"`
+    Result        *SubmitStageResponse `json:"result,omitempty"`
+    ErrorMessage  *string              `json:"error_message,omitempty"`
+    CreatedAt     time.Time            `json:"created_at"`
+    StartedAt     *time.Time           `json:"started_at,omitempty"`
+    GradedAt      *time.Time           `json:"graded_at,omitempty"`
+}
@@ -10,20 +10,30 @@ type User struct {
     FullName         string     `json:"full_name"`
     Role             string     `json:"role"`
     Level            int        `json:"level"`
-    TotalCoins       int        `json:"total_coins"`
+    TotalCoins       int        `json:"total_coins"` // Saldo dari coin_transactions
     ExperiencePoints int        `json:"experience_points"`
     ProfilePicture   *string    `json:"profile_picture,omitempty"`
     Bio              *string    `json:"bio,omitempty"`
+    EmailVerified    bool       `json:"email_verified"`
     CreatedAt        time.Time  `json:"created_at"`
     UpdatedAt        time.Time  `json:"updated_at"`
 }
 
+// Role user
+const (
+    RoleStudent = "student"
+    RoleTeacher = "teacher"
+    RoleAdmin   = "admin"
+)
+
 // RegisterRequest adalah struktur untuk request register
+// Registrasi publik selalu menjadi student, teacher/admin wajib memakai invite code
 type RegisterRequest struct {
-    Email    string `json:"email" binding:"required,email"`
-    Password string `json:"password" binding:"required,min=6"`
-    FullName string `json:"full_name" binding:"required"`
-    Role     string `json:"role" binding:"required,oneof=student teacher admin"`
+    Email      string `json:"email" binding:"required,email"`
+    Password   string `json:"password" binding:"required,min=6"`
+    FullName   string `json:"full_name" binding:"required"`
+    Role       string `json:"role" binding:"omitempty,oneof=student teacher admin"` // Opsional, harus sama dengan role invite
+    InviteCode string `json:"invite_code"`
 }
 
 // LoginRequest adalah struktur untuk request login
@@ -34,7 +44,83 @@ type LoginRequest struct {
 
 // LoginResponse adalah struktur untuk response login
 type LoginResponse struct {
-    Message string `json:"message"`
-    Token   string `json:"token"`
-    User    *User  `json:"user"`
-}
\ No newline at end of file
+    Message      string `json:"message"`
+    Token        string `json:"token"`         // Access token (JWT, berumur pendek)
+    RefreshToken string `json:"refresh_token"` // Dipakai di POST /api/token/refresh
+    ExpiresIn    int    `json:"expires_in"`    // Umur access token (detik)
+    User         *User  `json:"user"`
+    Warning      string `json:"warning,omitempty"` // Contoh: email belum diverifikasi
+}
+
+// VerifyEmailRequest adalah struktur untuk request verifikasi email
+type VerifyEmailRequest struct {
+    Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest adalah struktur untuk request link reset password
+type ForgotPasswordRequest struct {
+    Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest adalah struktur untuk request reset password
+type ResetPasswordRequest struct {
+    Token       string `json:"token" binding:"required"`
+    NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// RefreshTokenRequest adalah struktur untuk request refresh token
+type RefreshTokenRequest struct {
+    RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPair adalah access token + refresh token hasil login / refresh
+type TokenPair struct {
+    Token        string `json:"token"`
+    RefreshToken string `json:"refresh_token"`
+    ExpiresIn    int    `json:"expires_in"`
+}
+
+// ═══════════════════════════════════════════════════════════
+// ROLE PROVISIONING (Admin)
+// ═══════════════════════════════════════════════════════════
+
+// RoleInvite adalah model untuk tabel role_invites
+type RoleInvite struct {
+    ID        int        `json:"id"`
+    Code      string     `json:"code,omitempty"` // Hanya dikirim sekali saat invite dibuat
+    Role      string     `json:"role"`
+    CreatedBy *int       `json:"created_by,omitempty"`
+    ExpiresAt time.Time  `json:"expires_at"`
+    UsedBy    *int       `json:"used_by,omitempty"`
+    UsedAt    *time.Time `json:"used_at,omitempty"`
+    CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateInviteRequest adalah struktur untuk request membuat invite code
+type CreateInviteRequest struct {
+    Role           string `json:"role" binding:"required,oneof=teacher admin"`
+    ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1,max=720"` // Default 72 jam
+}
+
+// UpdateRoleRequest adalah struktur untuk request promote / demote user
+type UpdateRoleRequest struct {
+    Role   string `json:"role" binding:"required,oneof=student teacher admin"`
+    Reason string `json:"reason"`
+}
+
+// UnlockIPRequest adalah struktur untuk request membuka kunci login per IP
+type UnlockIPRequest struct {
+    IP string `json:"ip" binding:"required,ip"`
+}
+
+// RoleAuditEntry adalah model untuk tabel role_audit_log
+type RoleAuditEntry struct {
+    ID        int       `json:"id"`
+    UserID    int       `json:"user_id"`
+    OldRole   *string   `json:"old_role"` // nil = role awal saat registrasi
+    NewRole   string    `json:"new_role"`
+    ChangedBy *int      `json:"changed_by,omitempty"`
+    InviteID  *int      `json:"invite_id,omitempty"`
+    Reason    *string   `json:"reason,omitempty"`
+    CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,212 @@
+package oidc
+
+import (
+    "context"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "encoding/base64"
+    "errors"
+    "math/big"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// ═══════════════════════════════════════════════════════════
+// ID TOKEN VERIFICATION
+// Signature diverifikasi dengan JWKS provider (RSA / EC),
+// lalu iss, aud, exp & nonce dicek
+// ═══════════════════════════════════════════════════════════
+
+// clockSkew adalah toleransi perbedaan jam dengan provider
+const clockSkew = time.Minute
+
+// Claims adalah identitas user dari ID token
+type Claims struct {
+    Issuer        string
+    Subject       string
+    Email         string
+    EmailVerified bool
+    Name          string
+}
+
+// jsonWebKey adalah satu key di JWKS
+type jsonWebKey struct {
+    Kid string `json:"kid"`
+    Kty string `json:"kty"`
+    Use string `json:"use"`
+    N   string `json:"n"`
+    E   string `json:"e"`
+    Crv string `json:"crv"`
+    X   string `json:"x"`
+    Y   string `json:"y"`
+}
+
+// VerifyIDToken memverifikasi ID token dan mengembalikan identitas user
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+    doc, err := p.getDiscovery(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+        kid, _ := token.Header["kid"].(string)
+        return p.getKey(ctx, kid)
+    },
+        jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+        jwt.WithIssuer(doc.Issuer),
+        jwt.WithAudience(p.ClientID),
+        jwt.WithExpirationRequired(),
+        jwt.WithLeeway(clockSkew),
+    )
+    if err != nil {
+        return nil, errors.New("id_token tidak valid: " + err.Error())
+    }
+
+    mapClaims, ok := token.Claims.(jwt.MapClaims)
+    if !ok || !token.Valid {
+        return nil, errors.New("id_token tidak valid")
+    }
+
+    if tokenNonce, _ := mapClaims["nonce"].(string); tokenNonce != nonce {
+        return nil, errors.New("id_token tidak valid: nonce tidak cocok")
+    }
+
+    claims := &Claims{Issuer: doc.Issuer}
+    claims.Subject, _ = mapClaims["sub"].(string)
+    claims.Email, _ = mapClaims["email"].(string)
+    claims.Name, _ = mapClaims["name"].(string)
+    if claims.Name == "" {
+        claims.Name, _ = mapClaims["preferred_username"].(string)
+    }
+
+    // Beberapa provider mengirim email_verified sebagai string
+    switch verified := mapClaims["email_verified"].(type) {
+    case bool:
+        claims.EmailVerified = verified
+    case string:
+        claims.EmailVerified = verified == "true"
+    }
+    if p.TrustEmail && claims.Email != "" {
+        claims.EmailVerified = true
+    }
+
+    if claims.Subject == "" {
+        return nil, errors.New("id_token tidak berisi sub")
+    }
+
+    return claims, nil
+}
+
+// getKey mencari public key berdasarkan kid (JWKS di-fetch ulang jika kid belum dikenal,
+// misalnya setelah provider melakukan key rotation)
+func (p *Provider) getKey(ctx context.Context, kid string) (any, error) {
+    if key := p.cachedKey(kid); key != nil {
+        return key, nil
+    }
+
+    if err := p.refreshKeys(ctx); err != nil {
+        return nil, err
+    }
+
+    if key := p.cachedKey(kid); key != nil {
+        return key, nil
+    }
+    return nil, errors.New("signing key tidak ditemukan di JWKS")
+}
+
+// cachedKey mengambil key dari cache (kid kosong = satu-satunya key di JWKS)
+func (p *Provider) cachedKey(kid string) any {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if kid == "" && len(p.keys) == 1 {
+        for _, key := range p.keys {
+            return key
+        }
+    }
+    return p.keys[kid]
+}
+
+// refreshKeys mengambil ulang JWKS dari provider
+func (p *Provider) refreshKeys(ctx context.Context) error {
+    doc, err := p.getDiscovery(ctx)
+    if err != nil {
+        return err
+    }
+
+    var jwks struct {
+        Keys []jsonWebKey `json:"keys"`
+    }
+    if err := p.getJSON(ctx, doc.JWKSURI, &jwks); err != nil {
+        return errors.New("gagal mengambil JWKS: " + err.Error())
+    }
+
+    keys := make(map[string]any, len(jwks.Keys))
+    for _, jwk := range jwks.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        key, err := jwk.publicKey()
+        if err != nil {
+            continue // Key dengan tipe yang tidak didukung dilewati
+        }
+        keys[jwk.Kid] = key
+    }
+
+    p.mu.Lock()
+    p.keys = keys
+    p.mu.Unlock()
+
+    return nil
+}
+
+// publicKey mengubah JWK menjadi *rsa.PublicKey atau *ecdsa.PublicKey
+func (k jsonWebKey) publicKey() (any, error) {
+    switch k.Kty {
+    case "RSA":
+        n, err := decodeBigInt(k.N)
+        if err != nil {
+            return nil, err
+        }
+        e, err := decodeBigInt(k.E)
+        if err != nil {
+            return nil, err
+        }
+        return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+    case "EC":
+        var curve elliptic.Curve
+        switch k.Crv {
+        case "P-256":
+            curve = elliptic.P256()
+        case "P-384":
+            curve = elliptic.P384()
+        case "P-521":
+            curve = elliptic.P521()
+        default:
+            return nil, errors.New("curve tidak didukung: " + k.Crv)
+        }
+        x, err := decodeBigInt(k.X)
+        if err != nil {
+            return nil, err
+        }
+        y, err := decodeBigInt(k.Y)
+        if err != nil {
+            return nil, err
+        }
+        return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+    }
+
+    return nil, errors.New("tipe key tidak didukung: " + k.Kty)
+}
+
+// decodeBigInt men-decode angka base64url (tanpa padding) dari JWK
+func decodeBigInt(value string) (*big.Int, error) {
+    buf, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return nil, err
+    }
+    return new(big.Int).SetBytes(buf), nil
+}
@@ -0,0 +1,207 @@
+// Package oidctest menyediakan mock OIDC identity provider lokal (httptest)
+// untuk menguji alur login SSO tanpa provider sungguhan
+package oidctest
+
+import (
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// ═══════════════════════════════════════════════════════════
+// MOCK OIDC PROVIDER
+// Endpoint: /.well-known/openid-configuration, /authorize, /token, /jwks
+// ID token ditandatangani RS256 dengan key yang dibuat saat NewServer
+// ═══════════════════════════════════════════════════════════
+
+// keyID adalah kid signing key mock provider
+const keyID = "oidctest-key"
+
+// Identity adalah user yang "login" di mock provider
+type Identity struct {
+    Subject       string
+    Email         string
+    EmailVerified bool
+    Name          string
+}
+
+// Server adalah mock OIDC provider
+type Server struct {
+    *httptest.Server
+    ClientID string
+
+    key *rsa.PrivateKey
+
+    mu    sync.Mutex
+    codes map[string]authRequest
+}
+
+// authRequest adalah authorization code yang sudah diterbitkan (belum ditukar)
+type authRequest struct {
+    identity      Identity
+    nonce         string
+    codeChallenge string
+    redirectURI   string
+    overrides     map[string]any
+}
+
+// NewServer menjalankan mock provider untuk client ID tertentu
+// Caller wajib memanggil Close() setelah selesai
+func NewServer(clientID string) (*Server, error) {
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        return nil, err
+    }
+
+    s := &Server{ClientID: clientID, key: key, codes: map[string]authRequest{}}
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+    mux.HandleFunc("/jwks", s.handleJWKS)
+    mux.HandleFunc("/token", s.handleToken)
+    s.Server = httptest.NewServer(mux)
+
+    return s, nil
+}
+
+// Issuer mengembalikan issuer mock provider (sama dengan URL server)
+func (s *Server) Issuer() string {
+    return s.URL
+}
+
+// Authorize mensimulasikan user login di halaman provider: membaca URL dari
+// Provider.AuthCodeURL lalu menerbitkan authorization code untuk identity
+// overrides (opsional) menimpa claim ID token, misalnya "nonce", "iss" atau "aud"
+func (s *Server) Authorize(authURL string, identity Identity, overrides map[string]any) (code string, state string, err error) {
+    parsed, err := url.Parse(authURL)
+    if err != nil {
+        return "", "", err
+    }
+    query := parsed.Query()
+
+    if query.Get("client_id") != s.ClientID {
+        return "", "", errors.New("client_id tidak dikenal")
+    }
+    if query.Get("response_type") != "code" {
+        return "", "", errors.New("response_type harus code")
+    }
+    if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+        return "", "", errors.New("PKCE S256 wajib")
+    }
+
+    code = randomString()
+    s.mu.Lock()
+    s.codes[code] = authRequest{
+        identity:      identity,
+        nonce:         query.Get("nonce"),
+        codeChallenge: query.Get("code_challenge"),
+        redirectURI:   query.Get("redirect_uri"),
+        overrides:     overrides,
+    }
+    s.mu.Unlock()
+
+    return code, query.Get("state"), nil
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, map[string]string{
+        "issuer":                 s.URL,
+        "authorization_endpoint": s.URL + "/authorize",
+        "token_endpoint":         s.URL + "/token",
+        "jwks_uri":               s.URL + "/jwks",
+    })
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, map[string]any{
+        "keys": []map[string]string{{
+            "kid": keyID,
+            "kty": "RSA",
+            "use": "sig",
+            "alg": "RS256",
+            "n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+            "e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+        }},
+    })
+}
+
+// handleToken menukar code (sekali pakai) setelah mengecek client_id,
+// redirect_uri & PKCE code_verifier
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+        return
+    }
+
+    code := r.PostForm.Get("code")
+    s.mu.Lock()
+    request, ok := s.codes[code]
+    delete(s.codes, code)
+    s.mu.Unlock()
+
+    verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+    switch {
+    case !ok:
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+        return
+    case r.PostForm.Get("client_id") != s.ClientID,
+        r.PostForm.Get("redirect_uri") != request.redirectURI,
+        base64.RawURLEncoding.EncodeToString(verifier[:]) != request.codeChallenge:
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+        return
+    }
+
+    now := time.Now()
+    claims := jwt.MapClaims{
+        "iss":            s.URL,
+        "aud":            s.ClientID,
+        "sub":            request.identity.Subject,
+        "email":          request.identity.Email,
+        "email_verified": request.identity.EmailVerified,
+        "name":           request.identity.Name,
+        "nonce":          request.nonce,
+        "iat":            now.Unix(),
+        "exp":            now.Add(5 * time.Minute).Unix(),
+    }
+    for name, value := range request.overrides {
+        claims[name] = value
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+    token.Header["kid"] = keyID
+    idToken, err := token.SignedString(s.key)
+    if err != nil {
+        writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+        return
+    }
+
+    writeJSON(w, http.StatusOK, map[string]any{
+        "access_token": randomString(),
+        "token_type":   "Bearer",
+        "id_token":     idToken,
+        "expires_in":   300,
+    })
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+    buf := make([]byte, 16)
+    rand.Read(buf)
+    return base64.RawURLEncoding.EncodeToString(buf)
+}
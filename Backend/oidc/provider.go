@@ -0,0 +1,249 @@
+package oidc
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+// ═══════════════════════════════════════════════════════════
+// OIDC CLIENT
+// Authorization code flow + PKCE (S256) untuk identity provider sekolah
+// Endpoint provider diambil dari <issuer>/.well-known/openid-configuration
+// ═══════════════════════════════════════════════════════════
+
+// httpTimeout adalah batas waktu request ke provider
+const httpTimeout = 10 * time.Second
+
+// Config adalah konfigurasi satu OIDC identity provider
+type Config struct {
+    Issuer       string
+    ClientID     string
+    ClientSecret string // Kosong = public client (hanya PKCE)
+    RedirectURL  string // Callback backend: .../api/auth/oidc/callback
+    Scopes       []string
+    TrustEmail   bool // Anggap email terverifikasi walau provider tidak mengirim email_verified
+}
+
+// Provider adalah client untuk satu OIDC identity provider
+// (openid-configuration & JWKS di-cache di memory)
+type Provider struct {
+    Config
+
+    client *http.Client
+
+    mu        sync.Mutex
+    discovery *discoveryDocument
+    keys      map[string]any // kid -> public key (JWKS)
+}
+
+// discoveryDocument adalah bagian openid-configuration yang dipakai
+type discoveryDocument struct {
+    Issuer                string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse adalah response token endpoint
+type TokenResponse struct {
+    AccessToken string `json:"access_token"`
+    TokenType   string `json:"token_type"`
+    IDToken     string `json:"id_token"`
+    ExpiresIn   int    `json:"expires_in"`
+}
+
+// FromEnv membuat Provider dari environment variables
+// OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL,
+// OIDC_SCOPES (default "openid email profile"), OIDC_TRUST_EMAIL (true/false)
+// Return nil jika OIDC_ISSUER tidak diisi (SSO dinonaktifkan)
+func FromEnv() *Provider {
+    issuer := os.Getenv("OIDC_ISSUER")
+    if issuer == "" {
+        return nil
+    }
+
+    return NewProvider(Config{
+        Issuer:       issuer,
+        ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+        ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+        RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+        Scopes:       strings.Fields(os.Getenv("OIDC_SCOPES")),
+        TrustEmail:   os.Getenv("OIDC_TRUST_EMAIL") == "true",
+    })
+}
+
+// NewProvider membuat Provider siap pakai dari konfigurasi
+func NewProvider(config Config) *Provider {
+    config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+    if len(config.Scopes) == 0 {
+        config.Scopes = []string{"openid", "email", "profile"}
+    }
+
+    return &Provider{
+        Config: config,
+        client: &http.Client{Timeout: httpTimeout},
+    }
+}
+
+// ═══════════════════════════════════════════════════════════
+// PKCE & RANDOM VALUES
+// ═══════════════════════════════════════════════════════════
+
+// RandomString membuat string acak URL-safe (untuk state, nonce & code verifier)
+func RandomString() (string, error) {
+    buf := make([]byte, 32)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CodeChallenge menghitung PKCE code_challenge (S256) dari code_verifier
+func CodeChallenge(verifier string) string {
+    sum := sha256.Sum256([]byte(verifier))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ═══════════════════════════════════════════════════════════
+// AUTHORIZATION CODE FLOW
+// ═══════════════════════════════════════════════════════════
+
+// AuthCodeURL membuat URL login di provider
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+    doc, err := p.getDiscovery(ctx)
+    if err != nil {
+        return "", err
+    }
+
+    params := url.Values{
+        "response_type":         {"code"},
+        "client_id":             {p.ClientID},
+        "redirect_uri":          {p.RedirectURL},
+        "scope":                 {strings.Join(p.Scopes, " ")},
+        "state":                 {state},
+        "nonce":                 {nonce},
+        "code_challenge":        {CodeChallenge(codeVerifier)},
+        "code_challenge_method": {"S256"},
+    }
+
+    separator := "?"
+    if strings.Contains(doc.AuthorizationEndpoint, "?") {
+        separator = "&"
+    }
+    return doc.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange menukar authorization code dengan token (mengirim code_verifier PKCE)
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string) (*TokenResponse, error) {
+    doc, err := p.getDiscovery(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    form := url.Values{
+        "grant_type":    {"authorization_code"},
+        "code":          {code},
+        "redirect_uri":  {p.RedirectURL},
+        "client_id":     {p.ClientID},
+        "code_verifier": {codeVerifier},
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    if p.ClientSecret != "" {
+        req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+    }
+
+    resp, err := p.client.Do(req)
+    if err != nil {
+        return nil, errors.New("gagal menghubungi token endpoint: " + err.Error())
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    if err != nil {
+        return nil, errors.New("gagal membaca response token: " + err.Error())
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("token endpoint mengembalikan status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+    }
+
+    var tokens TokenResponse
+    if err := json.Unmarshal(body, &tokens); err != nil {
+        return nil, errors.New("response token tidak valid: " + err.Error())
+    }
+    if tokens.IDToken == "" {
+        return nil, errors.New("response token tidak berisi id_token")
+    }
+
+    return &tokens, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// DISCOVERY
+// ═══════════════════════════════════════════════════════════
+
+// getDiscovery mengambil (dan meng-cache) openid-configuration provider
+func (p *Provider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+    p.mu.Lock()
+    doc := p.discovery
+    p.mu.Unlock()
+    if doc != nil {
+        return doc, nil
+    }
+
+    var fetched discoveryDocument
+    if err := p.getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", &fetched); err != nil {
+        return nil, errors.New("gagal mengambil openid-configuration: " + err.Error())
+    }
+
+    if strings.TrimSuffix(fetched.Issuer, "/") != p.Issuer {
+        return nil, errors.New("issuer di openid-configuration tidak sama dengan OIDC_ISSUER")
+    }
+    if fetched.AuthorizationEndpoint == "" || fetched.TokenEndpoint == "" || fetched.JWKSURI == "" {
+        return nil, errors.New("openid-configuration tidak lengkap")
+    }
+
+    p.mu.Lock()
+    p.discovery = &fetched
+    p.mu.Unlock()
+
+    return &fetched, nil
+}
+
+// getJSON melakukan GET lalu decode JSON
+func (p *Provider) getJSON(ctx context.Context, endpoint string, target any) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+
+    resp, err := p.client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("status %d dari %s", resp.StatusCode, endpoint)
+    }
+
+    return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
@@ -0,0 +1,195 @@
+package oidc
+
+import (
+    "context"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+
+    "primmfy_db/oidc/oidctest"
+)
+
+// newTestProvider menjalankan mock provider dan Provider yang terhubung ke sana
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+    t.Helper()
+
+    server, err := oidctest.NewServer("primmfy")
+    if err != nil {
+        t.Fatalf("gagal menjalankan mock provider: %v", err)
+    }
+    t.Cleanup(server.Close)
+
+    provider := NewProvider(Config{
+        Issuer:      server.Issuer(),
+        ClientID:    "primmfy",
+        RedirectURL: "http://localhost:8080/api/auth/oidc/callback",
+    })
+    return server, provider
+}
+
+// login menjalankan alur authorization code + PKCE sampai VerifyIDToken
+func login(t *testing.T, server *oidctest.Server, provider *Provider, identity oidctest.Identity, overrides map[string]any) (*Claims, error) {
+    t.Helper()
+    ctx := context.Background()
+
+    authURL, err := provider.AuthCodeURL(ctx, "state-123", "nonce-123", "verifier-123")
+    if err != nil {
+        t.Fatalf("AuthCodeURL: %v", err)
+    }
+
+    code, state, err := server.Authorize(authURL, identity, overrides)
+    if err != nil {
+        t.Fatalf("Authorize: %v", err)
+    }
+    if state != "state-123" {
+        t.Fatalf("state = %q, want state-123", state)
+    }
+
+    tokens, err := provider.Exchange(ctx, code, "verifier-123")
+    if err != nil {
+        t.Fatalf("Exchange: %v", err)
+    }
+
+    return provider.VerifyIDToken(ctx, tokens.IDToken, "nonce-123")
+}
+
+var student = oidctest.Identity{
+    Subject:       "user-1",
+    Email:         "siswa@sekolah.sch.id",
+    EmailVerified: true,
+    Name:          "Siswa Satu",
+}
+
+func TestLoginHappyPath(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    claims, err := login(t, server, provider, student, nil)
+    if err != nil {
+        t.Fatalf("VerifyIDToken: %v", err)
+    }
+
+    if claims.Issuer != server.Issuer() || claims.Subject != "user-1" ||
+        claims.Email != "siswa@sekolah.sch.id" || !claims.EmailVerified || claims.Name != "Siswa Satu" {
+        t.Fatalf("claims tidak sesuai: %+v", claims)
+    }
+}
+
+func TestAuthCodeURLUsesPKCE(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    authURL, err := provider.AuthCodeURL(context.Background(), "s", "n", "verifier-123")
+    if err != nil {
+        t.Fatalf("AuthCodeURL: %v", err)
+    }
+    if !strings.HasPrefix(authURL, server.Issuer()+"/authorize?") {
+        t.Fatalf("authorization endpoint dari discovery tidak dipakai: %s", authURL)
+    }
+
+    parsed, _ := url.Parse(authURL)
+    query := parsed.Query()
+    if query.Get("code_challenge") != CodeChallenge("verifier-123") || query.Get("code_challenge_method") != "S256" {
+        t.Fatalf("PKCE challenge tidak valid: %s", authURL)
+    }
+    if query.Get("code_verifier") != "" {
+        t.Fatal("code_verifier tidak boleh dikirim di authorization URL")
+    }
+}
+
+func TestExchangeRejectsWrongCodeVerifier(t *testing.T) {
+    server, provider := newTestProvider(t)
+    ctx := context.Background()
+
+    authURL, _ := provider.AuthCodeURL(ctx, "s", "n", "verifier-123")
+    code, _, err := server.Authorize(authURL, student, nil)
+    if err != nil {
+        t.Fatalf("Authorize: %v", err)
+    }
+
+    if _, err := provider.Exchange(ctx, code, "verifier-lain"); err == nil {
+        t.Fatal("Exchange dengan code_verifier salah harus gagal")
+    }
+}
+
+func TestExchangeCodeIsSingleUse(t *testing.T) {
+    server, provider := newTestProvider(t)
+    ctx := context.Background()
+
+    authURL, _ := provider.AuthCodeURL(ctx, "s", "n", "verifier-123")
+    code, _, _ := server.Authorize(authURL, student, nil)
+
+    if _, err := provider.Exchange(ctx, code, "verifier-123"); err != nil {
+        t.Fatalf("Exchange pertama: %v", err)
+    }
+    if _, err := provider.Exchange(ctx, code, "verifier-123"); err == nil {
+        t.Fatal("code yang sama tidak boleh ditukar dua kali")
+    }
+}
+
+func TestVerifyIDTokenRejectsBadNonce(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    _, err := login(t, server, provider, student, map[string]any{"nonce": "nonce-lain"})
+    if err == nil || !strings.Contains(err.Error(), "nonce") {
+        t.Fatalf("nonce salah harus ditolak, err = %v", err)
+    }
+}
+
+func TestVerifyIDTokenRejectsWrongIssuer(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    _, err := login(t, server, provider, student, map[string]any{"iss": "https://issuer-lain.example.com"})
+    if err == nil {
+        t.Fatal("issuer lain harus ditolak")
+    }
+}
+
+func TestVerifyIDTokenRejectsWrongAudience(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    _, err := login(t, server, provider, student, map[string]any{"aud": "client-lain"})
+    if err == nil {
+        t.Fatal("audience client lain harus ditolak")
+    }
+}
+
+func TestVerifyIDTokenRejectsExpiredToken(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    _, err := login(t, server, provider, student, map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})
+    if err == nil {
+        t.Fatal("id_token expired harus ditolak")
+    }
+}
+
+func TestVerifyIDTokenEmailVerified(t *testing.T) {
+    server, provider := newTestProvider(t)
+
+    unverified := student
+    unverified.EmailVerified = false
+    claims, err := login(t, server, provider, unverified, nil)
+    if err != nil {
+        t.Fatalf("VerifyIDToken: %v", err)
+    }
+    if claims.EmailVerified {
+        t.Fatal("email_verified=false harus terbaca belum terverifikasi")
+    }
+
+    // Beberapa provider mengirim email_verified sebagai string
+    claims, err = login(t, server, provider, unverified, map[string]any{"email_verified": "true"})
+    if err != nil {
+        t.Fatalf("VerifyIDToken: %v", err)
+    }
+    if !claims.EmailVerified {
+        t.Fatal("email_verified=\"true\" harus terbaca terverifikasi")
+    }
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+    server, _ := newTestProvider(t)
+
+    provider := NewProvider(Config{Issuer: server.Issuer() + "/tenant-lain", ClientID: "primmfy"})
+    if _, err := provider.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+        t.Fatal("openid-configuration dengan issuer berbeda harus ditolak")
+    }
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "net/url"
+    "os"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/mailer"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// EMAIL VERIFICATION & PASSWORD RESET
+// Token = JWT (HS256) dengan key turunan JWT_SECRET per purpose,
+// sehingga tidak bisa dipakai sebagai access token (dan sebaliknya)
+// ═══════════════════════════════════════════════════════════
+
+// Purpose token akun
+const (
+    tokenPurposeVerifyEmail   = "verify_email"
+    tokenPurposeResetPassword = "reset_password"
+)
+
+// Default umur token (bisa di-override lewat EMAIL_VERIFY_TTL & PASSWORD_RESET_TTL)
+const (
+    defaultVerifyEmailTTL   = 48 * time.Hour
+    defaultPasswordResetTTL = time.Hour
+)
+
+// Kebijakan login untuk akun yang email-nya belum diverifikasi (UNVERIFIED_LOGIN)
+const (
+    UnverifiedLoginWarn  = "warn"  // Login tetap berhasil + warning di response
+    UnverifiedLoginBlock = "block" // Login ditolak sampai email diverifikasi
+)
+
+// UnverifiedLoginPolicy membaca kebijakan login akun belum terverifikasi (default: warn)
+func UnverifiedLoginPolicy() string {
+    if os.Getenv("UNVERIFIED_LOGIN") == UnverifiedLoginBlock {
+        return UnverifiedLoginBlock
+    }
+    return UnverifiedLoginWarn
+}
+
+// ttlFromEnv membaca durasi dari environment (contoh: 48h), fallback ke default
+func ttlFromEnv(key string, fallback time.Duration) time.Duration {
+    if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+        return d
+    }
+    return fallback
+}
+
+// appBaseURL adalah URL frontend (APP_BASE_URL, default http://localhost:3000)
+func appBaseURL() string {
+    base := os.Getenv("APP_BASE_URL")
+    if base == "" {
+        base = "http://localhost:3000"
+    }
+    return base
+}
+
+// appURL membuat link ke frontend dengan token di query string
+func appURL(path string, token string) string {
+    return appBaseURL() + path + "?token=" + url.QueryEscape(token)
+}
+
+// accountTokenKey menurunkan signing key per purpose dari JWT_SECRET
+func accountTokenKey(purpose string) ([]byte, error) {
+    secretKey := os.Getenv("JWT_SECRET")
+    if secretKey == "" {
+        return nil, errors.New("JWT_SECRET tidak ditemukan di environment variables")
+    }
+
+    mac := hmac.New(sha256.New, []byte(secretKey))
+    mac.Write([]byte("primmfy:" + purpose))
+    return mac.Sum(nil), nil
+}
+
+// passwordFingerprint mengikat token reset ke password saat ini
+// (token otomatis tidak berlaku setelah password diganti)
+func passwordFingerprint(passwordHash string) string {
+    sum := sha256.Sum256([]byte(passwordHash))
+    return hex.EncodeToString(sum[:8])
+}
+
+// signAccountToken membuat token akun yang ditandatangani & punya masa berlaku
+func signAccountToken(purpose string, userID int, email string, fingerprint string, ttl time.Duration) (string, error) {
+    key, err := accountTokenKey(purpose)
+    if err != nil {
+        return "", err
+    }
+
+    claims := jwt.MapClaims{
+        "user_id": userID,
+        "email":   email,
+        "purpose": purpose,
+        "exp":     time.Now().Add(ttl).Unix(),
+    }
+    if fingerprint != "" {
+        claims["fp"] = fingerprint
+    }
+
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+}
+
+// parseAccountToken memvalidasi signature, expiry & purpose token akun
+func parseAccountToken(purpose string, tokenString string) (int, jwt.MapClaims, error) {
+    key, err := accountTokenKey(purpose)
+    if err != nil {
+        return 0, nil, err
+    }
+
+    token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, errors.New("invalid signing method")
+        }
+        return key, nil
+    })
+    if err != nil || !token.Valid {
+        return 0, nil, errors.New("token tidak valid atau sudah expired")
+    }
+
+    claims, ok := token.Claims.(jwt.MapClaims)
+    if !ok || claims["purpose"] != purpose {
+        return 0, nil, errors.New("token tidak valid atau sudah expired")
+    }
+
+    userID, ok := claims["user_id"].(float64)
+    if !ok {
+        return 0, nil, errors.New("token tidak valid atau sudah expired")
+    }
+
+    return int(userID), claims, nil
+}
+
+// SendVerificationEmail mengirim link verifikasi ke email user
+func SendVerificationEmail(ctx context.Context, db *pgxpool.Pool, m mailer.Mailer, userID int) error {
+    var email, fullName string
+    var verified bool
+    err := db.QueryRow(ctx,
+        "SELECT email, full_name, email_verified_at IS NOT NULL FROM users WHERE id = $1",
+        userID).Scan(&email, &fullName, &verified)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return errors.New("user tidak ditemukan")
+        }
+        return errors.New("gagal mengambil user: " + err.Error())
+    }
+
+    if verified {
+        return errors.New("email sudah diverifikasi")
+    }
+
+    token, err := signAccountToken(tokenPurposeVerifyEmail, userID, email, "",
+        ttlFromEnv("EMAIL_VERIFY_TTL", defaultVerifyEmailTTL))
+    if err != nil {
+        return errors.New("gagal generate token: " + err.Error())
+    }
+
+    return m.Send(ctx, mailer.Message{
+        To:      email,
+        Subject: "Verifikasi email akun PRIMMFY",
+        Body: "Halo " + fullName + ",\n\n" +
+            "Klik link berikut untuk memverifikasi email kamu:\n" +
+            appURL("/verify-email", token) + "\n\n" +
+            "Abaikan email ini jika kamu tidak mendaftar di PRIMMFY.\n",
+    })
+}
+
+// VerifyEmail menandai email user terverifikasi berdasarkan token dari email
+func VerifyEmail(ctx context.Context, db *pgxpool.Pool, token string) (*models.User, error) {
+    userID, claims, err := parseAccountToken(tokenPurposeVerifyEmail, token)
+    if err != nil {
+        return nil, err
+    }
+
+    // Email di token harus sama dengan email user saat ini
+    tag, err := db.Exec(ctx, `
+        UPDATE users
+        SET email_verified_at = COALESCE(email_verified_at, NOW())
+        WHERE id = $1 AND email = $2`,
+        userID, claims["email"])
+
+    if err != nil {
+        return nil, errors.New("gagal verifikasi email: " + err.Error())
+    }
+
+    if tag.RowsAffected() == 0 {
+        return nil, errors.New("token tidak valid atau sudah expired")
+    }
+
+    return GetUserByID(ctx, db, userID)
+}
+
+// RequestPasswordReset mengirim link reset password
+// Tidak mengembalikan error jika email tidak terdaftar (mencegah enumerasi email)
+func RequestPasswordReset(ctx context.Context, db *pgxpool.Pool, m mailer.Mailer, email string) error {
+    var userID int
+    var fullName, passwordHash string
+    err := db.QueryRow(ctx,
+        "SELECT id, full_name, password_hash FROM users WHERE email = $1",
+        email).Scan(&userID, &fullName, &passwordHash)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil
+        }
+        return errors.New("gagal mengambil user: " + err.Error())
+    }
+
+    token, err := signAccountToken(tokenPurposeResetPassword, userID, email, passwordFingerprint(passwordHash),
+        ttlFromEnv("PASSWORD_RESET_TTL", defaultPasswordResetTTL))
+    if err != nil {
+        return errors.New("gagal generate token: " + err.Error())
+    }
+
+    return m.Send(ctx, mailer.Message{
+        To:      email,
+        Subject: "Reset password akun PRIMMFY",
+        Body: "Halo " + fullName + ",\n\n" +
+            "Klik link berikut untuk membuat password baru:\n" +
+            appURL("/reset-password", token) + "\n\n" +
+            "Link hanya bisa dipakai sekali. Abaikan email ini jika kamu tidak meminta reset password.\n",
+    })
+}
+
+// ResetPassword mengganti password berdasarkan token reset
+// Semua session user dicabut sehingga harus login ulang di semua device
+func ResetPassword(ctx context.Context, db *pgxpool.Pool, req models.ResetPasswordRequest) error {
+    userID, claims, err := parseAccountToken(tokenPurposeResetPassword, req.Token)
+    if err != nil {
+        return err
+    }
+
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Token hanya berlaku untuk password saat token dibuat (sekali pakai)
+    var email, passwordHash string
+    err = tx.QueryRow(ctx,
+        "SELECT email, password_hash FROM users WHERE id = $1 FOR UPDATE",
+        userID).Scan(&email, &passwordHash)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return errors.New("token tidak valid atau sudah expired")
+        }
+        return errors.New("gagal mengambil user: " + err.Error())
+    }
+
+    if claims["email"] != email || claims["fp"] != passwordFingerprint(passwordHash) {
+        return errors.New("token tidak valid atau sudah expired")
+    }
+
+    // 2. Simpan password baru (email otomatis terverifikasi karena link diterima di inbox)
+    hashedPassword, err := HashPassword(req.NewPassword)
+    if err != nil {
+        return errors.New("gagal hash password: " + err.Error())
+    }
+
+    _, err = tx.Exec(ctx, `
+        UPDATE users
+        SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, NOW())
+        WHERE id = $2`,
+        hashedPassword, userID)
+
+    if err != nil {
+        return errors.New("gagal reset password: " + err.Error())
+    }
+
+    // 3. Logout dari semua device
+    if _, err := RevokeUserSessions(ctx, tx, userID); err != nil {
+        return err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return errors.New("gagal reset password: " + err.Error())
+    }
+
+    return nil
+}
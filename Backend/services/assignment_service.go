@@ -0,0 +1,391 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// ASSIGNMENTS
+// Deadline lesson / course untuk satu kelas. Submission engine mengecek
+// jendela open_at - due_at dan late policy sebelum jawaban dinilai
+// Waktu dari client (RFC3339 + offset) dikirim sebagai timestamptz lalu disimpan
+// ke kolom TIMESTAMP dalam UTC (session database di-set timezone UTC)
+// ═══════════════════════════════════════════════════════════
+
+// assignmentColumns adalah kolom untuk scanAssignment
+const assignmentColumns = `
+    a.id, a.classroom_id, cl.name, a.lesson_id, a.course_id,
+    COALESCE(l.title, co.title, ''), a.title, COALESCE(a.instructions, ''),
+    a.open_at, a.due_at, a.late_policy, a.late_penalty_percent,
+    CASE
+        WHEN a.open_at IS NOT NULL AND NOW() < a.open_at THEN 'upcoming'
+        WHEN NOW() <= a.due_at THEN 'open'
+        ELSE 'past_due'
+    END,
+    a.created_by, a.created_at, a.updated_at`
+
+// assignmentJoins adalah FROM + JOIN untuk assignmentColumns
+const assignmentJoins = `
+    FROM assignments a
+    JOIN classrooms cl ON a.classroom_id = cl.id
+    LEFT JOIN lessons l ON a.lesson_id = l.id
+    LEFT JOIN courses co ON a.course_id = co.id`
+
+// scanAssignment membaca satu baris assignmentColumns
+func scanAssignment(row pgx.Row) (*models.Assignment, error) {
+    var assignment models.Assignment
+    err := row.Scan(
+        &assignment.ID, &assignment.ClassroomID, &assignment.ClassroomName,
+        &assignment.LessonID, &assignment.CourseID, &assignment.TargetTitle,
+        &assignment.Title, &assignment.Instructions, &assignment.OpenAt, &assignment.DueAt,
+        &assignment.LatePolicy, &assignment.LatePenaltyPercent, &assignment.Status,
+        &assignment.CreatedBy, &assignment.CreatedAt, &assignment.UpdatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &assignment, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// ASSIGNMENT MANAGEMENT (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// CreateAssignment membuat assignment untuk lesson / course yang sudah di-assign ke kelas
+func CreateAssignment(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int, req models.CreateAssignmentRequest) (*models.Assignment, error) {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return nil, err
+    }
+
+    // 1. Validasi target & jadwal
+    if (req.LessonID == nil) == (req.CourseID == nil) {
+        return nil, errors.New("isi salah satu: lesson_id atau course_id")
+    }
+    if req.LatePolicy == "" {
+        req.LatePolicy = models.LatePolicyAllow
+    }
+    if err := validateAssignmentSchedule(req.OpenAt, req.DueAt, req.LatePolicy, req.LatePenaltyPercent); err != nil {
+        return nil, err
+    }
+
+    // 2. Lesson (atau lesson dari course) harus sudah di-assign ke kelas
+    lessonID := 0
+    if req.LessonID != nil {
+        lessonID = *req.LessonID
+    } else {
+        err := db.QueryRow(ctx, "SELECT lesson_id FROM courses WHERE id = $1", *req.CourseID).Scan(&lessonID)
+        if err != nil {
+            if err == pgx.ErrNoRows {
+                return nil, errors.New("course tidak ditemukan")
+            }
+            return nil, errors.New("gagal mengecek course: " + err.Error())
+        }
+    }
+
+    var assigned bool
+    err := db.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM classroom_lessons WHERE classroom_id = $1 AND lesson_id = $2
+        )`, classroomID, lessonID).Scan(&assigned)
+    if err != nil {
+        return nil, errors.New("gagal mengecek lesson classroom: " + err.Error())
+    }
+    if !assigned {
+        return nil, errors.New("lesson belum di-assign ke classroom ini")
+    }
+
+    // 3. Simpan assignment
+    var assignmentID int
+    err = db.QueryRow(ctx, `
+        INSERT INTO assignments
+        (classroom_id, lesson_id, course_id, title, instructions, open_at, due_at,
+         late_policy, late_penalty_percent, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6::timestamptz, $7::timestamptz, $8, $9, $10)
+        RETURNING id`,
+        classroomID, req.LessonID, req.CourseID, req.Title, req.Instructions, req.OpenAt, req.DueAt,
+        req.LatePolicy, req.LatePenaltyPercent, actor.UserID).Scan(&assignmentID)
+
+    if err != nil {
+        return nil, errors.New("gagal membuat assignment: " + err.Error())
+    }
+
+    return getAssignment(ctx, db, assignmentID)
+}
+
+// GetClassroomAssignments mengambil semua assignment kelas (butuh classroom:manage)
+func GetClassroomAssignments(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int) ([]models.Assignment, error) {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return nil, err
+    }
+
+    rows, err := db.Query(ctx, `
+        SELECT `+assignmentColumns+assignmentJoins+`
+        WHERE a.classroom_id = $1
+        ORDER BY a.due_at ASC`,
+        classroomID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil assignment: " + err.Error())
+    }
+    defer rows.Close()
+
+    return collectAssignments(rows)
+}
+
+// UpdateAssignment mengubah judul, jadwal atau late policy assignment
+func UpdateAssignment(ctx context.Context, db *pgxpool.Pool, actor Actor, assignmentID int, req models.UpdateAssignmentRequest) (*models.Assignment, error) {
+    current, err := getAssignment(ctx, db, assignmentID)
+    if err != nil {
+        return nil, err
+    }
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, current.ClassroomID}); err != nil {
+        return nil, err
+    }
+
+    // Validasi kombinasi nilai baru dengan nilai yang tidak diubah
+    openAt, dueAt := current.OpenAt, current.DueAt
+    if req.OpenAt != nil {
+        openAt = req.OpenAt
+    }
+    if req.DueAt != nil {
+        dueAt = *req.DueAt
+    }
+    latePolicy, penalty := current.LatePolicy, current.LatePenaltyPercent
+    if req.LatePolicy != "" {
+        latePolicy = req.LatePolicy
+    }
+    if req.LatePenaltyPercent != nil {
+        penalty = *req.LatePenaltyPercent
+    }
+    if err := validateAssignmentSchedule(openAt, dueAt, latePolicy, penalty); err != nil {
+        return nil, err
+    }
+
+    setClauses := []string{"updated_at = NOW()"}
+    args := []interface{}{}
+    argPos := 1
+
+    if req.Title != "" {
+        setClauses = append(setClauses, fmt.Sprintf("title = $%d", argPos))
+        args = append(args, req.Title)
+        argPos++
+    }
+    if req.Instructions != nil {
+        setClauses = append(setClauses, fmt.Sprintf("instructions = $%d", argPos))
+        args = append(args, *req.Instructions)
+        argPos++
+    }
+    if req.OpenAt != nil {
+        setClauses = append(setClauses, fmt.Sprintf("open_at = $%d::timestamptz", argPos))
+        args = append(args, *req.OpenAt)
+        argPos++
+    }
+    if req.DueAt != nil {
+        setClauses = append(setClauses, fmt.Sprintf("due_at = $%d::timestamptz", argPos))
+        args = append(args, *req.DueAt)
+        argPos++
+    }
+    if req.LatePolicy != "" {
+        setClauses = append(setClauses, fmt.Sprintf("late_policy = $%d", argPos))
+        args = append(args, req.LatePolicy)
+        argPos++
+    }
+    if req.LatePenaltyPercent != nil {
+        setClauses = append(setClauses, fmt.Sprintf("late_penalty_percent = $%d", argPos))
+        args = append(args, *req.LatePenaltyPercent)
+        argPos++
+    }
+
+    args = append(args, assignmentID)
+    query := fmt.Sprintf("UPDATE assignments SET %s WHERE id = $%d", strings.Join(setClauses, ", "), argPos)
+
+    if _, err := db.Exec(ctx, query, args...); err != nil {
+        var pgErr *pgconn.PgError
+        if errors.As(err, &pgErr) && pgErr.Code == "23514" {
+            return nil, errors.New("open_at harus sebelum due_at")
+        }
+        return nil, errors.New("gagal update assignment: " + err.Error())
+    }
+
+    return getAssignment(ctx, db, assignmentID)
+}
+
+// DeleteAssignment menghapus assignment (attempt yang sudah ditandai terlambat tetap tersimpan)
+func DeleteAssignment(ctx context.Context, db *pgxpool.Pool, actor Actor, assignmentID int) error {
+    current, err := getAssignment(ctx, db, assignmentID)
+    if err != nil {
+        return err
+    }
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, current.ClassroomID}); err != nil {
+        return err
+    }
+
+    if _, err := db.Exec(ctx, "DELETE FROM assignments WHERE id = $1", assignmentID); err != nil {
+        return errors.New("gagal menghapus assignment: " + err.Error())
+    }
+
+    return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// ASSIGNMENT VISIBILITY (Student)
+// ═══════════════════════════════════════════════════════════
+
+// GetMyAssignments mengambil assignment dari semua kelas aktif yang diikuti student
+func GetMyAssignments(ctx context.Context, db *pgxpool.Pool, userID int) ([]models.Assignment, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+assignmentColumns+assignmentJoins+`
+        JOIN classroom_members cm ON cm.classroom_id = a.classroom_id AND cm.user_id = $1
+        WHERE cl.is_active = true
+        ORDER BY a.due_at ASC`,
+        userID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil assignment: " + err.Error())
+    }
+    defer rows.Close()
+
+    return collectAssignments(rows)
+}
+
+// ═══════════════════════════════════════════════════════════
+// SUBMISSION WINDOW (dipakai submission engine & grading queue)
+// ═══════════════════════════════════════════════════════════
+
+// assignmentWindow adalah hasil pengecekan deadline untuk satu submission
+type assignmentWindow struct {
+    AssignmentID       int
+    LatePolicy         string
+    LatePenaltyPercent int
+    IsLate             bool
+}
+
+// checkAssignmentWindow mencari assignment yang berlaku untuk stage ini
+// lalu menolak submission sebelum open_at atau setelah due_at (late_policy block)
+// submittedAt nil = sekarang; grading queue mengirim waktu submission masuk antrian
+// Jika student ada di beberapa kelas: assignment course lebih spesifik dari lesson,
+// lalu dipilih due_at paling akhir. Tanpa assignment (belajar mandiri) return nil
+func checkAssignmentWindow(ctx context.Context, q querier, userID int, stageID int, submittedAt *time.Time) (*assignmentWindow, error) {
+    // Waktu acuan selalu dikirim eksplisit dalam UTC (tidak bergantung timezone session)
+    at := time.Now().UTC()
+    if submittedAt != nil {
+        at = submittedAt.UTC()
+    }
+
+    var window assignmentWindow
+    var notOpen bool
+    err := q.QueryRow(ctx, `
+        SELECT a.id, a.late_policy, a.late_penalty_percent,
+               a.open_at IS NOT NULL AND ($3::timestamptz AT TIME ZONE 'UTC') < a.open_at,
+               ($3::timestamptz AT TIME ZONE 'UTC') > a.due_at
+        FROM primm_stages ps
+        JOIN courses c ON ps.course_id = c.id
+        JOIN assignments a ON a.course_id = c.id OR a.lesson_id = c.lesson_id
+        JOIN classrooms cl ON a.classroom_id = cl.id AND cl.is_active = true
+        JOIN classroom_members cm ON cm.classroom_id = a.classroom_id AND cm.user_id = $1
+        WHERE ps.id = $2
+        ORDER BY a.course_id IS NOT NULL DESC, a.due_at DESC
+        LIMIT 1`,
+        userID, stageID, at).Scan(
+        &window.AssignmentID, &window.LatePolicy, &window.LatePenaltyPercent, &notOpen, &window.IsLate)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, nil
+        }
+        return nil, errors.New("gagal mengecek assignment: " + err.Error())
+    }
+
+    if notOpen {
+        return nil, &SubmissionWindowClosedError{NotOpen: true}
+    }
+    if window.IsLate && window.LatePolicy == models.LatePolicyBlock {
+        return nil, &SubmissionWindowClosedError{}
+    }
+
+    return &window, nil
+}
+
+// applyLatePenalty mengurangi score submission terlambat (late_policy penalize)
+// Return persentase penalty yang diterapkan
+func applyLatePenalty(window *assignmentWindow, graded *gradedAnswer) int {
+    if window == nil || !window.IsLate || window.LatePolicy != models.LatePolicyPenalize {
+        return 0
+    }
+
+    graded.Score = graded.Score * (100 - window.LatePenaltyPercent) / 100
+    graded.Message += fmt.Sprintf(" (Terlambat: score dikurangi %d%%)", window.LatePenaltyPercent)
+    return window.LatePenaltyPercent
+}
+
+// SubmissionWindowClosedError dikembalikan saat submission ditolak karena jadwal assignment
+// NotOpen = sebelum open_at, selain itu sudah lewat due_at (late_policy block)
+type SubmissionWindowClosedError struct {
+    NotOpen bool
+}
+
+func (e *SubmissionWindowClosedError) Error() string {
+    if e.NotOpen {
+        return "assignment belum dibuka"
+    }
+    return "batas waktu assignment sudah lewat"
+}
+
+// IsSubmissionWindowClosed mengecek error penolakan submission karena jadwal assignment (-> 403)
+func IsSubmissionWindowClosed(err error) bool {
+    var closed *SubmissionWindowClosedError
+    return errors.As(err, &closed)
+}
+
+// ═══════════════════════════════════════════════════════════
+// HELPER FUNCTIONS
+// ═══════════════════════════════════════════════════════════
+
+// validateAssignmentSchedule mengecek jadwal & late policy assignment
+func validateAssignmentSchedule(openAt *time.Time, dueAt time.Time, latePolicy string, penalty int) error {
+    if openAt != nil && !openAt.Before(dueAt) {
+        return errors.New("open_at harus sebelum due_at")
+    }
+    if latePolicy == models.LatePolicyPenalize && penalty <= 0 {
+        return errors.New("late_penalty_percent wajib diisi (1-100) untuk late_policy penalize")
+    }
+    return nil
+}
+
+// getAssignment mengambil satu assignment berdasarkan ID
+func getAssignment(ctx context.Context, db *pgxpool.Pool, assignmentID int) (*models.Assignment, error) {
+    assignment, err := scanAssignment(db.QueryRow(ctx, `
+        SELECT `+assignmentColumns+assignmentJoins+`
+        WHERE a.id = $1`,
+        assignmentID))
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("assignment tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil assignment: " + err.Error())
+    }
+
+    return assignment, nil
+}
+
+// collectAssignments membaca semua baris assignmentColumns
+func collectAssignments(rows pgx.Rows) ([]models.Assignment, error) {
+    assignments := []models.Assignment{}
+    for rows.Next() {
+        assignment, err := scanAssignment(rows)
+        if err != nil {
+            return nil, errors.New("gagal scan assignment: " + err.Error())
+        }
+        assignments = append(assignments, *assignment)
+    }
+    return assignments, nil
+}
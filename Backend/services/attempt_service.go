@@ -0,0 +1,110 @@
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// ATTEMPT HISTORY
+// ═══════════════════════════════════════════════════════════
+
+// recordStageAttempt menyimpan satu submission ke stage_attempts (append-only)
+func recordStageAttempt(ctx context.Context, q querier, userID int, answer StageAnswer, graded *gradedAnswer) (int, error) {
+    content := answer.Code
+    switch answer.StageType {
+    case "predict":
+        content = answer.SelectedAnswer
+    case "investigate":
+        content = answer.Reflection
+    }
+
+    verdict := models.VerdictIncorrect
+    switch {
+    case graded.PendingReview:
+        verdict = models.VerdictPendingReview
+    case graded.IsCorrect:
+        verdict = models.VerdictCorrect
+    case graded.CompileError != "":
+        verdict = models.VerdictCompileError
+    }
+
+    var testResultsJSON []byte
+    if graded.TestResults != nil {
+        var err error
+        testResultsJSON, err = json.Marshal(graded.TestResults)
+        if err != nil {
+            return 0, errors.New("gagal serialize test results: " + err.Error())
+        }
+    }
+
+    var output *string
+    if graded.Output != "" {
+        output = &graded.Output
+    }
+
+    var attemptID int
+    err := q.QueryRow(ctx, `
+        INSERT INTO stage_attempts
+        (user_id, stage_id, stage_type, answer, output, verdict, is_correct, score, is_late, test_results)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id`,
+        userID, answer.StageID, answer.StageType, content, output,
+        verdict, graded.IsCorrect, graded.Score, graded.IsLate, testResultsJSON).Scan(&attemptID)
+
+    if err != nil {
+        return 0, errors.New("gagal menyimpan attempt: " + err.Error())
+    }
+
+    return attemptID, nil
+}
+
+// GetStageAttempts mengambil semua attempt user di stage (urut dari yang pertama)
+// Attempt INVESTIGATE yang sudah dinilai memakai hasil review teacher
+func GetStageAttempts(ctx context.Context, db *pgxpool.Pool, stageID int, userID int) ([]models.StageAttempt, error) {
+    rows, err := db.Query(ctx, `
+        SELECT sa.id, ROW_NUMBER() OVER (ORDER BY sa.id) AS attempt_number,
+               sa.user_id, sa.stage_id, sa.stage_type, sa.answer, sa.output,
+               CASE rr.status
+                   WHEN 'approved' THEN 'correct'
+                   WHEN 'rejected' THEN 'incorrect'
+                   ELSE sa.verdict
+               END,
+               sa.is_correct OR COALESCE(rr.status = 'approved', false),
+               COALESCE(rr.score, sa.score), sa.is_late, rr.status, sa.test_results, sa.submitted_at
+        FROM stage_attempts sa
+        LEFT JOIN reflection_reviews rr ON rr.attempt_id = sa.id
+        WHERE sa.stage_id = $1 AND sa.user_id = $2
+        ORDER BY sa.id ASC`, stageID, userID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil attempts: " + err.Error())
+    }
+    defer rows.Close()
+
+    attempts := []models.StageAttempt{}
+    for rows.Next() {
+        var attempt models.StageAttempt
+        var testResultsJSON []byte
+        err := rows.Scan(
+            &attempt.ID, &attempt.AttemptNumber,
+            &attempt.UserID, &attempt.StageID, &attempt.StageType, &attempt.Answer, &attempt.Output,
+            &attempt.Verdict, &attempt.IsCorrect, &attempt.Score, &attempt.IsLate, &attempt.ReviewStatus, &testResultsJSON, &attempt.SubmittedAt)
+
+        if err != nil {
+            return nil, errors.New("gagal scan attempt: " + err.Error())
+        }
+
+        if testResultsJSON != nil {
+            json.Unmarshal(testResultsJSON, &attempt.TestResults)
+        }
+
+        attempts = append(attempts, attempt)
+    }
+
+    return attempts, nil
+}
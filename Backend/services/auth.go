@@ -8,6 +8,7 @@ import (
 
     "github.com/golang-jwt/jwt/v5"
     "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
     "golang.org/x/crypto/bcrypt"
     "primmfy_db/models"
 )
@@ -35,8 +36,9 @@ func CheckPasswordHash(password, hash string) bool {
 // JWT TOKEN FUNCTIONS
 // ═══════════════════════════════════════════════════════════
 
-// GenerateJWT membuat JWT token untuk user
-func GenerateJWT(userID int, email string, role string) (string, error) {
+// GenerateJWT membuat access token (JWT) untuk user di satu session
+// Umur token diatur lewat ACCESS_TOKEN_TTL (default 15 menit)
+func GenerateJWT(userID int, email string, role string, sessionID int64) (string, error) {
     secretKey := os.Getenv("JWT_SECRET")
     if secretKey == "" {
         return "", errors.New("JWT_SECRET tidak ditemukan di environment variables")
@@ -47,7 +49,8 @@ func GenerateJWT(userID int, email string, role string) (string, error) {
         "user_id": userID,
         "email":   email,
         "role":    role,
-        "exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // Token valid 7 hari
+        "sid":     sessionID,
+        "exp":     time.Now().Add(accessTokenTTL()).Unix(),
     }
 
     // Buat token dengan claims
@@ -95,16 +98,24 @@ func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
 // ═══════════════════════════════════════════════════════════
 
 // Register membuat user baru
-func Register(db *pgx.Conn, req models.RegisterRequest) (*models.User, error) {
+// Tanpa invite code user selalu menjadi student
+// Invite code (dari admin) menentukan role teacher/admin dan hanya bisa dipakai sekali
+func Register(ctx context.Context, db *pgxpool.Pool, req models.RegisterRequest) (*models.User, error) {
     // 1. Hash password
     hashedPassword, err := HashPassword(req.Password)
     if err != nil {
         return nil, errors.New("gagal hash password: " + err.Error())
     }
 
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
     // 2. Cek apakah email sudah terdaftar
     var existingID int
-    err = db.QueryRow(context.Background(),
+    err = tx.QueryRow(ctx,
         "SELECT id FROM users WHERE email = $1", req.Email).Scan(&existingID)
 
     if err == nil {
@@ -117,42 +128,87 @@ func Register(db *pgx.Conn, req models.RegisterRequest) (*models.User, error) {
         return nil, errors.New("gagal cek email: " + err.Error())
     }
 
-    // 3. Insert user baru DENGAN profile_picture dan bio
+    // 3. Tentukan role (public = student, teacher/admin dari invite)
+    role := models.RoleStudent
+    var invite *models.RoleInvite
+    if req.InviteCode != "" {
+        invite, err = lockRoleInvite(ctx, tx, req.InviteCode)
+        if err != nil {
+            return nil, err
+        }
+        if req.Role != "" && req.Role != invite.Role {
+            return nil, errors.New("role tidak sesuai dengan invite code")
+        }
+        role = invite.Role
+    } else if req.Role != "" && req.Role != models.RoleStudent {
+        return nil, errors.New("registrasi teacher/admin membutuhkan invite code")
+    }
+
+    // 4. Insert user baru DENGAN profile_picture dan bio
     var user models.User
-    err = db.QueryRow(context.Background(), `
-        INSERT INTO users (email, password_hash, full_name, role, level, total_coins, experience_points)
-        VALUES ($1, $2, $3, $4, 1, 0, 0)
-        RETURNING id, email, full_name, role, level, total_coins, experience_points, 
-                  profile_picture, bio, created_at, updated_at`,
-        req.Email, hashedPassword, req.FullName, req.Role).Scan(
+    err = tx.QueryRow(ctx, `
+        INSERT INTO users (email, password_hash, full_name, role, level, experience_points)
+        VALUES ($1, $2, $3, $4, 1, 0)
+        RETURNING id, email, full_name, role, level, `+userCoinBalanceSQL+`, experience_points, 
+                  profile_picture, bio, email_verified_at IS NOT NULL, created_at, updated_at`,
+        req.Email, hashedPassword, req.FullName, role).Scan(
         &user.ID, &user.Email, &user.FullName, &user.Role,
         &user.Level, &user.TotalCoins, &user.ExperiencePoints,
-        &user.ProfilePicture, &user.Bio, &user.CreatedAt, &user.UpdatedAt)
+        &user.ProfilePicture, &user.Bio, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
 
     if err != nil {
         return nil, errors.New("gagal membuat user: " + err.Error())
     }
 
+    // 5. Tandai invite terpakai + catat role awal di audit log
+    if invite != nil {
+        _, err = tx.Exec(ctx,
+            "UPDATE role_invites SET used_by = $1, used_at = NOW() WHERE id = $2",
+            user.ID, invite.ID)
+        if err != nil {
+            return nil, errors.New("gagal memakai invite code: " + err.Error())
+        }
+
+        err = recordRoleChange(ctx, tx, user.ID, nil, role, invite.CreatedBy, &invite.ID, "registrasi dengan invite code")
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal membuat user: " + err.Error())
+    }
+
     return &user, nil
 }
 
-// Login memvalidasi kredensial dan return JWT token
-func Login(db *pgx.Conn, req models.LoginRequest) (*models.LoginResponse, error) {
+// Login memvalidasi kredensial lalu membuat session baru (access + refresh token)
+// limiter membatasi login gagal per akun & per IP (nil = tanpa batas)
+func Login(ctx context.Context, db *pgxpool.Pool, limiter *LoginLimiter, req models.LoginRequest, client ClientInfo) (*models.LoginResponse, error) {
+    // 0. Tolak jika akun / IP sedang dikunci atau masih dalam masa backoff
+    // Percobaan ini langsung dihitung gagal, baru di-reset jika password benar
+    if limiter != nil {
+        if err := limiter.Reserve(ctx, req.Email, client.IPAddress); err != nil {
+            return nil, err
+        }
+    }
+
     // 1. Ambil user berdasarkan email DENGAN profile_picture dan bio
     var user models.User
     var passwordHash string
 
-    err := db.QueryRow(context.Background(),
-        `SELECT id, email, password_hash, full_name, role, level, total_coins, experience_points, 
-                profile_picture, bio, created_at, updated_at 
+    err := db.QueryRow(ctx,
+        `SELECT id, email, password_hash, full_name, role, level, `+userCoinBalanceSQL+`, experience_points, 
+                profile_picture, bio, email_verified_at IS NOT NULL, created_at, updated_at 
          FROM users WHERE email = $1`,
         req.Email).Scan(
         &user.ID, &user.Email, &passwordHash, &user.FullName, &user.Role,
         &user.Level, &user.TotalCoins, &user.ExperiencePoints,
-        &user.ProfilePicture, &user.Bio, &user.CreatedAt, &user.UpdatedAt)
+        &user.ProfilePicture, &user.Bio, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
 
     if err != nil {
         if err == pgx.ErrNoRows {
+            // Email tidak terdaftar juga dihitung gagal agar tidak bisa dipakai untuk enumerasi email
             return nil, errors.New("email atau password salah")
         }
         return nil, errors.New("gagal login: " + err.Error())
@@ -163,31 +219,49 @@ func Login(db *pgx.Conn, req models.LoginRequest) (*models.LoginResponse, error)
         return nil, errors.New("email atau password salah")
     }
 
-    // 3. Generate JWT token
-    token, err := GenerateJWT(user.ID, user.Email, user.Role)
+    if limiter != nil {
+        if err := limiter.RecordSuccess(ctx, req.Email, client.IPAddress); err != nil {
+            return nil, err
+        }
+    }
+
+    // 3. Cek verifikasi email (UNVERIFIED_LOGIN=block|warn)
+    var warning string
+    if !user.EmailVerified {
+        if UnverifiedLoginPolicy() == UnverifiedLoginBlock {
+            return nil, errors.New("email belum diverifikasi")
+        }
+        warning = "email belum diverifikasi, cek inbox untuk link verifikasi"
+    }
+
+    // 4. Buat session + generate token
+    tokens, err := CreateSession(ctx, db, &user, client)
     if err != nil {
-        return nil, errors.New("gagal generate token: " + err.Error())
+        return nil, err
     }
 
-    // 4. Return response dengan token
+    // 5. Return response dengan token
     return &models.LoginResponse{
-        Message: "Login berhasil!",
-        Token:   token,
-        User:    &user,
+        Message:      "Login berhasil!",
+        Token:        tokens.Token,
+        RefreshToken: tokens.RefreshToken,
+        ExpiresIn:    tokens.ExpiresIn,
+        User:         &user,
+        Warning:      warning,
     }, nil
 }
 
 // GetUserByID mengambil user berdasarkan ID DENGAN profile_picture dan bio
-func GetUserByID(db *pgx.Conn, userID int) (*models.User, error) {
+func GetUserByID(ctx context.Context, db *pgxpool.Pool, userID int) (*models.User, error) {
     var user models.User
-    err := db.QueryRow(context.Background(),
-        `SELECT id, email, full_name, role, level, total_coins, experience_points, 
-                profile_picture, bio, created_at, updated_at 
+    err := db.QueryRow(ctx,
+        `SELECT id, email, full_name, role, level, `+userCoinBalanceSQL+`, experience_points, 
+                profile_picture, bio, email_verified_at IS NOT NULL, created_at, updated_at 
          FROM users WHERE id = $1`,
         userID).Scan(
         &user.ID, &user.Email, &user.FullName, &user.Role,
         &user.Level, &user.TotalCoins, &user.ExperiencePoints,
-        &user.ProfilePicture, &user.Bio, &user.CreatedAt, &user.UpdatedAt)
+        &user.ProfilePicture, &user.Bio, &user.EmailVerified, &user.CreatedAt, &user.UpdatedAt)
 
     if err != nil {
         if err == pgx.ErrNoRows {
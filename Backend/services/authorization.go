@@ -0,0 +1,245 @@
+package services
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jackc/pgx/v5"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// AUTHORIZATION
+// Semua pengecekan akses lewat satu tempat:
+// 1. Role -> permission (boleh melakukan aksi ini secara umum?)
+// 2. Resource -> lesson -> relasi user ke lesson (owner/editor/viewer) -> permission
+//    (boleh melakukan aksi ini pada resource ini?)
+// Admin punya lesson:manage_any sehingga bisa mengelola lesson siapa saja
+// ═══════════════════════════════════════════════════════════
+
+// Permission adalah nama aksi yang bisa diizinkan (format resource:aksi)
+type Permission string
+
+const (
+    PermLessonCreate              Permission = "lesson:create"               // Membuat lesson & melihat lesson milik sendiri
+    PermLessonView                Permission = "lesson:view"                 // Melihat detail pengelolaan lesson (collaborator)
+    PermLessonEdit                Permission = "lesson:edit"                 // Mengubah lesson beserta course & stage di dalamnya
+    PermLessonDelete              Permission = "lesson:delete"               // Menghapus lesson
+    PermLessonManageCollaborators Permission = "lesson:manage_collaborators" // Mengundang & menghapus collaborator
+    PermLessonManageAny           Permission = "lesson:manage_any"           // Mengelola lesson tanpa cek ownership
+    PermSubmissionViewAll         Permission = "submission:view_all"         // Melihat attempt student lain di lesson
+    PermSubmissionFeedback        Permission = "submission:feedback"         // Memberi feedback pada attempt student
+    PermClassroomManage           Permission = "classroom:manage"            // Membuat & mengelola kelas
+    PermClassroomManageAny        Permission = "classroom:manage_any"        // Mengelola kelas tanpa cek ownership
+    PermClassroomJoin             Permission = "classroom:join"              // Bergabung ke kelas dengan join code
+    PermStageSubmit               Permission = "stage:submit"                // Mengerjakan stage & melihat progress sendiri
+    PermUserManage                Permission = "user:manage"                 // Invite, role & unlock user
+)
+
+// rolePermissions adalah permission yang dimiliki setiap role
+var rolePermissions = map[string][]Permission{
+    models.RoleStudent: {
+        PermStageSubmit, PermClassroomJoin,
+    },
+    models.RoleTeacher: {
+        PermLessonCreate, PermLessonView, PermLessonEdit, PermLessonDelete,
+        PermLessonManageCollaborators, PermSubmissionViewAll, PermSubmissionFeedback,
+        PermClassroomManage,
+    },
+    models.RoleAdmin: {
+        PermLessonCreate, PermLessonView, PermLessonEdit, PermLessonDelete,
+        PermLessonManageCollaborators, PermSubmissionViewAll, PermSubmissionFeedback,
+        PermClassroomManage, PermLessonManageAny, PermClassroomManageAny, PermUserManage,
+    },
+}
+
+// Relation adalah hubungan user dengan sebuah resource
+// (lesson: role collaborator, classroom: teacher kelas)
+type Relation string
+
+const (
+    RelationNone             Relation = ""
+    RelationOwner            Relation = models.CollaboratorOwner
+    RelationEditor           Relation = models.CollaboratorEditor
+    RelationViewer           Relation = models.CollaboratorViewer
+    RelationClassroomTeacher Relation = "classroom_teacher"
+)
+
+// relationPermissions adalah permission yang diberikan relasi user ke resource
+var relationPermissions = map[Relation][]Permission{
+    RelationOwner: {
+        PermLessonView, PermLessonEdit, PermLessonDelete,
+        PermLessonManageCollaborators, PermSubmissionViewAll, PermSubmissionFeedback,
+    },
+    RelationEditor: {PermLessonView, PermLessonEdit, PermSubmissionViewAll, PermSubmissionFeedback},
+    RelationViewer: {PermLessonView, PermSubmissionViewAll},
+
+    RelationClassroomTeacher: {PermClassroomManage},
+}
+
+// Actor adalah user yang sedang melakukan request
+type Actor struct {
+    UserID int
+    Role   string
+}
+
+// ResourceType adalah jenis resource yang ownership-nya bisa dicek
+type ResourceType string
+
+const (
+    ResourceLesson ResourceType = "lesson"
+    ResourceCourse ResourceType = "course"
+    ResourceStage  ResourceType = "stage"
+
+    ResourceClassroom ResourceType = "classroom"
+)
+
+// Resource menunjuk satu resource (contoh: Resource{ResourceCourse, 12})
+type Resource struct {
+    Type ResourceType
+    ID   int
+}
+
+// lessonResolvers mencari lesson tempat resource berada
+// (ownership course & stage selalu mengikuti lesson-nya)
+var lessonResolvers = map[ResourceType]func(ctx context.Context, q querier, id int) (int, error){
+    ResourceLesson: func(ctx context.Context, q querier, id int) (int, error) {
+        var lessonID int
+        err := q.QueryRow(ctx, "SELECT id FROM lessons WHERE id = $1", id).Scan(&lessonID)
+        return lessonID, err
+    },
+    ResourceCourse: func(ctx context.Context, q querier, id int) (int, error) {
+        var lessonID int
+        err := q.QueryRow(ctx, "SELECT lesson_id FROM courses WHERE id = $1", id).Scan(&lessonID)
+        return lessonID, err
+    },
+    ResourceStage: func(ctx context.Context, q querier, id int) (int, error) {
+        var lessonID int
+        err := q.QueryRow(ctx, `
+            SELECT c.lesson_id
+            FROM primm_stages ps
+            JOIN courses c ON ps.course_id = c.id
+            WHERE ps.id = $1`, id).Scan(&lessonID)
+        return lessonID, err
+    },
+}
+
+// PermissionDeniedError dikembalikan saat actor tidak punya permission
+type PermissionDeniedError struct {
+    Permission Permission
+}
+
+func (e *PermissionDeniedError) Error() string {
+    return "anda tidak memiliki akses (" + string(e.Permission) + ")"
+}
+
+// IsPermissionDenied mengecek apakah error berasal dari pengecekan akses (-> 403)
+func IsPermissionDenied(err error) bool {
+    var denied *PermissionDeniedError
+    return errors.As(err, &denied)
+}
+
+// HasPermission mengecek permission berdasarkan role saja (tanpa resource)
+func HasPermission(role string, perm Permission) bool {
+    return containsPermission(rolePermissions[role], perm)
+}
+
+// Authorize mengecek apakah actor boleh melakukan perm pada resource
+// Error: "<resource> tidak ditemukan" atau *PermissionDeniedError
+func Authorize(ctx context.Context, q querier, actor Actor, perm Permission, resource Resource) error {
+    if !HasPermission(actor.Role, perm) {
+        return &PermissionDeniedError{Permission: perm}
+    }
+
+    var relation Relation
+    var err error
+    if resource.Type == ResourceClassroom {
+        relation, err = getClassroomRelation(ctx, q, resource.ID, actor.UserID)
+        if err != nil {
+            return err
+        }
+        if HasPermission(actor.Role, PermClassroomManageAny) {
+            return nil
+        }
+    } else {
+        resolve, ok := lessonResolvers[resource.Type]
+        if !ok {
+            return errors.New("resource tidak dikenal: " + string(resource.Type))
+        }
+
+        lessonID, err := resolve(ctx, q, resource.ID)
+        if err != nil {
+            if err == pgx.ErrNoRows {
+                return errors.New(string(resource.Type) + " tidak ditemukan")
+            }
+            return errors.New("gagal cek " + string(resource.Type) + ": " + err.Error())
+        }
+
+        if HasPermission(actor.Role, PermLessonManageAny) {
+            return nil
+        }
+
+        relation, err = GetLessonRelation(ctx, q, lessonID, actor.UserID)
+        if err != nil {
+            return err
+        }
+    }
+
+    if !containsPermission(relationPermissions[relation], perm) {
+        return &PermissionDeniedError{Permission: perm}
+    }
+
+    return nil
+}
+
+// GetLessonRelation mengambil hubungan user dengan lesson
+// (role di lesson_collaborators yang sudah diterima)
+func GetLessonRelation(ctx context.Context, q querier, lessonID int, userID int) (Relation, error) {
+    var role *string
+    err := q.QueryRow(ctx, `
+        SELECT lc.role
+        FROM lessons l
+        LEFT JOIN lesson_collaborators lc
+            ON lc.lesson_id = l.id AND lc.user_id = $2 AND lc.accepted_at IS NOT NULL
+        WHERE l.id = $1`,
+        lessonID, userID).Scan(&role)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return RelationNone, errors.New("lesson tidak ditemukan")
+        }
+        return RelationNone, errors.New("gagal cek ownership: " + err.Error())
+    }
+
+    if role == nil {
+        return RelationNone, nil
+    }
+    return Relation(*role), nil
+}
+
+// getClassroomRelation mengambil hubungan user dengan kelas (teacher kelas atau tidak)
+func getClassroomRelation(ctx context.Context, q querier, classroomID int, userID int) (Relation, error) {
+    var teacherID int
+    err := q.QueryRow(ctx, "SELECT teacher_id FROM classrooms WHERE id = $1", classroomID).Scan(&teacherID)
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return RelationNone, errors.New("classroom tidak ditemukan")
+        }
+        return RelationNone, errors.New("gagal cek classroom: " + err.Error())
+    }
+
+    if teacherID == userID {
+        return RelationClassroomTeacher, nil
+    }
+    return RelationNone, nil
+}
+
+// containsPermission mengecek apakah perm ada di daftar
+func containsPermission(perms []Permission, perm Permission) bool {
+    for _, p := range perms {
+        if p == perm {
+            return true
+        }
+    }
+    return false
+}
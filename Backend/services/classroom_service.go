@@ -0,0 +1,505 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// CLASSROOMS
+// Teacher membuat kelas, student bergabung dengan join code
+// Lesson yang di-assign ke kelas otomatis di-enroll untuk semua member
+// (termasuk student yang bergabung belakangan)
+// ═══════════════════════════════════════════════════════════
+
+// joinCodeAlphabet tanpa karakter yang mirip (0/O, 1/I/L) agar mudah diketik
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// joinCodeLength adalah panjang join code kelas
+const joinCodeLength = 8
+
+// generateJoinCode membuat join code acak
+func generateJoinCode() (string, error) {
+    buf := make([]byte, joinCodeLength)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+
+    code := make([]byte, joinCodeLength)
+    for i, b := range buf {
+        code[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+    }
+    return string(code), nil
+}
+
+// normalizeJoinCode menyamakan format input student (huruf besar, tanpa spasi / strip)
+func normalizeJoinCode(code string) string {
+    code = strings.ToUpper(strings.TrimSpace(code))
+    return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+// isUniqueViolation mengecek error duplicate key dari Postgres
+func isUniqueViolation(err error) bool {
+    var pgErr *pgconn.PgError
+    return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// classroomColumns adalah kolom untuk scanClassroom
+const classroomColumns = `
+    cl.id, cl.teacher_id, u.full_name, cl.name, COALESCE(cl.description, ''), cl.join_code,
+    cl.is_active, cl.created_at, cl.updated_at,
+    (SELECT COUNT(*) FROM classroom_members cm WHERE cm.classroom_id = cl.id),
+    (SELECT COUNT(*) FROM classroom_lessons cls WHERE cls.classroom_id = cl.id)`
+
+// scanClassroom membaca satu baris classroomColumns
+func scanClassroom(row pgx.Row) (*models.Classroom, error) {
+    var classroom models.Classroom
+    err := row.Scan(
+        &classroom.ID, &classroom.TeacherID, &classroom.TeacherName, &classroom.Name,
+        &classroom.Description, &classroom.JoinCode, &classroom.IsActive,
+        &classroom.CreatedAt, &classroom.UpdatedAt, &classroom.MemberCount, &classroom.LessonCount)
+    if err != nil {
+        return nil, err
+    }
+    return &classroom, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// CLASSROOM MANAGEMENT (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// CreateClassroom membuat kelas baru dengan join code unik
+func CreateClassroom(ctx context.Context, db *pgxpool.Pool, teacherID int, req models.CreateClassroomRequest) (*models.Classroom, error) {
+    // Join code di-generate ulang jika kebetulan bentrok dengan kelas lain
+    for attempt := 0; attempt < 5; attempt++ {
+        code, err := generateJoinCode()
+        if err != nil {
+            return nil, errors.New("gagal generate join code: " + err.Error())
+        }
+
+        var classroomID int
+        err = db.QueryRow(ctx, `
+            INSERT INTO classrooms (teacher_id, name, description, join_code)
+            VALUES ($1, $2, $3, $4)
+            RETURNING id`,
+            teacherID, req.Name, req.Description, code).Scan(&classroomID)
+
+        if err == nil {
+            return getClassroom(ctx, db, classroomID)
+        }
+        if !isUniqueViolation(err) {
+            return nil, errors.New("gagal membuat classroom: " + err.Error())
+        }
+    }
+
+    return nil, errors.New("gagal membuat classroom: join code bentrok, coba lagi")
+}
+
+// GetClassroomsByTeacher mengambil semua kelas milik teacher
+func GetClassroomsByTeacher(ctx context.Context, db *pgxpool.Pool, teacherID int) ([]models.Classroom, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+classroomColumns+`
+        FROM classrooms cl
+        JOIN users u ON cl.teacher_id = u.id
+        WHERE cl.teacher_id = $1
+        ORDER BY cl.created_at DESC`,
+        teacherID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil classroom: " + err.Error())
+    }
+    defer rows.Close()
+
+    classrooms := []models.Classroom{}
+    for rows.Next() {
+        classroom, err := scanClassroom(rows)
+        if err != nil {
+            return nil, errors.New("gagal scan classroom: " + err.Error())
+        }
+        classrooms = append(classrooms, *classroom)
+    }
+
+    return classrooms, nil
+}
+
+// GetClassroomDetail mengambil kelas beserta roster & lesson (butuh classroom:manage)
+func GetClassroomDetail(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int) (*models.ClassroomDetail, error) {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return nil, err
+    }
+
+    classroom, err := getClassroom(ctx, db, classroomID)
+    if err != nil {
+        return nil, err
+    }
+
+    detail := &models.ClassroomDetail{
+        Classroom: *classroom,
+        Members:   []models.ClassroomMember{},
+        Lessons:   []models.ClassroomLesson{},
+    }
+
+    // 1. Roster
+    rows, err := db.Query(ctx, `
+        SELECT u.id, u.full_name, u.email, cm.joined_at
+        FROM classroom_members cm
+        JOIN users u ON cm.user_id = u.id
+        WHERE cm.classroom_id = $1
+        ORDER BY u.full_name ASC`,
+        classroomID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil roster: " + err.Error())
+    }
+    for rows.Next() {
+        var member models.ClassroomMember
+        if err := rows.Scan(&member.UserID, &member.FullName, &member.Email, &member.JoinedAt); err != nil {
+            rows.Close()
+            return nil, errors.New("gagal scan roster: " + err.Error())
+        }
+        detail.Members = append(detail.Members, member)
+    }
+    rows.Close()
+
+    // 2. Lesson yang di-assign
+    lessons, err := getClassroomLessons(ctx, db, classroomID)
+    if err != nil {
+        return nil, err
+    }
+    detail.Lessons = lessons
+
+    return detail, nil
+}
+
+// UpdateClassroom mengupdate nama / deskripsi / status kelas (butuh classroom:manage)
+func UpdateClassroom(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int, req models.UpdateClassroomRequest) (*models.Classroom, error) {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return nil, err
+    }
+
+    setClauses := []string{"updated_at = NOW()"}
+    args := []interface{}{}
+    argPos := 1
+
+    if req.Name != "" {
+        setClauses = append(setClauses, fmt.Sprintf("name = $%d", argPos))
+        args = append(args, req.Name)
+        argPos++
+    }
+    if req.Description != "" {
+        setClauses = append(setClauses, fmt.Sprintf("description = $%d", argPos))
+        args = append(args, req.Description)
+        argPos++
+    }
+    if req.IsActive != nil {
+        setClauses = append(setClauses, fmt.Sprintf("is_active = $%d", argPos))
+        args = append(args, *req.IsActive)
+        argPos++
+    }
+
+    args = append(args, classroomID)
+    query := fmt.Sprintf("UPDATE classrooms SET %s WHERE id = $%d", strings.Join(setClauses, ", "), argPos)
+
+    if _, err := db.Exec(ctx, query, args...); err != nil {
+        return nil, errors.New("gagal update classroom: " + err.Error())
+    }
+
+    return getClassroom(ctx, db, classroomID)
+}
+
+// RegenerateJoinCode mengganti join code kelas (code lama tidak berlaku lagi)
+func RegenerateJoinCode(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int) (*models.Classroom, error) {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return nil, err
+    }
+
+    for attempt := 0; attempt < 5; attempt++ {
+        code, err := generateJoinCode()
+        if err != nil {
+            return nil, errors.New("gagal generate join code: " + err.Error())
+        }
+
+        _, err = db.Exec(ctx,
+            "UPDATE classrooms SET join_code = $1, updated_at = NOW() WHERE id = $2",
+            code, classroomID)
+
+        if err == nil {
+            return getClassroom(ctx, db, classroomID)
+        }
+        if !isUniqueViolation(err) {
+            return nil, errors.New("gagal mengganti join code: " + err.Error())
+        }
+    }
+
+    return nil, errors.New("gagal mengganti join code: join code bentrok, coba lagi")
+}
+
+// RemoveClassroomMember mengeluarkan student dari kelas
+// (enrollment lesson yang sudah ada tetap dipertahankan beserta progress-nya)
+func RemoveClassroomMember(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int, userID int) error {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return err
+    }
+
+    tag, err := db.Exec(ctx,
+        "DELETE FROM classroom_members WHERE classroom_id = $1 AND user_id = $2",
+        classroomID, userID)
+
+    if err != nil {
+        return errors.New("gagal mengeluarkan student: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return errors.New("student tidak ditemukan di classroom ini")
+    }
+
+    return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// CLASSROOM LESSONS (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// AssignLessonToClassroom meng-assign lesson ke kelas lalu meng-enroll semua member
+// Return jumlah student yang baru di-enroll
+func AssignLessonToClassroom(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int, lessonID int) (int64, error) {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return 0, err
+    }
+
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return 0, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Lesson harus ada dan aktif
+    var isActive bool
+    err = tx.QueryRow(ctx, "SELECT is_active FROM lessons WHERE id = $1", lessonID).Scan(&isActive)
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return 0, errors.New("lesson tidak ditemukan")
+        }
+        return 0, errors.New("gagal mengecek lesson: " + err.Error())
+    }
+    if !isActive {
+        return 0, errors.New("lesson tidak aktif")
+    }
+
+    // 2. Assign lesson ke kelas
+    tag, err := tx.Exec(ctx, `
+        INSERT INTO classroom_lessons (classroom_id, lesson_id, assigned_by)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (classroom_id, lesson_id) DO NOTHING`,
+        classroomID, lessonID, actor.UserID)
+
+    if err != nil {
+        return 0, errors.New("gagal assign lesson: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return 0, errors.New("lesson sudah di-assign ke classroom ini")
+    }
+
+    // 3. Bulk enroll semua member (yang sudah enroll sendiri dilewati)
+    tag, err = tx.Exec(ctx, `
+        INSERT INTO user_lessons (user_id, lesson_id)
+        SELECT user_id, $2 FROM classroom_members WHERE classroom_id = $1
+        ON CONFLICT (user_id, lesson_id) DO NOTHING`,
+        classroomID, lessonID)
+
+    if err != nil {
+        return 0, errors.New("gagal enroll member: " + err.Error())
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return 0, errors.New("gagal assign lesson: " + err.Error())
+    }
+
+    return tag.RowsAffected(), nil
+}
+
+// UnassignLessonFromClassroom menghapus lesson dari kelas
+// (enrollment student tidak dihapus agar progress tidak hilang)
+func UnassignLessonFromClassroom(ctx context.Context, db *pgxpool.Pool, actor Actor, classroomID int, lessonID int) error {
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return err
+    }
+
+    tag, err := db.Exec(ctx,
+        "DELETE FROM classroom_lessons WHERE classroom_id = $1 AND lesson_id = $2",
+        classroomID, lessonID)
+
+    if err != nil {
+        return errors.New("gagal menghapus lesson dari classroom: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return errors.New("lesson tidak di-assign ke classroom ini")
+    }
+
+    return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// CLASSROOM MEMBERSHIP (Student)
+// ═══════════════════════════════════════════════════════════
+
+// JoinClassroom menambahkan student ke kelas lewat join code
+// lalu meng-enroll student ke semua lesson kelas
+func JoinClassroom(ctx context.Context, db *pgxpool.Pool, userID int, joinCode string) (*models.Classroom, error) {
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Cari kelas aktif dengan join code ini
+    var classroomID int
+    var isActive bool
+    err = tx.QueryRow(ctx,
+        "SELECT id, is_active FROM classrooms WHERE join_code = $1",
+        normalizeJoinCode(joinCode)).Scan(&classroomID, &isActive)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("join code tidak valid")
+        }
+        return nil, errors.New("gagal mencari classroom: " + err.Error())
+    }
+    if !isActive {
+        return nil, errors.New("classroom tidak aktif")
+    }
+
+    // 2. Tambah ke roster
+    tag, err := tx.Exec(ctx, `
+        INSERT INTO classroom_members (classroom_id, user_id)
+        VALUES ($1, $2)
+        ON CONFLICT (classroom_id, user_id) DO NOTHING`,
+        classroomID, userID)
+
+    if err != nil {
+        return nil, errors.New("gagal bergabung ke classroom: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return nil, errors.New("anda sudah bergabung di classroom ini")
+    }
+
+    // 3. Enroll ke semua lesson aktif yang sudah di-assign
+    _, err = tx.Exec(ctx, `
+        INSERT INTO user_lessons (user_id, lesson_id)
+        SELECT $2, cls.lesson_id
+        FROM classroom_lessons cls
+        JOIN lessons l ON cls.lesson_id = l.id
+        WHERE cls.classroom_id = $1 AND l.is_active = true
+        ON CONFLICT (user_id, lesson_id) DO NOTHING`,
+        classroomID, userID)
+
+    if err != nil {
+        return nil, errors.New("gagal enroll lesson classroom: " + err.Error())
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal bergabung ke classroom: " + err.Error())
+    }
+
+    return getClassroom(ctx, db, classroomID)
+}
+
+// GetMyClassrooms mengambil semua kelas yang diikuti student
+func GetMyClassrooms(ctx context.Context, db *pgxpool.Pool, userID int) ([]models.Classroom, error) {
+    rows, err := db.Query(ctx, `
+        SELECT `+classroomColumns+`
+        FROM classrooms cl
+        JOIN users u ON cl.teacher_id = u.id
+        JOIN classroom_members me ON me.classroom_id = cl.id AND me.user_id = $1
+        ORDER BY me.joined_at DESC`,
+        userID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil classroom: " + err.Error())
+    }
+    defer rows.Close()
+
+    classrooms := []models.Classroom{}
+    for rows.Next() {
+        classroom, err := scanClassroom(rows)
+        if err != nil {
+            return nil, errors.New("gagal scan classroom: " + err.Error())
+        }
+        classrooms = append(classrooms, *classroom)
+    }
+
+    return classrooms, nil
+}
+
+// LeaveClassroom mengeluarkan student dari kelas atas kemauan sendiri
+func LeaveClassroom(ctx context.Context, db *pgxpool.Pool, userID int, classroomID int) error {
+    tag, err := db.Exec(ctx,
+        "DELETE FROM classroom_members WHERE classroom_id = $1 AND user_id = $2",
+        classroomID, userID)
+
+    if err != nil {
+        return errors.New("gagal keluar dari classroom: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return errors.New("anda bukan member classroom ini")
+    }
+
+    return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// HELPER FUNCTIONS
+// ═══════════════════════════════════════════════════════════
+
+// getClassroom mengambil satu kelas berdasarkan ID
+func getClassroom(ctx context.Context, db *pgxpool.Pool, classroomID int) (*models.Classroom, error) {
+    classroom, err := scanClassroom(db.QueryRow(ctx, `
+        SELECT `+classroomColumns+`
+        FROM classrooms cl
+        JOIN users u ON cl.teacher_id = u.id
+        WHERE cl.id = $1`,
+        classroomID))
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("classroom tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil classroom: " + err.Error())
+    }
+
+    return classroom, nil
+}
+
+// getClassroomLessons mengambil lesson yang di-assign ke kelas
+func getClassroomLessons(ctx context.Context, db *pgxpool.Pool, classroomID int) ([]models.ClassroomLesson, error) {
+    rows, err := db.Query(ctx, `
+        SELECT l.id, l.title, l.category, l.difficulty, cls.assigned_at
+        FROM classroom_lessons cls
+        JOIN lessons l ON cls.lesson_id = l.id
+        WHERE cls.classroom_id = $1
+        ORDER BY cls.assigned_at ASC`,
+        classroomID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil lesson classroom: " + err.Error())
+    }
+    defer rows.Close()
+
+    lessons := []models.ClassroomLesson{}
+    for rows.Next() {
+        var lesson models.ClassroomLesson
+        if err := rows.Scan(&lesson.LessonID, &lesson.Title, &lesson.Category, &lesson.Difficulty, &lesson.AssignedAt); err != nil {
+            return nil, errors.New("gagal scan lesson classroom: " + err.Error())
+        }
+        lessons = append(lessons, lesson)
+    }
+
+    return lessons, nil
+}
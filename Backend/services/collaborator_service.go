@@ -0,0 +1,262 @@
+package services
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// LESSON COLLABORATORS (Co-teacher)
+// Owner mengundang teacher lain sebagai owner / editor / viewer,
+// undangan berlaku setelah diterima. Lesson selalu punya minimal satu owner
+// lessons.teacher_id tetap menunjuk salah satu owner (pembuat / owner pengganti)
+// ═══════════════════════════════════════════════════════════
+
+// collaboratorColumns adalah kolom untuk scanCollaborator
+const collaboratorColumns = `
+    lc.lesson_id, lc.user_id, u.full_name, u.email, lc.role,
+    lc.invited_by, lc.invited_at, lc.accepted_at`
+
+// scanCollaborator membaca satu baris collaboratorColumns
+func scanCollaborator(row pgx.Row) (*models.LessonCollaborator, error) {
+    var collaborator models.LessonCollaborator
+    err := row.Scan(
+        &collaborator.LessonID, &collaborator.UserID, &collaborator.FullName, &collaborator.Email,
+        &collaborator.Role, &collaborator.InvitedBy, &collaborator.InvitedAt, &collaborator.AcceptedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &collaborator, nil
+}
+
+// InviteCollaborator mengundang teacher ke lesson (butuh lesson:manage_collaborators)
+// Undangan yang belum diterima bisa dikirim ulang dengan role baru
+func InviteCollaborator(ctx context.Context, db *pgxpool.Pool, actor Actor, lessonID int, req models.InviteCollaboratorRequest) (*models.LessonCollaborator, error) {
+    if err := Authorize(ctx, db, actor, PermLessonManageCollaborators, Resource{ResourceLesson, lessonID}); err != nil {
+        return nil, err
+    }
+
+    // 1. Cari user yang diundang (harus bisa mengelola lesson: teacher / admin)
+    var inviteeID int
+    var inviteeRole string
+    err := db.QueryRow(ctx,
+        "SELECT id, role FROM users WHERE LOWER(email) = LOWER($1)", req.Email).Scan(&inviteeID, &inviteeRole)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("user tidak ditemukan")
+        }
+        return nil, errors.New("gagal mencari user: " + err.Error())
+    }
+
+    if inviteeID == actor.UserID {
+        return nil, errors.New("tidak bisa mengundang diri sendiri")
+    }
+    if !HasPermission(inviteeRole, PermLessonEdit) {
+        return nil, errors.New("collaborator harus teacher atau admin")
+    }
+
+    // 2. Simpan undangan (collaborator yang sudah menerima tidak ditimpa)
+    _, err = db.Exec(ctx, `
+        INSERT INTO lesson_collaborators (lesson_id, user_id, role, invited_by)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (lesson_id, user_id) DO UPDATE SET
+            role = EXCLUDED.role,
+            invited_by = EXCLUDED.invited_by,
+            invited_at = NOW()
+        WHERE lesson_collaborators.accepted_at IS NULL`,
+        lessonID, inviteeID, req.Role, actor.UserID)
+
+    if err != nil {
+        return nil, errors.New("gagal menyimpan undangan: " + err.Error())
+    }
+
+    collaborator, err := scanCollaborator(db.QueryRow(ctx, `
+        SELECT `+collaboratorColumns+`
+        FROM lesson_collaborators lc
+        JOIN users u ON lc.user_id = u.id
+        WHERE lc.lesson_id = $1 AND lc.user_id = $2`,
+        lessonID, inviteeID))
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil collaborator: " + err.Error())
+    }
+
+    if collaborator.AcceptedAt != nil {
+        return nil, errors.New("user sudah menjadi collaborator lesson ini")
+    }
+
+    return collaborator, nil
+}
+
+// GetCollaborators mengambil semua collaborator lesson termasuk undangan pending
+// (butuh lesson:view)
+func GetCollaborators(ctx context.Context, db *pgxpool.Pool, actor Actor, lessonID int) ([]models.LessonCollaborator, error) {
+    if err := Authorize(ctx, db, actor, PermLessonView, Resource{ResourceLesson, lessonID}); err != nil {
+        return nil, err
+    }
+
+    rows, err := db.Query(ctx, `
+        SELECT `+collaboratorColumns+`
+        FROM lesson_collaborators lc
+        JOIN users u ON lc.user_id = u.id
+        WHERE lc.lesson_id = $1
+        ORDER BY lc.accepted_at IS NULL, lc.invited_at ASC`,
+        lessonID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil collaborator: " + err.Error())
+    }
+    defer rows.Close()
+
+    collaborators := []models.LessonCollaborator{}
+    for rows.Next() {
+        collaborator, err := scanCollaborator(rows)
+        if err != nil {
+            return nil, errors.New("gagal scan collaborator: " + err.Error())
+        }
+        collaborators = append(collaborators, *collaborator)
+    }
+
+    return collaborators, nil
+}
+
+// GetMyCollaboratorInvites mengambil undangan collaborator yang belum diterima user
+func GetMyCollaboratorInvites(ctx context.Context, db *pgxpool.Pool, userID int) ([]models.CollaboratorInvite, error) {
+    rows, err := db.Query(ctx, `
+        SELECT lc.lesson_id, l.title, lc.role, u.full_name, lc.invited_at
+        FROM lesson_collaborators lc
+        JOIN lessons l ON lc.lesson_id = l.id
+        LEFT JOIN users u ON lc.invited_by = u.id
+        WHERE lc.user_id = $1 AND lc.accepted_at IS NULL
+        ORDER BY lc.invited_at DESC`,
+        userID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil undangan: " + err.Error())
+    }
+    defer rows.Close()
+
+    invites := []models.CollaboratorInvite{}
+    for rows.Next() {
+        var invite models.CollaboratorInvite
+        err := rows.Scan(&invite.LessonID, &invite.LessonTitle, &invite.Role, &invite.InvitedByName, &invite.InvitedAt)
+        if err != nil {
+            return nil, errors.New("gagal scan undangan: " + err.Error())
+        }
+        invites = append(invites, invite)
+    }
+
+    return invites, nil
+}
+
+// AcceptCollaboratorInvite menerima undangan collaborator untuk lesson
+func AcceptCollaboratorInvite(ctx context.Context, db *pgxpool.Pool, userID int, lessonID int) (*models.LessonCollaborator, error) {
+    tag, err := db.Exec(ctx, `
+        UPDATE lesson_collaborators
+        SET accepted_at = NOW()
+        WHERE lesson_id = $1 AND user_id = $2 AND accepted_at IS NULL`,
+        lessonID, userID)
+
+    if err != nil {
+        return nil, errors.New("gagal menerima undangan: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return nil, errors.New("undangan tidak ditemukan")
+    }
+
+    collaborator, err := scanCollaborator(db.QueryRow(ctx, `
+        SELECT `+collaboratorColumns+`
+        FROM lesson_collaborators lc
+        JOIN users u ON lc.user_id = u.id
+        WHERE lc.lesson_id = $1 AND lc.user_id = $2`,
+        lessonID, userID))
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil collaborator: " + err.Error())
+    }
+
+    return collaborator, nil
+}
+
+// RemoveCollaborator menghapus collaborator / membatalkan undangan
+// Owner (lesson:manage_collaborators) bisa menghapus siapa saja,
+// collaborator lain hanya bisa menghapus dirinya sendiri (keluar / menolak undangan)
+func RemoveCollaborator(ctx context.Context, db *pgxpool.Pool, actor Actor, lessonID int, userID int) error {
+    if userID != actor.UserID {
+        if err := Authorize(ctx, db, actor, PermLessonManageCollaborators, Resource{ResourceLesson, lessonID}); err != nil {
+            return err
+        }
+    }
+
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Lock lesson agar pengecekan "minimal satu owner" tidak balapan
+    var primaryOwnerID int
+    err = tx.QueryRow(ctx,
+        "SELECT teacher_id FROM lessons WHERE id = $1 FOR UPDATE", lessonID).Scan(&primaryOwnerID)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return errors.New("lesson tidak ditemukan")
+        }
+        return errors.New("gagal cek lesson: " + err.Error())
+    }
+
+    // 2. Hapus collaborator
+    var role string
+    var accepted bool
+    err = tx.QueryRow(ctx, `
+        DELETE FROM lesson_collaborators
+        WHERE lesson_id = $1 AND user_id = $2
+        RETURNING role, accepted_at IS NOT NULL`,
+        lessonID, userID).Scan(&role, &accepted)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return errors.New("collaborator tidak ditemukan")
+        }
+        return errors.New("gagal menghapus collaborator: " + err.Error())
+    }
+
+    // 3. Pastikan masih ada owner, lalu pindahkan teacher_id jika owner utama keluar
+    if role == models.CollaboratorOwner && accepted {
+        var nextOwnerID int
+        err = tx.QueryRow(ctx, `
+            SELECT user_id FROM lesson_collaborators
+            WHERE lesson_id = $1 AND role = $2 AND accepted_at IS NOT NULL
+            ORDER BY accepted_at ASC
+            LIMIT 1`,
+            lessonID, models.CollaboratorOwner).Scan(&nextOwnerID)
+
+        if err != nil {
+            if err == pgx.ErrNoRows {
+                return errors.New("lesson harus memiliki minimal satu owner")
+            }
+            return errors.New("gagal cek owner: " + err.Error())
+        }
+
+        if userID == primaryOwnerID {
+            _, err = tx.Exec(ctx,
+                "UPDATE lessons SET teacher_id = $1, updated_at = NOW() WHERE id = $2",
+                nextOwnerID, lessonID)
+            if err != nil {
+                return errors.New("gagal memindahkan owner lesson: " + err.Error())
+            }
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return errors.New("gagal menghapus collaborator: " + err.Error())
+    }
+
+    return nil
+}
@@ -7,6 +7,7 @@ import (
 	"primmfy_db/models"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 
@@ -15,27 +16,27 @@ import (
 // ═══════════════════════════════════════════════════════════
 
 // CreateCourse membuat course baru dalam lesson
-func CreateCourse(db *pgx.Conn, teacherID int, req models.CreateCourseRequest) (*models.Course, error) {
-    // 1. Cek apakah lesson exist dan teacher adalah owner
-    var lessonTeacherID int
-    err := db.QueryRow(context.Background(),
-        "SELECT teacher_id FROM lessons WHERE id = $1 AND is_active = true",
-        req.LessonID).Scan(&lessonTeacherID)
+func CreateCourse(ctx context.Context, db *pgxpool.Pool, actor Actor, req models.CreateCourseRequest) (*models.Course, error) {
+    // 1. Cek akses (lesson:edit) dan lesson masih aktif
+    if err := Authorize(ctx, db, actor, PermLessonEdit, Resource{ResourceLesson, req.LessonID}); err != nil {
+        return nil, err
+    }
+
+    var isActive bool
+    err := db.QueryRow(ctx,
+        "SELECT is_active FROM lessons WHERE id = $1", req.LessonID).Scan(&isActive)
 
     if err != nil {
-        if err == pgx.ErrNoRows {
-            return nil, errors.New("lesson tidak ditemukan atau tidak aktif")
-        }
         return nil, errors.New("gagal mengecek lesson: " + err.Error())
     }
 
-    if lessonTeacherID != teacherID {
-        return nil, errors.New("anda tidak memiliki akses untuk membuat course di lesson ini")
+    if !isActive {
+        return nil, errors.New("lesson tidak ditemukan atau tidak aktif")
     }
 
     // 2. Insert course baru
     var course models.Course
-    err = db.QueryRow(context.Background(), `
+    err = db.QueryRow(ctx, `
         INSERT INTO courses (lesson_id, title, description, order_index, coin_reward, is_active)
         VALUES ($1, $2, $3, $4, $5, true)
         RETURNING id, lesson_id, title, description, order_index, coin_reward, is_active, created_at, updated_at`,
@@ -52,9 +53,9 @@ func CreateCourse(db *pgx.Conn, teacherID int, req models.CreateCourseRequest) (
 }
 
 // GetCourseByID mengambil detail course by ID
-func GetCourseByID(db *pgx.Conn, courseID int) (*models.Course, error) {
+func GetCourseByID(ctx context.Context, db *pgxpool.Pool, courseID int) (*models.Course, error) {
     var course models.Course
-    err := db.QueryRow(context.Background(), `
+    err := db.QueryRow(ctx, `
         SELECT id, lesson_id, title, description, order_index, coin_reward, 
                is_active, created_at, updated_at
         FROM courses
@@ -75,8 +76,8 @@ func GetCourseByID(db *pgx.Conn, courseID int) (*models.Course, error) {
 }
 
 // GetCoursesByLesson mengambil semua course dalam lesson
-func GetCoursesByLesson(db *pgx.Conn, lessonID int) ([]models.Course, error) {
-    rows, err := db.Query(context.Background(), `
+func GetCoursesByLesson(ctx context.Context, db *pgxpool.Pool, lessonID int) ([]models.Course, error) {
+    rows, err := db.Query(ctx, `
         SELECT id, lesson_id, title, description, order_index, coin_reward, 
                is_active, created_at, updated_at
         FROM courses
@@ -107,25 +108,10 @@ func GetCoursesByLesson(db *pgx.Conn, lessonID int) ([]models.Course, error) {
 }
 
 // UpdateCourse mengupdate course existing
-func UpdateCourse(db *pgx.Conn, courseID int, teacherID int, req models.UpdateCourseRequest) (*models.Course, error) {
-    // 1. Cek ownership (apakah teacher adalah owner lesson)
-    var lessonTeacherID int
-    err := db.QueryRow(context.Background(), `
-        SELECT l.teacher_id
-        FROM courses c
-        JOIN lessons l ON c.lesson_id = l.id
-        WHERE c.id = $1`,
-        courseID).Scan(&lessonTeacherID)
-
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return nil, errors.New("course tidak ditemukan")
-        }
-        return nil, errors.New("gagal mengecek course: " + err.Error())
-    }
-
-    if lessonTeacherID != teacherID {
-        return nil, errors.New("anda tidak memiliki akses untuk mengupdate course ini")
+func UpdateCourse(ctx context.Context, db *pgxpool.Pool, courseID int, actor Actor, req models.UpdateCourseRequest) (*models.Course, error) {
+    // 1. Cek akses (lesson:edit pada lesson tempat course berada)
+    if err := Authorize(ctx, db, actor, PermLessonEdit, Resource{ResourceCourse, courseID}); err != nil {
+        return nil, err
     }
 
     // 2. Build dynamic update query
@@ -170,7 +156,7 @@ func UpdateCourse(db *pgx.Conn, courseID int, teacherID int, req models.UpdateCo
 
     // 3. Execute update
     var course models.Course
-    err = db.QueryRow(context.Background(), query, args...).Scan(
+    err := db.QueryRow(ctx, query, args...).Scan(
         &course.ID, &course.LessonID, &course.Title, &course.Description,
         &course.OrderIndex, &course.CoinReward, &course.IsActive,
         &course.CreatedAt, &course.UpdatedAt)
@@ -183,29 +169,14 @@ func UpdateCourse(db *pgx.Conn, courseID int, teacherID int, req models.UpdateCo
 }
 
 // DeleteCourse menghapus course (soft delete dengan is_active = false)
-func DeleteCourse(db *pgx.Conn, courseID int, teacherID int) error {
-    // 1. Cek ownership
-    var lessonTeacherID int
-    err := db.QueryRow(context.Background(), `
-        SELECT l.teacher_id
-        FROM courses c
-        JOIN lessons l ON c.lesson_id = l.id
-        WHERE c.id = $1`,
-        courseID).Scan(&lessonTeacherID)
-
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return errors.New("course tidak ditemukan")
-        }
-        return errors.New("gagal mengecek course: " + err.Error())
-    }
-
-    if lessonTeacherID != teacherID {
-        return errors.New("anda tidak memiliki akses untuk menghapus course ini")
+func DeleteCourse(ctx context.Context, db *pgxpool.Pool, courseID int, actor Actor) error {
+    // 1. Cek akses (lesson:edit pada lesson tempat course berada)
+    if err := Authorize(ctx, db, actor, PermLessonEdit, Resource{ResourceCourse, courseID}); err != nil {
+        return err
     }
 
     // 2. Soft delete
-    _, err = db.Exec(context.Background(),
+    _, err := db.Exec(ctx,
         "UPDATE courses SET is_active = false, updated_at = NOW() WHERE id = $1",
         courseID)
 
@@ -217,15 +188,15 @@ func DeleteCourse(db *pgx.Conn, courseID int, teacherID int) error {
 }
 
 // GetCourseWithStages mengambil course beserta semua PRIMM stages-nya
-func GetCourseWithStages(db *pgx.Conn, courseID int) (*models.Course, []models.PRIMMStage, error) {
+func GetCourseWithStages(ctx context.Context, db *pgxpool.Pool, courseID int) (*models.Course, []models.PRIMMStage, error) {
     // 1. Get course details
-    course, err := GetCourseByID(db, courseID)
+    course, err := GetCourseByID(ctx, db, courseID)
     if err != nil {
         return nil, nil, err
     }
 
     // 2. Get all stages in this course (dari primm_stage_service.go)
-    stages, err := GetStagesByCourse(db, courseID)
+    stages, err := GetStagesByCourse(ctx, db, courseID)
     if err != nil {
         return course, []models.PRIMMStage{}, nil // Return empty stages if error
     }
@@ -234,8 +205,8 @@ func GetCourseWithStages(db *pgx.Conn, courseID int) (*models.Course, []models.P
 }
 
 // GetCoursesByLessonWithProgress mengambil courses dengan progress user
-func GetCoursesByLessonWithProgress(db *pgx.Conn, lessonID int, userID int) ([]models.CourseWithProgress, error) {
-    rows, err := db.Query(context.Background(), `
+func GetCoursesByLessonWithProgress(ctx context.Context, db *pgxpool.Pool, lessonID int, userID int) ([]models.CourseWithProgress, error) {
+    rows, err := db.Query(ctx, `
         SELECT 
             c.id, 
             c.lesson_id, 
@@ -247,12 +218,12 @@ func GetCoursesByLessonWithProgress(db *pgx.Conn, lessonID int, userID int) ([]m
             c.created_at, 
             c.updated_at,
             COALESCE(COUNT(DISTINCT ps.id), 0) as total_stages,
-            COALESCE(COUNT(DISTINCT ss.stage_id) FILTER (WHERE ss.is_correct = true), 0) as completed_stages,
+            COALESCE(COUNT(DISTINCT usc.stage_id) FILTER (WHERE usc.is_completed = true), 0) as completed_stages,
             COALESCE(ucc.course_id, 0) > 0 as is_completed
         FROM courses c
         LEFT JOIN primm_stages ps ON c.id = ps.course_id AND ps.is_active = true
-        LEFT JOIN stage_submissions ss ON ps.id = ss.stage_id AND ss.user_id = $1
-        LEFT JOIN user_course_completion ucc ON c.id = ucc.course_id AND ucc.user_id = $1
+        LEFT JOIN user_stage_completions usc ON ps.id = usc.stage_id AND usc.user_id = $1
+        LEFT JOIN user_course_completions ucc ON c.id = ucc.course_id AND ucc.user_id = $1 AND ucc.is_completed = true
         WHERE c.lesson_id = $2 AND c.is_active = true
         GROUP BY c.id, ucc.course_id
         ORDER BY c.order_index ASC
@@ -0,0 +1,582 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/spreadsheet"
+)
+
+// ═══════════════════════════════════════════════════════════
+// TEACHER GRADEBOOK
+// Matrix student × stage per lesson dari user_stage_completions,
+// user_course_completions, stage_attempts & coin ledger
+// Roster = student yang enroll lesson (opsional: hanya member satu kelas)
+// ═══════════════════════════════════════════════════════════
+
+const (
+    gradebookDefaultLimit = 50
+
+    // Export membaca student per batch agar file besar tidak dibangun di memory
+    gradebookExportBatch = 200
+
+    // Student dianggap "stuck" jika belum selesai dan salah satu:
+    // - ada stage yang belum complete setelah stuckAttemptThreshold attempt
+    // - tidak ada aktivitas selama stuckInactiveDays hari
+    stuckAttemptThreshold = 3
+    stuckInactiveDays     = 7
+)
+
+// gradebookSorts memetakan parameter sort ke kolom SQL (whitelist, bukan input user)
+var gradebookSorts = map[string]string{
+    "name":          "full_name",
+    "progress":      "completed_stages",
+    "score":         "total_score",
+    "last_activity": "last_activity_at",
+    "attempts":      "total_attempts",
+}
+
+// GetGradebook mengambil gradebook lesson dengan filter, sorting & pagination
+// Tanpa classroom_id: butuh submission:view_all pada lesson (owner / collaborator)
+// Dengan classroom_id: cukup teacher kelas, lesson harus sudah di-assign ke kelas
+func GetGradebook(ctx context.Context, db *pgxpool.Pool, actor Actor, lessonID int, query models.GradebookQuery) (*models.Gradebook, error) {
+    if err := authorizeGradebook(ctx, db, actor, lessonID, query.ClassroomID); err != nil {
+        return nil, err
+    }
+
+    gradebook, err := loadGradebookColumns(ctx, db, lessonID, query.CourseID)
+    if err != nil {
+        return nil, err
+    }
+
+    if query.Page < 1 {
+        query.Page = 1
+    }
+    if query.Limit < 1 {
+        query.Limit = gradebookDefaultLimit
+    }
+    gradebook.Page = query.Page
+    gradebook.Limit = query.Limit
+    if query.ClassroomID != 0 {
+        gradebook.ClassroomID = &query.ClassroomID
+    }
+
+    students, total, err := loadGradebookRows(ctx, db, gradebook, query, (query.Page-1)*query.Limit, query.Limit)
+    if err != nil {
+        return nil, err
+    }
+    gradebook.Students = students
+    gradebook.Total = total
+
+    return gradebook, nil
+}
+
+// GetTeacherDashboard mengambil ringkasan progress semua lesson & kelas teacher
+func GetTeacherDashboard(ctx context.Context, db *pgxpool.Pool, teacherID int) (*models.TeacherDashboard, error) {
+    rows, err := db.Query(ctx, `
+        WITH my_lessons AS (
+            SELECT id, title, is_active, created_at FROM lessons
+            WHERE teacher_id = $1
+               OR id IN (
+                   SELECT lesson_id FROM lesson_collaborators
+                   WHERE user_id = $1 AND accepted_at IS NOT NULL
+               )
+        ),
+        lesson_stages AS (
+            SELECT c.lesson_id, ps.id AS stage_id
+            FROM primm_stages ps
+            JOIN courses c ON ps.course_id = c.id
+            WHERE c.lesson_id IN (SELECT id FROM my_lessons)
+              AND c.is_active = true AND ps.is_active = true
+        ),
+        stage_totals AS (
+            SELECT lesson_id, COUNT(*) AS total FROM lesson_stages GROUP BY lesson_id
+        ),
+        attempts AS (
+            SELECT user_id, stage_id, COUNT(*) AS attempts
+            FROM stage_attempts
+            WHERE stage_id IN (SELECT stage_id FROM lesson_stages)
+            GROUP BY user_id, stage_id
+        ),
+        student_stats AS (
+            SELECT ul.lesson_id, ul.user_id,
+                   COUNT(usc.id) FILTER (WHERE usc.is_completed = true) AS completed,
+                   AVG(usc.score)::float8 AS avg_score,
+                   MAX(usc.updated_at) AS last_activity_at,
+                   COUNT(*) FILTER (
+                       WHERE COALESCE(usc.is_completed, false) = false AND COALESCE(a.attempts, 0) >= $2
+                   ) AS struggling
+            FROM user_lessons ul
+            JOIN users u ON ul.user_id = u.id AND u.role = 'student'
+            LEFT JOIN lesson_stages ls ON ls.lesson_id = ul.lesson_id
+            LEFT JOIN user_stage_completions usc ON usc.user_id = ul.user_id AND usc.stage_id = ls.stage_id
+            LEFT JOIN attempts a ON a.user_id = ul.user_id AND a.stage_id = ls.stage_id
+            WHERE ul.lesson_id IN (SELECT id FROM my_lessons)
+            GROUP BY ul.lesson_id, ul.user_id
+        )
+        SELECT ml.id, ml.title, COALESCE(ml.is_active, false), COALESCE(st.total, 0),
+               COUNT(ss.user_id),
+               COUNT(ss.user_id) FILTER (WHERE st.total > 0 AND ss.completed >= st.total),
+               COUNT(ss.user_id) FILTER (
+                   WHERE ss.completed < COALESCE(st.total, 0)
+                     AND (ss.struggling > 0 OR ss.last_activity_at < NOW() - make_interval(days => $3))
+               ),
+               COALESCE(AVG(ss.completed * 100.0 / NULLIF(st.total, 0)), 0)::float8,
+               COALESCE(AVG(ss.avg_score), 0)::float8,
+               MAX(ss.last_activity_at)
+        FROM my_lessons ml
+        LEFT JOIN stage_totals st ON st.lesson_id = ml.id
+        LEFT JOIN student_stats ss ON ss.lesson_id = ml.id
+        GROUP BY ml.id, ml.title, ml.is_active, ml.created_at, st.total
+        ORDER BY ml.created_at DESC`,
+        teacherID, stuckAttemptThreshold, stuckInactiveDays)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil statistik lesson: " + err.Error())
+    }
+
+    dashboard := &models.TeacherDashboard{Lessons: []models.LessonAnalytics{}}
+    for rows.Next() {
+        var lesson models.LessonAnalytics
+        err := rows.Scan(
+            &lesson.LessonID, &lesson.Title, &lesson.IsActive, &lesson.TotalStages,
+            &lesson.EnrolledStudents, &lesson.CompletedStudents, &lesson.StuckStudents,
+            &lesson.AverageProgress, &lesson.AverageScore, &lesson.LastActivityAt)
+        if err != nil {
+            rows.Close()
+            return nil, errors.New("gagal scan statistik lesson: " + err.Error())
+        }
+        dashboard.Lessons = append(dashboard.Lessons, lesson)
+    }
+    rows.Close()
+
+    classrooms, err := GetClassroomsByTeacher(ctx, db, teacherID)
+    if err != nil {
+        return nil, err
+    }
+    dashboard.Classrooms = classrooms
+
+    return dashboard, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// GRADEBOOK EXPORT (CSV / XLSX)
+// ═══════════════════════════════════════════════════════════
+
+// PrepareGradebookExport mengecek akses & mengambil kolom gradebook
+// Dipanggil sebelum response dimulai, sehingga error masih bisa dikirim sebagai JSON
+func PrepareGradebookExport(ctx context.Context, db *pgxpool.Pool, actor Actor, lessonID int, query models.GradebookQuery) (*models.Gradebook, error) {
+    if err := authorizeGradebook(ctx, db, actor, lessonID, query.ClassroomID); err != nil {
+        return nil, err
+    }
+    return loadGradebookColumns(ctx, db, lessonID, query.CourseID)
+}
+
+// WriteGradebook menulis gradebook ke spreadsheet: satu baris per student,
+// kolom per course & stage, lalu total. Student dibaca & di-flush per batch
+// (filter & sorting sama dengan GetGradebook, pagination diabaikan)
+// Semua batch dibaca dalam satu transaction REPEATABLE READ (snapshot yang sama),
+// sehingga student tidak terlewat / dobel saat data berubah di tengah export
+func WriteGradebook(ctx context.Context, db *pgxpool.Pool, gradebook *models.Gradebook, query models.GradebookQuery, w spreadsheet.Writer) error {
+    // 1. Header
+    header := []interface{}{"Nama", "Email", "Status"}
+    for _, course := range gradebook.Courses {
+        header = append(header, course.Title+" (Selesai)")
+        for _, stage := range gradebook.Stages {
+            if stage.CourseID == course.CourseID {
+                header = append(header, fmt.Sprintf("%s - %s (%s)", course.Title, stage.Title, stage.StageType))
+            }
+        }
+    }
+    header = append(header, "Stage Selesai", "Total Stage", "Progress (%)", "Total Score",
+        "Attempts", "Course Selesai", "Coins", "Aktivitas Terakhir")
+
+    if err := w.WriteRow(header...); err != nil {
+        return errors.New("gagal menulis gradebook: " + err.Error())
+    }
+
+    // 2. Student per batch
+    tx, err := db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+    if err != nil {
+        return errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    for offset := 0; ; offset += gradebookExportBatch {
+        students, _, err := loadGradebookRows(ctx, tx, gradebook, query, offset, gradebookExportBatch)
+        if err != nil {
+            return err
+        }
+
+        for _, student := range students {
+            if err := w.WriteRow(gradebookRecord(gradebook, student)...); err != nil {
+                return errors.New("gagal menulis gradebook: " + err.Error())
+            }
+        }
+
+        if err := w.Flush(); err != nil {
+            return errors.New("gagal menulis gradebook: " + err.Error())
+        }
+        if len(students) < gradebookExportBatch {
+            break
+        }
+    }
+
+    return w.Close()
+}
+
+// gradebookRecord menyusun satu baris export (urutan kolom sama dengan header WriteGradebook)
+func gradebookRecord(gradebook *models.Gradebook, student models.GradebookRow) []interface{} {
+    record := []interface{}{student.FullName, student.Email, student.Status}
+
+    for i, course := range gradebook.Courses {
+        record = append(record, student.Courses[i].IsCompleted)
+        for j, stage := range gradebook.Stages {
+            if stage.CourseID != course.CourseID {
+                continue
+            }
+            // Stage yang belum dikerjakan dikosongkan (beda dengan score 0)
+            if student.Stages[j].Status == models.GradebookStatusNotStarted {
+                record = append(record, nil)
+            } else {
+                record = append(record, student.Stages[j].Score)
+            }
+        }
+    }
+
+    return append(record,
+        student.CompletedStages, student.TotalStages, math.Round(student.ProgressPercent*10)/10,
+        student.TotalScore, student.TotalAttempts, student.CompletedCourses, student.CoinsEarned,
+        student.LastActivityAt)
+}
+
+// ═══════════════════════════════════════════════════════════
+// HELPER FUNCTIONS
+// ═══════════════════════════════════════════════════════════
+
+// authorizeGradebook mengecek akses gradebook lesson (lihat GetGradebook)
+func authorizeGradebook(ctx context.Context, db *pgxpool.Pool, actor Actor, lessonID int, classroomID int) error {
+    if classroomID == 0 {
+        return Authorize(ctx, db, actor, PermSubmissionViewAll, Resource{ResourceLesson, lessonID})
+    }
+
+    if err := Authorize(ctx, db, actor, PermClassroomManage, Resource{ResourceClassroom, classroomID}); err != nil {
+        return err
+    }
+
+    var assigned bool
+    err := db.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM classroom_lessons WHERE classroom_id = $1 AND lesson_id = $2
+        )`, classroomID, lessonID).Scan(&assigned)
+    if err != nil {
+        return errors.New("gagal mengecek lesson classroom: " + err.Error())
+    }
+    if !assigned {
+        return errors.New("lesson tidak di-assign ke classroom ini")
+    }
+
+    return nil
+}
+
+// loadGradebookColumns mengambil kolom course & stage lesson (opsional satu course saja)
+func loadGradebookColumns(ctx context.Context, db *pgxpool.Pool, lessonID int, courseID int) (*models.Gradebook, error) {
+    gradebook := &models.Gradebook{
+        LessonID: lessonID,
+        Courses:  []models.GradebookCourse{},
+        Stages:   []models.GradebookStage{},
+        Students: []models.GradebookRow{},
+    }
+
+    err := db.QueryRow(ctx, "SELECT title FROM lessons WHERE id = $1", lessonID).Scan(&gradebook.LessonTitle)
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("lesson tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil lesson: " + err.Error())
+    }
+
+    // 1. Course
+    rows, err := db.Query(ctx, `
+        SELECT id, title, order_index, coin_reward
+        FROM courses
+        WHERE lesson_id = $1 AND is_active = true AND ($2 = 0 OR id = $2)
+        ORDER BY order_index ASC`,
+        lessonID, courseID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil course: " + err.Error())
+    }
+    for rows.Next() {
+        var course models.GradebookCourse
+        if err := rows.Scan(&course.CourseID, &course.Title, &course.OrderIndex, &course.CoinReward); err != nil {
+            rows.Close()
+            return nil, errors.New("gagal scan course: " + err.Error())
+        }
+        gradebook.Courses = append(gradebook.Courses, course)
+    }
+    rows.Close()
+
+    if courseID != 0 && len(gradebook.Courses) == 0 {
+        return nil, errors.New("course tidak ditemukan")
+    }
+
+    // 2. Stage (urut course lalu PRIMM order)
+    rows, err = db.Query(ctx, `
+        SELECT ps.id, ps.course_id, ps.stage_type, ps.title, ps.order_index
+        FROM primm_stages ps
+        JOIN courses c ON ps.course_id = c.id
+        WHERE c.lesson_id = $1 AND c.is_active = true AND ps.is_active = true
+          AND ($2 = 0 OR c.id = $2)
+        ORDER BY c.order_index ASC, ps.order_index ASC`,
+        lessonID, courseID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil stage: " + err.Error())
+    }
+    for rows.Next() {
+        var stage models.GradebookStage
+        if err := rows.Scan(&stage.StageID, &stage.CourseID, &stage.StageType, &stage.Title, &stage.OrderIndex); err != nil {
+            rows.Close()
+            return nil, errors.New("gagal scan stage: " + err.Error())
+        }
+        gradebook.Stages = append(gradebook.Stages, stage)
+    }
+    rows.Close()
+
+    return gradebook, nil
+}
+
+// loadGradebookRows mengambil satu halaman student beserta cell stage & course
+// Return total student setelah filter (sebelum LIMIT / OFFSET)
+func loadGradebookRows(ctx context.Context, q querier, gradebook *models.Gradebook, query models.GradebookQuery, offset int, limit int) ([]models.GradebookRow, int, error) {
+    stageIDs := make([]int, len(gradebook.Stages))
+    for i, stage := range gradebook.Stages {
+        stageIDs[i] = stage.StageID
+    }
+    courseIDs := make([]int, len(gradebook.Courses))
+    for i, course := range gradebook.Courses {
+        courseIDs[i] = course.CourseID
+    }
+
+    sortColumn, ok := gradebookSorts[query.Sort]
+    if !ok {
+        sortColumn = gradebookSorts["name"]
+    }
+    order := "ASC"
+    if query.Order == "desc" {
+        order = "DESC"
+    }
+
+    // 1. Statistik per student + filter status, urut & dipotong per halaman
+    rows, err := q.Query(ctx, fmt.Sprintf(`
+        WITH roster AS (
+            SELECT u.id AS user_id, u.full_name, u.email
+            FROM user_lessons ul
+            JOIN users u ON ul.user_id = u.id
+            WHERE ul.lesson_id = $1 AND u.role = 'student'
+              AND ($2 = 0 OR EXISTS (
+                  SELECT 1 FROM classroom_members cm WHERE cm.classroom_id = $2 AND cm.user_id = u.id
+              ))
+              AND ($3 = '' OR u.full_name ILIKE '%%' || $3 || '%%' OR u.email ILIKE '%%' || $3 || '%%')
+        ),
+        attempts AS (
+            SELECT user_id, stage_id, COUNT(*) AS attempts
+            FROM stage_attempts
+            WHERE stage_id = ANY($4::int[]) AND user_id IN (SELECT user_id FROM roster)
+            GROUP BY user_id, stage_id
+        ),
+        stats AS (
+            SELECT r.user_id,
+                   COUNT(usc.id) FILTER (WHERE usc.is_completed = true) AS completed_stages,
+                   COALESCE(SUM(usc.score), 0) AS total_score,
+                   COALESCE(SUM(a.attempts), 0) AS total_attempts,
+                   MAX(usc.updated_at) AS last_activity_at,
+                   COUNT(*) FILTER (
+                       WHERE COALESCE(usc.is_completed, false) = false AND COALESCE(a.attempts, 0) >= $5
+                   ) AS struggling
+            FROM roster r
+            LEFT JOIN UNNEST($4::int[]) AS st(stage_id) ON true
+            LEFT JOIN user_stage_completions usc ON usc.user_id = r.user_id AND usc.stage_id = st.stage_id
+            LEFT JOIN attempts a ON a.user_id = r.user_id AND a.stage_id = st.stage_id
+            GROUP BY r.user_id
+        ),
+        graded AS (
+            SELECT r.user_id, r.full_name, r.email,
+                   s.completed_stages, s.total_score, s.total_attempts, s.last_activity_at,
+                   CASE
+                       WHEN $6 > 0 AND s.completed_stages >= $6 THEN 'completed'
+                       WHEN s.last_activity_at IS NULL THEN 'not_started'
+                       ELSE 'in_progress'
+                   END AS status,
+                   (s.completed_stages < $6 AND (
+                       s.struggling > 0 OR s.last_activity_at < NOW() - make_interval(days => $7)
+                   )) AS is_stuck
+            FROM roster r
+            JOIN stats s ON s.user_id = r.user_id
+        )
+        SELECT user_id, full_name, email, completed_stages, total_score, total_attempts,
+               last_activity_at, status, is_stuck, COUNT(*) OVER ()
+        FROM graded
+        WHERE $8 = '' OR ($8 = 'stuck' AND is_stuck) OR status = $8
+        ORDER BY %s %s NULLS LAST, full_name ASC, user_id ASC
+        LIMIT $9 OFFSET $10`, sortColumn, order),
+        gradebook.LessonID, query.ClassroomID, query.Search, stageIDs,
+        stuckAttemptThreshold, len(stageIDs), stuckInactiveDays, query.Status, limit, offset)
+
+    if err != nil {
+        return nil, 0, errors.New("gagal mengambil gradebook: " + err.Error())
+    }
+
+    students := []models.GradebookRow{}
+    index := make(map[int]int) // user_id -> index di students
+    total := 0
+    for rows.Next() {
+        row := models.GradebookRow{TotalStages: len(stageIDs)}
+        err := rows.Scan(
+            &row.UserID, &row.FullName, &row.Email, &row.CompletedStages, &row.TotalScore,
+            &row.TotalAttempts, &row.LastActivityAt, &row.Status, &row.IsStuck, &total)
+        if err != nil {
+            rows.Close()
+            return nil, 0, errors.New("gagal scan gradebook: " + err.Error())
+        }
+        if row.TotalStages > 0 {
+            row.ProgressPercent = float64(row.CompletedStages) * 100 / float64(row.TotalStages)
+        }
+
+        // Cell default: belum dikerjakan
+        row.Stages = make([]models.GradebookCell, len(stageIDs))
+        for i, stageID := range stageIDs {
+            row.Stages[i] = models.GradebookCell{StageID: stageID, Status: models.GradebookStatusNotStarted}
+        }
+        row.Courses = make([]models.GradebookCourseCell, len(courseIDs))
+        for i, courseID := range courseIDs {
+            row.Courses[i] = models.GradebookCourseCell{CourseID: courseID}
+        }
+
+        index[row.UserID] = len(students)
+        students = append(students, row)
+    }
+    rows.Close()
+
+    if len(students) == 0 {
+        return students, total, nil
+    }
+
+    userIDs := make([]int, len(students))
+    for i, student := range students {
+        userIDs[i] = student.UserID
+    }
+    if err := fillGradebookCells(ctx, q, students, index, userIDs, stageIDs, courseIDs); err != nil {
+        return nil, 0, err
+    }
+
+    return students, total, nil
+}
+
+// fillGradebookCells mengisi cell stage, status course & coins untuk student di halaman ini
+func fillGradebookCells(ctx context.Context, q querier, students []models.GradebookRow, index map[int]int, userIDs []int, stageIDs []int, courseIDs []int) error {
+    stageColumn := make(map[int]int, len(stageIDs))
+    for i, stageID := range stageIDs {
+        stageColumn[stageID] = i
+    }
+    courseColumn := make(map[int]int, len(courseIDs))
+    for i, courseID := range courseIDs {
+        courseColumn[courseID] = i
+    }
+
+    // 1. Cell stage
+    rows, err := q.Query(ctx, `
+        SELECT usc.user_id, usc.stage_id, COALESCE(usc.is_completed, false), COALESCE(usc.score, 0),
+               usc.completed_at, usc.updated_at,
+               COALESCE(a.attempts, 0), COALESCE(a.has_late, false)
+        FROM user_stage_completions usc
+        LEFT JOIN (
+            SELECT user_id, stage_id, COUNT(*) AS attempts, BOOL_OR(is_late) AS has_late
+            FROM stage_attempts
+            WHERE user_id = ANY($1::int[]) AND stage_id = ANY($2::int[])
+            GROUP BY user_id, stage_id
+        ) a ON a.user_id = usc.user_id AND a.stage_id = usc.stage_id
+        WHERE usc.user_id = ANY($1::int[]) AND usc.stage_id = ANY($2::int[])`,
+        userIDs, stageIDs)
+
+    if err != nil {
+        return errors.New("gagal mengambil progress stage: " + err.Error())
+    }
+    for rows.Next() {
+        var userID, stageID int
+        var isCompleted bool
+        var cell models.GradebookCell
+        var updatedAt *time.Time
+        err := rows.Scan(&userID, &stageID, &isCompleted, &cell.Score, &cell.CompletedAt, &updatedAt,
+            &cell.Attempts, &cell.HasLateAttempt)
+        if err != nil {
+            rows.Close()
+            return errors.New("gagal scan progress stage: " + err.Error())
+        }
+
+        cell.StageID = stageID
+        cell.LastActivityAt = updatedAt
+        cell.Status = models.GradebookStatusInProgress
+        if isCompleted {
+            cell.Status = models.GradebookStatusCompleted
+        }
+        students[index[userID]].Stages[stageColumn[stageID]] = cell
+    }
+    rows.Close()
+
+    // 2. Status course
+    rows, err = q.Query(ctx, `
+        SELECT user_id, course_id, COALESCE(is_completed, false), completed_at
+        FROM user_course_completions
+        WHERE user_id = ANY($1::int[]) AND course_id = ANY($2::int[])`,
+        userIDs, courseIDs)
+
+    if err != nil {
+        return errors.New("gagal mengambil progress course: " + err.Error())
+    }
+    for rows.Next() {
+        var userID int
+        var cell models.GradebookCourseCell
+        if err := rows.Scan(&userID, &cell.CourseID, &cell.IsCompleted, &cell.CompletedAt); err != nil {
+            rows.Close()
+            return errors.New("gagal scan progress course: " + err.Error())
+        }
+        student := &students[index[userID]]
+        student.Courses[courseColumn[cell.CourseID]] = cell
+        if cell.IsCompleted {
+            student.CompletedCourses++
+        }
+    }
+    rows.Close()
+
+    // 3. Coins dari stage & course lesson ini (coin ledger)
+    rows, err = q.Query(ctx, `
+        SELECT user_id, COALESCE(SUM(amount), 0)
+        FROM coin_transactions
+        WHERE user_id = ANY($1::int[])
+          AND ((source_type = $2 AND source_id = ANY($3::int[]))
+            OR (source_type = $4 AND source_id = ANY($5::int[])))
+        GROUP BY user_id`,
+        userIDs, CoinSourceStage, stageIDs, CoinSourceCourse, courseIDs)
+
+    if err != nil {
+        return errors.New("gagal mengambil coins: " + err.Error())
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var userID, coins int
+        if err := rows.Scan(&userID, &coins); err != nil {
+            return errors.New("gagal scan coins: " + err.Error())
+        }
+        students[index[userID]].CoinsEarned = coins
+    }
+
+    return nil
+}
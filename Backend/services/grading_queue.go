@@ -0,0 +1,353 @@
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/executor"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// ASYNC GRADING QUEUE
+// ═══════════════════════════════════════════════════════════
+
+// pollInterval adalah interval worker mengecek submission pending
+// (cadangan jika sinyal Enqueue terlewat, misalnya dari instance lain)
+const pollInterval = 5 * time.Second
+
+// defaultGradingLease adalah batas waktu klaim 'running' sebelum submission boleh
+// diambil ulang worker lain (instance yang meng-klaim dianggap mati)
+const defaultGradingLease = 10 * time.Minute
+
+// errSubmissionClaimLost: submission sudah diambil ulang worker lain (lease habis)
+var errSubmissionClaimLost = errors.New("klaim submission sudah diambil worker lain")
+
+// gradingLease membaca lease grading dari environment (GRADING_LEASE, contoh: 10m)
+// Harus lebih lama dari waktu grading terlama (compile + semua test case)
+func gradingLease() time.Duration {
+    if d, err := time.ParseDuration(os.Getenv("GRADING_LEASE")); err == nil && d > 0 {
+        return d
+    }
+    return defaultGradingLease
+}
+
+// GradingQueue menjalankan grading MODIFY & MAKE di worker pool terbatas
+// Antrian disimpan di tabel submissions, jadi tetap aman saat server restart
+type GradingQueue struct {
+    db      *pgxpool.Pool
+    workers int
+    wake    chan struct{}
+    events  *SubmissionBroker
+    cancel  context.CancelFunc
+    wg      sync.WaitGroup
+}
+
+// NewGradingQueue membuat GradingQueue dengan jumlah worker tertentu
+func NewGradingQueue(db *pgxpool.Pool, workers int) *GradingQueue {
+    if workers < 1 {
+        workers = 1
+    }
+    return &GradingQueue{
+        db:      db,
+        workers: workers,
+        wake:    make(chan struct{}, workers),
+        events:  NewSubmissionBroker(),
+    }
+}
+
+// Events mengembalikan broker untuk subscribe perubahan status submission
+func (q *GradingQueue) Events() *SubmissionBroker {
+    return q.events
+}
+
+// Start menjalankan worker pool
+// Submission 'running' milik instance yang mati tidak di-reset di sini (bisa jadi masih
+// dikerjakan instance lain), tapi diambil ulang oleh claimNext setelah lease habis
+func (q *GradingQueue) Start(ctx context.Context) error {
+    ctx, q.cancel = context.WithCancel(ctx)
+    for i := 0; i < q.workers; i++ {
+        q.wg.Add(1)
+        go q.worker(ctx)
+    }
+
+    log.Printf("⚙️  Grading queue berjalan dengan %d worker\n", q.workers)
+    return nil
+}
+
+// Stop menghentikan semua worker dan menunggu sampai selesai
+func (q *GradingQueue) Stop() {
+    if q.cancel != nil {
+        q.cancel()
+    }
+    q.wg.Wait()
+}
+
+// Enqueue menyimpan submission baru dengan status 'pending' lalu membangunkan worker
+// Submission di luar jadwal assignment langsung ditolak (tidak masuk antrian)
+func (q *GradingQueue) Enqueue(ctx context.Context, userID int, stageID int, stageType string, code string) (*models.Submission, error) {
+    if _, err := checkAssignmentWindow(ctx, q.db, userID, stageID, nil); err != nil {
+        return nil, err
+    }
+
+    var submission models.Submission
+    err := q.db.QueryRow(ctx, `
+        INSERT INTO submissions (user_id, stage_id, stage_type, submitted_code, status)
+        SELECT $1, id, stage_type, $3, 'pending'
+        FROM primm_stages
+        WHERE id = $2 AND stage_type = $4
+        RETURNING id, user_id, stage_id, stage_type, submitted_code, status, created_at`,
+        userID, stageID, code, stageType).Scan(
+        &submission.ID, &submission.UserID, &submission.StageID, &submission.StageType,
+        &submission.SubmittedCode, &submission.Status, &submission.CreatedAt)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New(stageType + " stage tidak ditemukan")
+        }
+        return nil, errors.New("gagal menyimpan submission: " + err.Error())
+    }
+
+    // Non-blocking: jika semua worker sibuk, submission tetap di DB sebagai pending
+    select {
+    case q.wake <- struct{}{}:
+    default:
+    }
+
+    return &submission, nil
+}
+
+// worker mengambil submission pending satu per satu sampai context dibatalkan
+func (q *GradingQueue) worker(ctx context.Context) {
+    defer q.wg.Done()
+
+    ticker := time.NewTicker(pollInterval)
+    defer ticker.Stop()
+
+    for {
+        // Proses semua submission pending yang ada
+        for {
+            if ctx.Err() != nil {
+                return
+            }
+            submission, err := q.claimNext(ctx)
+            if err != nil {
+                if ctx.Err() == nil {
+                    log.Printf("⚠️  Grading queue: %v\n", err)
+                }
+                break
+            }
+            if submission == nil {
+                break
+            }
+            q.grade(ctx, submission)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-q.wake:
+        case <-ticker.C:
+        }
+    }
+}
+
+// claimNext mengambil submission pending tertua (atau 'running' yang lease-nya habis)
+// dan menandainya 'running'. started_at menjadi token klaim: hasil grading hanya
+// disimpan jika started_at belum berubah (lihat markSubmissionGraded)
+// FOR UPDATE SKIP LOCKED memastikan satu submission tidak diambil dua worker
+func (q *GradingQueue) claimNext(ctx context.Context) (*models.Submission, error) {
+    var submission models.Submission
+    err := q.db.QueryRow(ctx, `
+        UPDATE submissions
+        SET status = 'running', started_at = NOW(), updated_at = NOW()
+        WHERE id = (
+            SELECT id FROM submissions
+            WHERE status = 'pending'
+               OR (status = 'running' AND started_at < NOW() - make_interval(secs => $1))
+            ORDER BY id
+            FOR UPDATE SKIP LOCKED
+            LIMIT 1
+        )
+        RETURNING id, user_id, stage_id, stage_type, submitted_code, status, created_at, started_at`,
+        gradingLease().Seconds()).Scan(
+        &submission.ID, &submission.UserID, &submission.StageID, &submission.StageType,
+        &submission.SubmittedCode, &submission.Status, &submission.CreatedAt, &submission.StartedAt)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, nil
+        }
+        return nil, errors.New("gagal mengambil submission: " + err.Error())
+    }
+
+    return &submission, nil
+}
+
+// grade menjalankan grading untuk satu submission lalu menyimpan hasilnya
+// Status 'graded' ditulis di transaction yang sama dengan hasil grading (attempt,
+// completion, reward), jadi submission tidak pernah di-grade dua kali
+func (q *GradingQueue) grade(ctx context.Context, submission *models.Submission) {
+    q.events.Publish(SubmissionEvent{
+        Type:         SubmissionEventStatus,
+        SubmissionID: submission.ID,
+        Status:       models.SubmissionStatusRunning,
+    })
+
+    // Hasil setiap test case langsung diteruskan ke subscriber
+    ctx = executor.WithProgress(ctx, func(tc models.TestCase, result executor.TestResult) {
+        feedback := result.Feedback(tc)
+        q.events.Publish(SubmissionEvent{
+            Type:         SubmissionEventTestResult,
+            SubmissionID: submission.ID,
+            Status:       models.SubmissionStatusRunning,
+            TestResult:   &feedback,
+        })
+    })
+
+    var err error
+    switch submission.StageType {
+    case "modify", "make":
+        _, err = submitStageAnswer(ctx, q.db, submission.UserID, StageAnswer{
+            StageID:     submission.StageID,
+            StageType:   submission.StageType,
+            Code:        submission.SubmittedCode,
+            SubmittedAt: &submission.CreatedAt,
+            Finalize: func(ctx context.Context, tx pgx.Tx, response *models.SubmitStageResponse) error {
+                return markSubmissionGraded(ctx, tx, submission, response)
+            },
+        })
+    default:
+        err = errors.New("tipe stage tidak valid untuk grading queue")
+    }
+
+    if err == nil {
+        q.publishFinal(submission)
+        return
+    }
+
+    // Lease habis dan submission sudah diambil worker lain: hasil worker ini dibuang
+    if errors.Is(err, errSubmissionClaimLost) {
+        log.Printf("⚠️  Submission %d sudah diambil ulang worker lain, hasil grading dibuang\n", submission.ID)
+        return
+    }
+
+    // Server sedang shutdown: grading belum tersimpan, kembalikan ke 'pending'
+    if ctx.Err() != nil {
+        q.releaseClaim(submission)
+        return
+    }
+
+    tag, dbErr := q.db.Exec(context.Background(), `
+        UPDATE submissions
+        SET status = 'failed', error_message = $1, graded_at = NOW(), updated_at = NOW()
+        WHERE id = $2 AND status = 'running' AND started_at = $3`,
+        err.Error(), submission.ID, submission.StartedAt)
+    if dbErr != nil {
+        log.Printf("⚠️  Gagal menyimpan status submission %d: %v\n", submission.ID, dbErr)
+    }
+    if dbErr == nil && tag.RowsAffected() == 0 {
+        return
+    }
+    q.publishFinal(submission)
+}
+
+// markSubmissionGraded menandai submission 'graded' di dalam transaction grading
+// Gagal (errSubmissionClaimLost) jika klaim sudah diambil worker lain, sehingga
+// seluruh transaction (attempt, completion, reward) ikut di-rollback
+func markSubmissionGraded(ctx context.Context, tx pgx.Tx, submission *models.Submission, response *models.SubmitStageResponse) error {
+    resultJSON, err := json.Marshal(response)
+    if err != nil {
+        return errors.New("gagal serialize hasil submission: " + err.Error())
+    }
+
+    tag, err := tx.Exec(ctx, `
+        UPDATE submissions
+        SET status = 'graded', is_correct = $1, score = $2, result = $3,
+            graded_at = NOW(), updated_at = NOW()
+        WHERE id = $4 AND status = 'running' AND started_at = $5`,
+        response.IsCorrect, response.Score, resultJSON, submission.ID, submission.StartedAt)
+    if err != nil {
+        return errors.New("gagal menyimpan hasil submission: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return errSubmissionClaimLost
+    }
+
+    return nil
+}
+
+// releaseClaim mengembalikan submission ke 'pending' jika klaim masih milik worker ini
+// (grading dibatalkan sebelum transaction commit, misalnya saat server shutdown)
+func (q *GradingQueue) releaseClaim(submission *models.Submission) {
+    _, err := q.db.Exec(context.Background(), `
+        UPDATE submissions
+        SET status = 'pending', started_at = NULL, updated_at = NOW()
+        WHERE id = $1 AND status = 'running' AND started_at = $2`,
+        submission.ID, submission.StartedAt)
+    if err != nil {
+        log.Printf("⚠️  Gagal mengembalikan submission %d ke antrian: %v\n", submission.ID, err)
+    }
+}
+
+// publishFinal mengirim status akhir submission (dibaca ulang dari DB) ke subscriber
+func (q *GradingQueue) publishFinal(submission *models.Submission) {
+    final, err := GetSubmission(context.Background(), q.db, submission.ID, submission.UserID)
+    if err != nil {
+        log.Printf("⚠️  Gagal membaca submission %d: %v\n", submission.ID, err)
+        return
+    }
+
+    q.events.Publish(SubmissionEvent{
+        Type:         SubmissionEventStatus,
+        SubmissionID: final.ID,
+        Status:       final.Status,
+        Submission:   final,
+    })
+}
+
+// ═══════════════════════════════════════════════════════════
+// SUBMISSION STATUS
+// ═══════════════════════════════════════════════════════════
+
+// GetSubmission mengambil status & hasil submission milik user
+func GetSubmission(ctx context.Context, db *pgxpool.Pool, submissionID int, userID int) (*models.Submission, error) {
+    var submission models.Submission
+    var resultJSON []byte
+
+    err := db.QueryRow(ctx, `
+        SELECT id, user_id, stage_id, stage_type, submitted_code, status,
+               is_correct, score, result, error_message,
+               created_at, started_at, graded_at
+        FROM submissions
+        WHERE id = $1`, submissionID).Scan(
+        &submission.ID, &submission.UserID, &submission.StageID, &submission.StageType,
+        &submission.SubmittedCode, &submission.Status,
+        &submission.IsCorrect, &submission.Score, &resultJSON, &submission.ErrorMessage,
+        &submission.CreatedAt, &submission.StartedAt, &submission.GradedAt)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("submission tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil submission: " + err.Error())
+    }
+
+    // Student hanya boleh melihat submission miliknya sendiri
+    if submission.UserID != userID {
+        return nil, errors.New("submission tidak ditemukan")
+    }
+
+    if resultJSON != nil {
+        json.Unmarshal(resultJSON, &submission.Result)
+    }
+
+    return &submission, nil
+}
@@ -10,6 +10,7 @@ import (
 	"primmfy_db/models"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // ═══════════════════════════════════════════════════════════
@@ -55,10 +56,16 @@ type UserLessonEnrollment struct {
 // ═══════════════════════════════════════════════════════════
 
 // CreateLesson membuat lesson baru (teacher only)
-func CreateLesson(db *pgx.Conn, teacherID int, req models.CreateLessonRequest) (*models.Lesson, error) {
-    var lesson models.Lesson
+// Pembuat lesson otomatis menjadi collaborator dengan role owner
+func CreateLesson(ctx context.Context, db *pgxpool.Pool, teacherID int, req models.CreateLessonRequest) (*models.Lesson, error) {
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
 
-    err := db.QueryRow(context.Background(), `
+    var lesson models.Lesson
+    err = tx.QueryRow(ctx, `
         INSERT INTO lessons (teacher_id, title, description, category, difficulty, thumbnail_url)
         VALUES ($1, $2, $3, $4, $5, $6)
         RETURNING id, teacher_id, title, description, category, difficulty, 
@@ -72,12 +79,25 @@ func CreateLesson(db *pgx.Conn, teacherID int, req models.CreateLessonRequest) (
         return nil, errors.New("gagal membuat lesson: " + err.Error())
     }
 
+    _, err = tx.Exec(ctx, `
+        INSERT INTO lesson_collaborators (lesson_id, user_id, role, accepted_at)
+        VALUES ($1, $2, $3, NOW())`,
+        lesson.ID, teacherID, models.CollaboratorOwner)
+
+    if err != nil {
+        return nil, errors.New("gagal menyimpan owner lesson: " + err.Error())
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal membuat lesson: " + err.Error())
+    }
+
     return &lesson, nil
 }
 
 // GetAllLessons mengambil semua lesson yang aktif
-func GetAllLessons(db *pgx.Conn) ([]models.LessonWithTeacher, error) {
-    rows, err := db.Query(context.Background(), `
+func GetAllLessons(ctx context.Context, db *pgxpool.Pool) ([]models.LessonWithTeacher, error) {
+    rows, err := db.Query(ctx, `
         SELECT l.id, l.teacher_id, l.title, l.description, l.category, 
                l.difficulty, l.thumbnail_url, l.is_active, l.created_at, l.updated_at,
                u.full_name as teacher_name
@@ -110,10 +130,10 @@ func GetAllLessons(db *pgx.Conn) ([]models.LessonWithTeacher, error) {
 }
 
 // GetLessonByID mengambil detail lesson berdasarkan ID
-func GetLessonByID(db *pgx.Conn, lessonID int) (*models.LessonWithTeacher, error) {
+func GetLessonByID(ctx context.Context, db *pgxpool.Pool, lessonID int) (*models.LessonWithTeacher, error) {
     var lesson models.LessonWithTeacher
 
-    err := db.QueryRow(context.Background(), `
+    err := db.QueryRow(ctx, `
         SELECT l.id, l.teacher_id, l.title, l.description, l.category, 
                l.difficulty, l.thumbnail_url, l.is_active, l.created_at, l.updated_at,
                u.full_name as teacher_name
@@ -135,13 +155,18 @@ func GetLessonByID(db *pgx.Conn, lessonID int) (*models.LessonWithTeacher, error
     return &lesson, nil
 }
 
-// GetLessonsByTeacher mengambil semua lesson milik teacher tertentu
-func GetLessonsByTeacher(db *pgx.Conn, teacherID int) ([]models.Lesson, error) {
-    rows, err := db.Query(context.Background(), `
+// GetLessonsByTeacher mengambil semua lesson yang dikelola teacher
+// (lesson miliknya + lesson tempat dia menjadi collaborator)
+func GetLessonsByTeacher(ctx context.Context, db *pgxpool.Pool, teacherID int) ([]models.Lesson, error) {
+    rows, err := db.Query(ctx, `
         SELECT id, teacher_id, title, description, category, difficulty, 
                thumbnail_url, is_active, created_at, updated_at
         FROM lessons
         WHERE teacher_id = $1
+           OR id IN (
+               SELECT lesson_id FROM lesson_collaborators
+               WHERE user_id = $1 AND accepted_at IS NOT NULL
+           )
         ORDER BY created_at DESC`, teacherID)
 
     if err != nil {
@@ -166,22 +191,10 @@ func GetLessonsByTeacher(db *pgx.Conn, teacherID int) ([]models.Lesson, error) {
     return lessons, nil
 }
 
-// UpdateLesson mengupdate lesson (teacher only, hanya lesson miliknya)
-func UpdateLesson(db *pgx.Conn, lessonID int, teacherID int, req models.UpdateLessonRequest) (*models.Lesson, error) {
-    // Cek apakah lesson milik teacher ini
-    var ownerID int
-    err := db.QueryRow(context.Background(),
-        "SELECT teacher_id FROM lessons WHERE id = $1", lessonID).Scan(&ownerID)
-
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return nil, errors.New("lesson tidak ditemukan")
-        }
-        return nil, errors.New("gagal cek ownership: " + err.Error())
-    }
-
-    if ownerID != teacherID {
-        return nil, errors.New("anda tidak memiliki akses untuk mengupdate lesson ini")
+// UpdateLesson mengupdate lesson (butuh lesson:edit pada lesson ini)
+func UpdateLesson(ctx context.Context, db *pgxpool.Pool, lessonID int, actor Actor, req models.UpdateLessonRequest) (*models.Lesson, error) {
+    if err := Authorize(ctx, db, actor, PermLessonEdit, Resource{ResourceLesson, lessonID}); err != nil {
+        return nil, err
     }
 
     // Build dynamic update query
@@ -234,7 +247,7 @@ func UpdateLesson(db *pgx.Conn, lessonID int, teacherID int, req models.UpdateLe
         argPos)
 
     var lesson models.Lesson
-    err = db.QueryRow(context.Background(), query, args...).Scan(
+    err := db.QueryRow(ctx, query, args...).Scan(
         &lesson.ID, &lesson.TeacherID, &lesson.Title, &lesson.Description,
         &lesson.Category, &lesson.Difficulty, &lesson.ThumbnailURL,
         &lesson.IsActive, &lesson.CreatedAt, &lesson.UpdatedAt)
@@ -247,25 +260,14 @@ func UpdateLesson(db *pgx.Conn, lessonID int, teacherID int, req models.UpdateLe
 }
 
 // DeleteLesson menghapus lesson (soft delete: set is_active = false)
-func DeleteLesson(db *pgx.Conn, lessonID int, teacherID int) error {
-    // Cek ownership
-    var ownerID int
-    err := db.QueryRow(context.Background(),
-        "SELECT teacher_id FROM lessons WHERE id = $1", lessonID).Scan(&ownerID)
-
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return errors.New("lesson tidak ditemukan")
-        }
-        return errors.New("gagal cek ownership: " + err.Error())
-    }
-
-    if ownerID != teacherID {
-        return errors.New("anda tidak memiliki akses untuk menghapus lesson ini")
+// Butuh lesson:delete pada lesson ini
+func DeleteLesson(ctx context.Context, db *pgxpool.Pool, lessonID int, actor Actor) error {
+    if err := Authorize(ctx, db, actor, PermLessonDelete, Resource{ResourceLesson, lessonID}); err != nil {
+        return err
     }
 
     // Soft delete
-    _, err = db.Exec(context.Background(),
+    _, err := db.Exec(ctx,
         "UPDATE lessons SET is_active = false, updated_at = NOW() WHERE id = $1", lessonID)
 
     if err != nil {
@@ -280,8 +282,8 @@ func DeleteLesson(db *pgx.Conn, lessonID int, teacherID int) error {
 // ═══════════════════════════════════════════════════════════
 
 // GetUserEnrolledLessons mendapatkan semua lessons yang dienroll user beserta progress
-func GetUserEnrolledLessons(db *pgx.Conn, userID int) ([]EnrolledLessonResponse, error) {
-    rows, err := db.Query(context.Background(), `
+func GetUserEnrolledLessons(ctx context.Context, db *pgxpool.Pool, userID int) ([]EnrolledLessonResponse, error) {
+    rows, err := db.Query(ctx, `
         SELECT 
             l.id, 
             l.teacher_id, 
@@ -302,7 +304,7 @@ func GetUserEnrolledLessons(db *pgx.Conn, userID int) ([]EnrolledLessonResponse,
         JOIN lessons l ON ul.lesson_id = l.id
         JOIN users u ON l.teacher_id = u.id
         LEFT JOIN courses c ON l.id = c.lesson_id AND c.is_active = true
-        LEFT JOIN user_course_completion ucc ON c.id = ucc.course_id AND ucc.user_id = ul.user_id
+        LEFT JOIN user_course_completions ucc ON c.id = ucc.course_id AND ucc.user_id = ul.user_id AND ucc.is_completed = true
         WHERE ul.user_id = $1
         GROUP BY 
             l.id, 
@@ -372,15 +374,15 @@ func GetUserEnrolledLessons(db *pgx.Conn, userID int) ([]EnrolledLessonResponse,
 }
 
 // GetLessonWithCourses mengambil lesson beserta semua courses-nya
-func GetLessonWithCourses(db *pgx.Conn, lessonID int) (*models.LessonWithTeacher, []models.Course, error) {
+func GetLessonWithCourses(ctx context.Context, db *pgxpool.Pool, lessonID int) (*models.LessonWithTeacher, []models.Course, error) {
     // 1. Get lesson details
-    lesson, err := GetLessonByID(db, lessonID)
+    lesson, err := GetLessonByID(ctx, db, lessonID)
     if err != nil {
         return nil, nil, err
     }
 
     // 2. Get all courses in this lesson
-    courses, err := GetCoursesByLesson(db, lessonID)
+    courses, err := GetCoursesByLesson(ctx, db, lessonID)
     if err != nil {
         return lesson, []models.Course{}, nil // Return empty courses if error
     }
@@ -389,8 +391,8 @@ func GetLessonWithCourses(db *pgx.Conn, lessonID int) (*models.LessonWithTeacher
 }
 
 // GetMyEnrolledLessons mengambil semua lesson yang di-enroll oleh user dengan progress
-func GetMyEnrolledLessons(db *pgx.Conn, userID int) ([]models.LessonWithProgress, error) {
-    rows, err := db.Query(context.Background(), `
+func GetMyEnrolledLessons(ctx context.Context, db *pgxpool.Pool, userID int) ([]models.LessonWithProgress, error) {
+    rows, err := db.Query(ctx, `
         SELECT 
             l.id, 
             l.teacher_id, 
@@ -467,9 +469,9 @@ func GetMyEnrolledLessons(db *pgx.Conn, userID int) ([]models.LessonWithProgress
     return lessons, nil
 }
 // CheckEnrollment mengecek apakah user sudah enroll lesson
-func CheckEnrollment(db *pgx.Conn, userID int, lessonID int) (bool, error) {
+func CheckEnrollment(ctx context.Context, db *pgxpool.Pool, userID int, lessonID int) (bool, error) {
     var exists bool
-    err := db.QueryRow(context.Background(),
+    err := db.QueryRow(ctx,
         "SELECT EXISTS(SELECT 1 FROM user_lessons WHERE user_id = $1 AND lesson_id = $2)",
         userID, lessonID).Scan(&exists)
 
@@ -481,10 +483,10 @@ func CheckEnrollment(db *pgx.Conn, userID int, lessonID int) (bool, error) {
 }
 
 // EnrollLesson mendaftarkan user ke lesson
-func EnrollLesson(db *pgx.Conn, userID int, lessonID int) (*UserLessonEnrollment, error) {
+func EnrollLesson(ctx context.Context, db *pgxpool.Pool, userID int, lessonID int) (*UserLessonEnrollment, error) {
     // 1. Check apakah lesson exists dan active
     var isActive bool
-    err := db.QueryRow(context.Background(), `
+    err := db.QueryRow(ctx, `
         SELECT is_active FROM lessons WHERE id = $1
     `, lessonID).Scan(&isActive)
 
@@ -501,7 +503,7 @@ func EnrollLesson(db *pgx.Conn, userID int, lessonID int) (*UserLessonEnrollment
 
     // 2. Check apakah user sudah enroll
     var existingID int
-    err = db.QueryRow(context.Background(), `
+    err = db.QueryRow(ctx, `
         SELECT id FROM user_lessons WHERE user_id = $1 AND lesson_id = $2
     `, userID, lessonID).Scan(&existingID)
 
@@ -511,7 +513,7 @@ func EnrollLesson(db *pgx.Conn, userID int, lessonID int) (*UserLessonEnrollment
 
     // 3. Insert enrollment
     var enrollment UserLessonEnrollment
-    err = db.QueryRow(context.Background(), `
+    err = db.QueryRow(ctx, `
         INSERT INTO user_lessons (user_id, lesson_id)
         VALUES ($1, $2)
         RETURNING id, user_id, lesson_id, enrolled_at, last_accessed_at
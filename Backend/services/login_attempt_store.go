@@ -0,0 +1,198 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ═══════════════════════════════════════════════════════════
+// LOGIN ATTEMPT STORE
+// Penyimpanan counter login gagal untuk LoginLimiter
+// Postgres: konsisten antar restart & antar instance server
+// Memory:   untuk development / single instance
+// ═══════════════════════════════════════════════════════════
+
+// LoginAttempts adalah counter login gagal untuk satu key
+type LoginAttempts struct {
+    Failures         int
+    SinceLastFailure time.Duration
+}
+
+// LoginAttemptStore menyimpan counter login gagal per key (akun / IP)
+// Setiap percobaan login di-reserve (langsung dihitung gagal) sebelum password dicek,
+// lalu di-Release / Reset jika login berhasil
+type LoginAttemptStore interface {
+    // Reserve secara atomic memanggil check dengan counter saat ini (Failures = 0 jika
+    // gagal terakhir sudah lebih lama dari resetAfter), lalu menambah counter jika
+    // check return nil. Error dari check dikembalikan apa adanya tanpa mengubah counter
+    Reserve(ctx context.Context, key string, resetAfter time.Duration, check func(LoginAttempts) error) error
+    // Release mengurangi counter satu (percobaan yang ternyata berhasil)
+    Release(ctx context.Context, key string) error
+    // Reset menghapus counter
+    Reset(ctx context.Context, key string) error
+}
+
+// ═══════════════════════════════════════════════════════════
+// POSTGRES STORE
+// ═══════════════════════════════════════════════════════════
+
+// PostgresLoginAttemptStore menyimpan counter di tabel login_attempts
+type PostgresLoginAttemptStore struct {
+    db *pgxpool.Pool
+}
+
+// NewPostgresLoginAttemptStore membuat store Postgres
+func NewPostgresLoginAttemptStore(db *pgxpool.Pool) *PostgresLoginAttemptStore {
+    return &PostgresLoginAttemptStore{db: db}
+}
+
+// Reserve mengecek & menambah counter dalam satu transaction
+// Baris key dikunci (FOR UPDATE) sehingga percobaan paralel untuk key yang sama
+// dicek satu per satu (selisih waktu dihitung dengan NOW() database)
+func (s *PostgresLoginAttemptStore) Reserve(ctx context.Context, key string, resetAfter time.Duration, check func(LoginAttempts) error) error {
+    tx, err := s.db.Begin(ctx)
+    if err != nil {
+        return errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO login_attempts (key, failures, last_failed_at)
+        VALUES ($1, 0, NOW())
+        ON CONFLICT (key) DO NOTHING`, key)
+
+    if err != nil {
+        return errors.New("gagal mencatat login attempt: " + err.Error())
+    }
+
+    var attempts LoginAttempts
+    var seconds float64
+    err = tx.QueryRow(ctx, `
+        SELECT failures, EXTRACT(EPOCH FROM (NOW() - last_failed_at))::float8
+        FROM login_attempts
+        WHERE key = $1
+        FOR UPDATE`, key).Scan(&attempts.Failures, &seconds)
+
+    if err != nil {
+        return errors.New("gagal cek login attempts: " + err.Error())
+    }
+
+    attempts.SinceLastFailure = time.Duration(seconds * float64(time.Second))
+    if attempts.SinceLastFailure > resetAfter {
+        attempts = LoginAttempts{}
+    }
+    if err := check(attempts); err != nil {
+        return err
+    }
+
+    _, err = tx.Exec(ctx, `
+        UPDATE login_attempts SET failures = $2, last_failed_at = NOW()
+        WHERE key = $1`,
+        key, attempts.Failures+1)
+
+    if err != nil {
+        return errors.New("gagal mencatat login attempt: " + err.Error())
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return errors.New("gagal mencatat login attempt: " + err.Error())
+    }
+    return nil
+}
+
+// Release mengurangi counter di database
+func (s *PostgresLoginAttemptStore) Release(ctx context.Context, key string) error {
+    _, err := s.db.Exec(ctx, `
+        UPDATE login_attempts SET failures = GREATEST(failures - 1, 0)
+        WHERE key = $1`, key)
+
+    if err != nil {
+        return errors.New("gagal update login attempts: " + err.Error())
+    }
+    return nil
+}
+
+// Reset menghapus counter dari database
+func (s *PostgresLoginAttemptStore) Reset(ctx context.Context, key string) error {
+    _, err := s.db.Exec(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+    if err != nil {
+        return errors.New("gagal reset login attempts: " + err.Error())
+    }
+    return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// MEMORY STORE
+// ═══════════════════════════════════════════════════════════
+
+// memoryAttempt adalah counter di MemoryLoginAttemptStore
+type memoryAttempt struct {
+    failures     int
+    lastFailedAt time.Time
+}
+
+// MemoryLoginAttemptStore menyimpan counter di memory (hilang saat restart)
+type MemoryLoginAttemptStore struct {
+    mu       sync.Mutex
+    attempts map[string]*memoryAttempt
+}
+
+// NewMemoryLoginAttemptStore membuat store memory kosong
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+    return &MemoryLoginAttemptStore{attempts: make(map[string]*memoryAttempt)}
+}
+
+// Reserve mengecek & menambah counter di memory (atomic karena memegang mu)
+func (s *MemoryLoginAttemptStore) Reserve(ctx context.Context, key string, resetAfter time.Duration, check func(LoginAttempts) error) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    now := time.Now()
+    s.pruneLocked(now, resetAfter)
+
+    attempt, ok := s.attempts[key]
+    if !ok {
+        attempt = &memoryAttempt{lastFailedAt: now}
+    }
+    if err := check(LoginAttempts{Failures: attempt.failures, SinceLastFailure: now.Sub(attempt.lastFailedAt)}); err != nil {
+        return err
+    }
+
+    attempt.failures++
+    attempt.lastFailedAt = now
+    s.attempts[key] = attempt
+    return nil
+}
+
+// Release mengurangi counter di memory
+func (s *MemoryLoginAttemptStore) Release(ctx context.Context, key string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if attempt, ok := s.attempts[key]; ok && attempt.failures > 0 {
+        attempt.failures--
+    }
+    return nil
+}
+
+// Reset menghapus counter dari memory
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    delete(s.attempts, key)
+    return nil
+}
+
+// pruneLocked menghapus counter yang sudah kadaluarsa (caller memegang mu)
+func (s *MemoryLoginAttemptStore) pruneLocked(now time.Time, resetAfter time.Duration) {
+    for key, attempt := range s.attempts {
+        if now.Sub(attempt.lastFailedAt) > resetAfter {
+            delete(s.attempts, key)
+        }
+    }
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "os"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ═══════════════════════════════════════════════════════════
+// LOGIN LIMITER (Brute-force protection)
+// Per akun: exponential backoff setiap login gagal, lalu dikunci sementara
+//           setelah MaxFailures kali gagal
+// Per IP:   dikunci sementara setelah IPMaxFailures kali gagal (tanpa backoff,
+//           karena satu kelas bisa berbagi satu IP)
+// ═══════════════════════════════════════════════════════════
+
+// LoginLimiterConfig adalah batas login gagal
+type LoginLimiterConfig struct {
+    MaxFailures   int           // Akun dikunci setelah N login gagal
+    IPMaxFailures int           // IP dikunci setelah N login gagal
+    Lockout       time.Duration // Lama kunci (sekaligus window reset counter)
+    BackoffBase   time.Duration // Jeda setelah gagal pertama, dikali 2 setiap gagal berikutnya
+    BackoffMax    time.Duration // Batas atas jeda backoff
+}
+
+// DefaultLoginLimiterConfig adalah konfigurasi default limiter
+var DefaultLoginLimiterConfig = LoginLimiterConfig{
+    MaxFailures:   5,
+    IPMaxFailures: 50,
+    Lockout:       15 * time.Minute,
+    BackoffBase:   time.Second,
+    BackoffMax:    time.Minute,
+}
+
+// LoginBlockedError dikembalikan saat login ditolak oleh limiter
+type LoginBlockedError struct {
+    RetryAfter time.Duration
+    Locked     bool // true = dikunci sementara, false = backoff
+}
+
+func (e *LoginBlockedError) Error() string {
+    seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+    if e.Locked {
+        return fmt.Sprintf("akun dikunci sementara karena terlalu banyak login gagal, coba lagi dalam %d detik", seconds)
+    }
+    return fmt.Sprintf("terlalu banyak login gagal, coba lagi dalam %d detik", seconds)
+}
+
+// LoginLimiter melacak login gagal per akun & per IP
+type LoginLimiter struct {
+    store  LoginAttemptStore
+    config LoginLimiterConfig
+}
+
+// NewLoginLimiter membuat limiter dengan store & konfigurasi tertentu
+func NewLoginLimiter(store LoginAttemptStore, config LoginLimiterConfig) *LoginLimiter {
+    return &LoginLimiter{store: store, config: config}
+}
+
+// LoginLimiterFromEnv membuat limiter dari environment variables
+// LOGIN_LIMITER_STORE=postgres|memory (default postgres, tetap konsisten setelah restart)
+// LOGIN_MAX_FAILURES, LOGIN_IP_MAX_FAILURES, LOGIN_LOCKOUT, LOGIN_BACKOFF_BASE, LOGIN_BACKOFF_MAX
+func LoginLimiterFromEnv(db *pgxpool.Pool) *LoginLimiter {
+    config := DefaultLoginLimiterConfig
+    if n, err := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILURES")); err == nil && n > 0 {
+        config.MaxFailures = n
+    }
+    if n, err := strconv.Atoi(os.Getenv("LOGIN_IP_MAX_FAILURES")); err == nil && n > 0 {
+        config.IPMaxFailures = n
+    }
+    config.Lockout = ttlFromEnv("LOGIN_LOCKOUT", config.Lockout)
+    config.BackoffBase = ttlFromEnv("LOGIN_BACKOFF_BASE", config.BackoffBase)
+    config.BackoffMax = ttlFromEnv("LOGIN_BACKOFF_MAX", config.BackoffMax)
+
+    var store LoginAttemptStore = NewPostgresLoginAttemptStore(db)
+    if os.Getenv("LOGIN_LIMITER_STORE") == "memory" {
+        store = NewMemoryLoginAttemptStore()
+    }
+
+    return NewLoginLimiter(store, config)
+}
+
+// accountKey & ipKey adalah key counter di store
+func accountKey(email string) string {
+    return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+    return "ip:" + ip
+}
+
+// Reserve mengecek & mencatat satu percobaan login untuk akun & IP secara atomic,
+// sebelum password dicek (return *LoginBlockedError jika sedang dikunci / backoff)
+// Percobaan langsung dihitung gagal sehingga request paralel tidak bisa melewati
+// backoff & lockout; RecordSuccess menghapusnya jika login berhasil
+func (l *LoginLimiter) Reserve(ctx context.Context, email string, ip string) error {
+    if ip != "" {
+        if err := l.store.Reserve(ctx, ipKey(ip), l.config.Lockout, l.checkIP); err != nil {
+            return err
+        }
+    }
+
+    if err := l.store.Reserve(ctx, accountKey(email), l.config.Lockout, l.checkAccount); err != nil {
+        // Akun sedang dikunci: password tidak dicek, jadi tidak dihitung untuk IP
+        if ip != "" {
+            if releaseErr := l.store.Release(ctx, ipKey(ip)); releaseErr != nil {
+                return releaseErr
+            }
+        }
+        return err
+    }
+
+    return nil
+}
+
+// checkIP menolak IP yang sudah mencapai IPMaxFailures dalam window lockout
+func (l *LoginLimiter) checkIP(attempts LoginAttempts) error {
+    if attempts.Failures >= l.config.IPMaxFailures {
+        if wait := l.config.Lockout - attempts.SinceLastFailure; wait > 0 {
+            return &LoginBlockedError{RetryAfter: wait, Locked: true}
+        }
+    }
+    return nil
+}
+
+// checkAccount menolak akun yang masih dikunci atau dalam masa backoff
+func (l *LoginLimiter) checkAccount(attempts LoginAttempts) error {
+    if wait, locked := l.accountWait(attempts); wait > 0 {
+        return &LoginBlockedError{RetryAfter: wait, Locked: locked}
+    }
+    return nil
+}
+
+// accountWait menghitung sisa waktu tunggu akun (lockout atau backoff)
+func (l *LoginLimiter) accountWait(attempts LoginAttempts) (time.Duration, bool) {
+    if attempts.Failures == 0 {
+        return 0, false
+    }
+
+    if attempts.Failures >= l.config.MaxFailures {
+        return l.config.Lockout - attempts.SinceLastFailure, true
+    }
+
+    backoff := l.config.BackoffMax
+    if shift := attempts.Failures - 1; shift < 30 {
+        if d := l.config.BackoffBase << shift; d < backoff {
+            backoff = d
+        }
+    }
+    return backoff - attempts.SinceLastFailure, false
+}
+
+// RecordSuccess me-reset counter akun setelah login berhasil dan melepas
+// percobaan yang di-reserve untuk IP (counter IP tidak di-reset agar penyerang
+// tidak bisa menghapusnya dengan akun sendiri)
+func (l *LoginLimiter) RecordSuccess(ctx context.Context, email string, ip string) error {
+    if err := l.store.Reset(ctx, accountKey(email)); err != nil {
+        return err
+    }
+    if ip != "" {
+        return l.store.Release(ctx, ipKey(ip))
+    }
+    return nil
+}
+
+// UnlockAccount membuka kunci akun (admin)
+func (l *LoginLimiter) UnlockAccount(ctx context.Context, email string) error {
+    return l.store.Reset(ctx, accountKey(email))
+}
+
+// UnlockIP membuka kunci IP (admin)
+func (l *LoginLimiter) UnlockIP(ctx context.Context, ip string) error {
+    return l.store.Reset(ctx, ipKey(ip))
+}
@@ -2,429 +2,60 @@ package services
 
 import (
     "context"
-    "encoding/json"
     "errors"
-    "time"
+    "strings"
 
     "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/executor"
     "primmfy_db/models"
 )
 
 // ═══════════════════════════════════════════════════════════
 // SUBMIT STAGE FUNCTIONS
+// MODIFY & MAKE tidak dinilai di request, tapi lewat GradingQueue
 // ═══════════════════════════════════════════════════════════
 
 // SubmitPredictStage memproses submission PREDICT stage
 // Logic: Cek jawaban benar/salah, beri koin jika benar
-func SubmitPredictStage(db *pgx.Conn, userID int, req models.SubmitPredictRequest) (*models.SubmitStageResponse, error) {
-    // 1. Ambil data stage dari database
-    var correctAnswer string
-    err := db.QueryRow(context.Background(),
-        "SELECT correct_answer FROM primm_stages WHERE id = $1 AND stage_type = 'predict'",
-        req.StageID).Scan(&correctAnswer)
-
+func SubmitPredictStage(ctx context.Context, db *pgxpool.Pool, userID int, req models.SubmitPredictRequest) (*models.SubmitStageResponse, error) {
+    submission, err := submitStageAnswer(ctx, db, userID, StageAnswer{
+        StageID:        req.StageID,
+        StageType:      "predict",
+        SelectedAnswer: req.SelectedAnswer,
+    })
     if err != nil {
-        if err == pgx.ErrNoRows {
-            return nil, errors.New("predict stage tidak ditemukan")
-        }
-        return nil, errors.New("gagal mengambil stage: " + err.Error())
+        return nil, err
     }
-
-    // 2. Cek apakah jawaban benar
-    isCorrect := (req.SelectedAnswer == correctAnswer)
-
-    // 3. Cek apakah sudah pernah submit (untuk prevent duplicate submission)
-    var existingCompletionID int
-    err = db.QueryRow(context.Background(),
-        "SELECT id FROM user_stage_completions WHERE user_id = $1 AND stage_id = $2",
-        userID, req.StageID).Scan(&existingCompletionID)
-
-    var coinsEarned, xpEarned int
-    message := "Jawaban salah. Coba lagi!"
-
-    if isCorrect {
-        coinsEarned = 50  // Reward koin untuk Predict stage
-        xpEarned = 20     // Reward XP
-        message = "Jawaban benar! Selamat!"
-    }
-
-    // 4. Simpan atau update submission
-    if err == pgx.ErrNoRows {
-        // Belum pernah submit, insert baru
-        _, err = db.Exec(context.Background(), `
-            INSERT INTO user_stage_completions 
-            (user_id, stage_id, predict_selected_answer, predict_is_correct, is_completed, completed_at)
-            VALUES ($1, $2, $3, $4, $5, $6)`,
-            userID, req.StageID, req.SelectedAnswer, isCorrect, isCorrect,
-            func() *time.Time { if isCorrect { t := time.Now(); return &t }; return nil }())
-
-        if err != nil {
-            return nil, errors.New("gagal menyimpan submission: " + err.Error())
-        }
-    } else {
-        // Sudah pernah submit, update
-        _, err = db.Exec(context.Background(), `
-            UPDATE user_stage_completions
-            SET predict_selected_answer = $1, predict_is_correct = $2, 
-                is_completed = $3, completed_at = $4, updated_at = NOW()
-            WHERE user_id = $5 AND stage_id = $6`,
-            req.SelectedAnswer, isCorrect, isCorrect,
-            func() *time.Time { if isCorrect { t := time.Now(); return &t }; return nil }(),
-            userID, req.StageID)
-
-        if err != nil {
-            return nil, errors.New("gagal update submission: " + err.Error())
-        }
-    }
-
-    // 5. Jika benar, berikan reward (update coins & XP user)
-    if isCorrect {
-        _, err = db.Exec(context.Background(), `
-            UPDATE users
-            SET total_coins = total_coins + $1,
-                experience_points = experience_points + $2,
-                updated_at = NOW()
-            WHERE id = $3`,
-            coinsEarned, xpEarned, userID)
-
-        if err != nil {
-            return nil, errors.New("gagal memberikan reward: " + err.Error())
-        }
-
-        // Cek apakah perlu level up
-        checkAndLevelUp(db, userID)
-    }
-
-    return &models.SubmitStageResponse{
-        Success:     true,
-        IsCorrect:   isCorrect,
-        Message:     message,
-        CoinsEarned: coinsEarned,
-        XPEarned:    xpEarned,
-    }, nil
+    return submission.Response, nil
 }
 
 // SubmitRunStage memproses submission RUN stage
-// Purpose: Simpan code yang ditulis siswa & output hasil run
-func SubmitRunStage(db *pgx.Conn, userID int, req models.SubmitRunRequest) (*models.SubmitStageResponse, error) {
-    // 1. Validasi stage type
-    var stageType string
-    err := db.QueryRow(context.Background(),
-        "SELECT stage_type FROM primm_stages WHERE id = $1", req.StageID).Scan(&stageType)
-
-    if err != nil || stageType != "run" {
-        return nil, errors.New("run stage tidak ditemukan")
-    }
-
-    // 2. Simulate code execution (dalam production, pakai sandbox seperti Judge0)
-    // Untuk sementara, kita anggap code valid dan return "Success"
-    output := "Code executed successfully! Output: Hello World"
-
-    // 3. Simpan submission
-    var existingID int
-    err = db.QueryRow(context.Background(),
-        "SELECT id FROM user_stage_completions WHERE user_id = $1 AND stage_id = $2",
-        userID, req.StageID).Scan(&existingID)
-
-    coinsEarned := 50
-    xpEarned := 20
-
-    if err == pgx.ErrNoRows {
-        // Insert baru
-        _, err = db.Exec(context.Background(), `
-            INSERT INTO user_stage_completions
-            (user_id, stage_id, run_submitted_code, run_output, is_completed, completed_at)
-            VALUES ($1, $2, $3, $4, true, NOW())`,
-            userID, req.StageID, req.SubmittedCode, output)
-    } else {
-        // Update existing
-        _, err = db.Exec(context.Background(), `
-            UPDATE user_stage_completions
-            SET run_submitted_code = $1, run_output = $2, 
-                is_completed = true, completed_at = NOW(), updated_at = NOW()
-            WHERE user_id = $3 AND stage_id = $4`,
-            req.SubmittedCode, output, userID, req.StageID)
-    }
-
-    if err != nil {
-        return nil, errors.New("gagal menyimpan submission: " + err.Error())
-    }
-
-    // 4. Berikan reward
-    _, err = db.Exec(context.Background(), `
-        UPDATE users
-        SET total_coins = total_coins + $1,
-            experience_points = experience_points + $2,
-            updated_at = NOW()
-        WHERE id = $3`,
-        coinsEarned, xpEarned, userID)
-
+// Purpose: Jalankan code yang ditulis ulang siswa di sandbox & simpan output aslinya
+func SubmitRunStage(ctx context.Context, db *pgxpool.Pool, userID int, req models.SubmitRunRequest) (*models.SubmitStageResponse, error) {
+    submission, err := submitStageAnswer(ctx, db, userID, StageAnswer{
+        StageID:   req.StageID,
+        StageType: "run",
+        Code:      req.SubmittedCode,
+    })
     if err != nil {
-        return nil, errors.New("gagal memberikan reward: " + err.Error())
+        return nil, err
     }
-
-    checkAndLevelUp(db, userID)
-
-    return &models.SubmitStageResponse{
-        Success:     true,
-        IsCorrect:   true,
-        Message:     "Code berhasil dijalankan!",
-        CoinsEarned: coinsEarned,
-        XPEarned:    xpEarned,
-        Output:      output,
-    }, nil
+    return submission.Response, nil
 }
 
 // SubmitInvestigateStage memproses submission INVESTIGATE stage
 // Purpose: Simpan refleksi siswa (no right/wrong answer)
-func SubmitInvestigateStage(db *pgx.Conn, userID int, req models.SubmitInvestigateRequest) (*models.SubmitStageResponse, error) {
-    // 1. Validasi stage
-    var stageType string
-    err := db.QueryRow(context.Background(),
-        "SELECT stage_type FROM primm_stages WHERE id = $1", req.StageID).Scan(&stageType)
-
-    if err != nil || stageType != "investigate" {
-        return nil, errors.New("investigate stage tidak ditemukan")
-    }
-
-    // 2. Simpan refleksi (tidak ada benar/salah, hanya perlu submit)
-    var existingID int
-    err = db.QueryRow(context.Background(),
-        "SELECT id FROM user_stage_completions WHERE user_id = $1 AND stage_id = $2",
-        userID, req.StageID).Scan(&existingID)
-
-    coinsEarned := 30 // Lebih sedikit karena tidak ada validasi benar/salah
-    xpEarned := 15
-
-    if err == pgx.ErrNoRows {
-        _, err = db.Exec(context.Background(), `
-            INSERT INTO user_stage_completions
-            (user_id, stage_id, investigate_reflection, investigate_completed, is_completed, completed_at)
-            VALUES ($1, $2, $3, true, true, NOW())`,
-            userID, req.StageID, req.Reflection)
-    } else {
-        _, err = db.Exec(context.Background(), `
-            UPDATE user_stage_completions
-            SET investigate_reflection = $1, investigate_completed = true,
-                is_completed = true, completed_at = NOW(), updated_at = NOW()
-            WHERE user_id = $2 AND stage_id = $3`,
-            req.Reflection, userID, req.StageID)
-    }
-
-    if err != nil {
-        return nil, errors.New("gagal menyimpan submission: " + err.Error())
-    }
-
-    // 3. Berikan reward
-    _, err = db.Exec(context.Background(), `
-        UPDATE users
-        SET total_coins = total_coins + $1,
-            experience_points = experience_points + $2,
-            updated_at = NOW()
-        WHERE id = $3`,
-        coinsEarned, xpEarned, userID)
-
-    checkAndLevelUp(db, userID)
-
-    return &models.SubmitStageResponse{
-        Success:     true,
-        IsCorrect:   true, // Selalu true karena tidak ada validasi
-        Message:     "Refleksi berhasil disimpan!",
-        CoinsEarned: coinsEarned,
-        XPEarned:    xpEarned,
-    }, nil
-}
-
-// SubmitModifyStage memproses submission MODIFY stage dengan test case validation
-func SubmitModifyStage(db *pgx.Conn, userID int, req models.SubmitModifyRequest) (*models.SubmitStageResponse, error) {
-    // 1. Ambil stage data
-    var modifyTestCasesJSON []byte
-    err := db.QueryRow(context.Background(),
-        "SELECT modify_test_cases FROM primm_stages WHERE id = $1 AND stage_type = 'modify'",
-        req.StageID).Scan(&modifyTestCasesJSON)
-
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return nil, errors.New("modify stage tidak ditemukan")
-        }
-        return nil, errors.New("gagal mengambil stage: " + err.Error())
-    }
-
-    // 2. Parse test cases
-    var testCases []models.TestCase
-    if err := json.Unmarshal(modifyTestCasesJSON, &testCases); err != nil {
-        return nil, errors.New("gagal parse test cases: " + err.Error())
-    }
-
-    // 3. Simulate code execution & validation (dalam production pakai Judge0/sandbox)
-    // Untuk sementara, kita anggap code passed all test cases
-    isCorrect := true
-    output := "Test case 1: PASSED\nTest case 2: PASSED\nAll tests passed!"
-
-    // 4. Get atau create submission record
-    var existingID int
-    err = db.QueryRow(context.Background(),
-        "SELECT id FROM user_stage_completions WHERE user_id = $1 AND stage_id = $2",
-        userID, req.StageID).Scan(&existingID)
-
-    coinsEarned := 0
-    xpEarned := 0
-    message := "Code tidak passed test cases. Coba lagi!"
-
-    if isCorrect {
-        coinsEarned = 75
-        xpEarned = 30
-        message = "Code berhasil passed semua test cases! Selamat!"
-    }
-
-    // 5. Update atau insert submission
-    if err == pgx.ErrNoRows {
-        // Insert baru
-        _, err = db.Exec(context.Background(), `
-            INSERT INTO user_stage_completions
-            (user_id, stage_id, modify_submitted_code, modify_output, modify_is_correct, 
-             modify_attempts, is_completed, completed_at)
-            VALUES ($1, $2, $3, $4, $5, 1, $5, $6)`,
-            userID, req.StageID, req.SubmittedCode, output, isCorrect,
-            func() *time.Time { if isCorrect { t := time.Now(); return &t }; return nil }())
-    } else {
-        // Update existing (increment attempts)
-        _, err = db.Exec(context.Background(), `
-            UPDATE user_stage_completions
-            SET modify_submitted_code = $1, modify_output = $2, modify_is_correct = $3,
-                modify_attempts = modify_attempts + 1,
-                is_completed = $3, completed_at = $4, updated_at = NOW()
-            WHERE user_id = $5 AND stage_id = $6`,
-            req.SubmittedCode, output, isCorrect,
-            func() *time.Time { if isCorrect { t := time.Now(); return &t }; return nil }(),
-            userID, req.StageID)
-    }
-
-    if err != nil {
-        return nil, errors.New("gagal menyimpan submission: " + err.Error())
-    }
-
-    // 6. Berikan reward jika correct
-    if isCorrect {
-        _, err = db.Exec(context.Background(), `
-            UPDATE users
-            SET total_coins = total_coins + $1,
-                experience_points = experience_points + $2,
-                updated_at = NOW()
-            WHERE id = $3`,
-            coinsEarned, xpEarned, userID)
-
-        if err != nil {
-            return nil, errors.New("gagal memberikan reward: " + err.Error())
-        }
-
-        checkAndLevelUp(db, userID)
-    }
-
-    return &models.SubmitStageResponse{
-        Success:     true,
-        IsCorrect:   isCorrect,
-        Message:     message,
-        CoinsEarned: coinsEarned,
-        XPEarned:    xpEarned,
-        Output:      output,
-    }, nil
-}
-
-// SubmitMakeStage memproses submission MAKE stage dengan test case validation
-func SubmitMakeStage(db *pgx.Conn, userID int, req models.SubmitMakeRequest) (*models.SubmitStageResponse, error) {
-    // 1. Ambil stage data
-    var makeTestCasesJSON []byte
-    err := db.QueryRow(context.Background(),
-        "SELECT make_test_cases FROM primm_stages WHERE id = $1 AND stage_type = 'make'",
-        req.StageID).Scan(&makeTestCasesJSON)
-
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return nil, errors.New("make stage tidak ditemukan")
-        }
-        return nil, errors.New("gagal mengambil stage: " + err.Error())
-    }
-
-    // 2. Parse test cases
-    var testCases []models.TestCase
-    if err := json.Unmarshal(makeTestCasesJSON, &testCases); err != nil {
-        return nil, errors.New("gagal parse test cases: " + err.Error())
-    }
-
-    // 3. Simulate code execution (production: pakai Judge0)
-    isCorrect := true
-    output := "Test case 1: PASSED\nTest case 2: PASSED\nAll tests passed!"
-
-    // 4. Get atau create submission
-    var existingID int
-    err = db.QueryRow(context.Background(),
-        "SELECT id FROM user_stage_completions WHERE user_id = $1 AND stage_id = $2",
-        userID, req.StageID).Scan(&existingID)
-
-    coinsEarned := 0
-    xpEarned := 0
-    message := "Code tidak passed test cases. Coba lagi!"
-
-    if isCorrect {
-        coinsEarned = 100 // Highest reward untuk MAKE stage
-        xpEarned = 50
-        message = "Code berhasil passed semua test cases! Excellent work!"
-    }
-
-    // 5. Update atau insert
-    if err == pgx.ErrNoRows {
-        _, err = db.Exec(context.Background(), `
-            INSERT INTO user_stage_completions
-            (user_id, stage_id, make_submitted_code, make_output, make_is_correct, 
-             make_attempts, is_completed, completed_at)
-            VALUES ($1, $2, $3, $4, $5, 1, $5, $6)`,
-            userID, req.StageID, req.SubmittedCode, output, isCorrect,
-            func() *time.Time { if isCorrect { t := time.Now(); return &t }; return nil }())
-    } else {
-        _, err = db.Exec(context.Background(), `
-            UPDATE user_stage_completions
-            SET make_submitted_code = $1, make_output = $2, make_is_correct = $3,
-                make_attempts = make_attempts + 1,
-                is_completed = $3, completed_at = $4, updated_at = NOW()
-            WHERE user_id = $5 AND stage_id = $6`,
-            req.SubmittedCode, output, isCorrect,
-            func() *time.Time { if isCorrect { t := time.Now(); return &t }; return nil }(),
-            userID, req.StageID)
-    }
-
+func SubmitInvestigateStage(ctx context.Context, db *pgxpool.Pool, userID int, req models.SubmitInvestigateRequest) (*models.SubmitStageResponse, error) {
+    submission, err := submitStageAnswer(ctx, db, userID, StageAnswer{
+        StageID:    req.StageID,
+        StageType:  "investigate",
+        Reflection: req.Reflection,
+    })
     if err != nil {
-        return nil, errors.New("gagal menyimpan submission: " + err.Error())
-    }
-
-    // 6. Berikan reward
-    if isCorrect {
-        _, err = db.Exec(context.Background(), `
-            UPDATE users
-            SET total_coins = total_coins + $1,
-                experience_points = experience_points + $2,
-                updated_at = NOW()
-            WHERE id = $3`,
-            coinsEarned, xpEarned, userID)
-
-        if err != nil {
-            return nil, errors.New("gagal memberikan reward: " + err.Error())
-        }
-
-        checkAndLevelUp(db, userID)
-
-        // Check apakah course sudah complete (semua 5 stages done)
-        checkAndCompleteCourse(db, userID, req.StageID)
+        return nil, err
     }
-
-    return &models.SubmitStageResponse{
-        Success:     true,
-        IsCorrect:   isCorrect,
-        Message:     message,
-        CoinsEarned: coinsEarned,
-        XPEarned:    xpEarned,
-        Output:      output,
-    }, nil
+    return submission.Response, nil
 }
 
 // ═══════════════════════════════════════════════════════════
@@ -432,10 +63,10 @@ func SubmitMakeStage(db *pgx.Conn, userID int, req models.SubmitMakeRequest) (*m
 // ═══════════════════════════════════════════════════════════
 
 // GetMyProgress mengambil progress siswa di lesson tertentu
-func GetMyProgress(db *pgx.Conn, userID int, lessonID int) (*models.ProgressSummary, error) {
+func GetMyProgress(ctx context.Context, db *pgxpool.Pool, userID int, lessonID int) (*models.ProgressSummary, error) {
     // 1. Hitung total courses di lesson
     var totalCourses int
-    err := db.QueryRow(context.Background(),
+    err := db.QueryRow(ctx,
         "SELECT COUNT(*) FROM courses WHERE lesson_id = $1 AND is_active = true", lessonID).Scan(&totalCourses)
 
     if err != nil {
@@ -444,7 +75,7 @@ func GetMyProgress(db *pgx.Conn, userID int, lessonID int) (*models.ProgressSumm
 
     // 2. Hitung completed courses
     var completedCourses int
-    err = db.QueryRow(context.Background(), `
+    err = db.QueryRow(ctx, `
         SELECT COUNT(*)
         FROM user_course_completions
         WHERE user_id = $1 AND course_id IN (
@@ -461,14 +92,21 @@ func GetMyProgress(db *pgx.Conn, userID int, lessonID int) (*models.ProgressSumm
         progressPercent = (float64(completedCourses) / float64(totalCourses)) * 100
     }
 
-    // 4. Hitung total coins & XP yang didapat dari lesson ini
+    // 4. Hitung total coins & XP yang didapat dari lesson ini (dari coin ledger)
     var totalCoins, totalXP int
-    db.QueryRow(context.Background(), `
-        SELECT COALESCE(SUM(coins_earned), 0)
-        FROM user_course_completions
-        WHERE user_id = $1 AND course_id IN (
-            SELECT id FROM courses WHERE lesson_id = $2
-        )`, userID, lessonID).Scan(&totalCoins)
+    db.QueryRow(ctx, `
+        SELECT COALESCE(SUM(ct.amount), 0), COALESCE(SUM(ct.xp), 0)
+        FROM coin_transactions ct
+        WHERE ct.user_id = $1 AND (
+            (ct.source_type = 'stage' AND ct.source_id IN (
+                SELECT ps.id FROM primm_stages ps
+                JOIN courses c ON ps.course_id = c.id
+                WHERE c.lesson_id = $2
+            ))
+            OR (ct.source_type = 'course' AND ct.source_id IN (
+                SELECT id FROM courses WHERE lesson_id = $2
+            ))
+        )`, userID, lessonID).Scan(&totalCoins, &totalXP)
 
     return &models.ProgressSummary{
         TotalCourses:     totalCourses,
@@ -483,11 +121,96 @@ func GetMyProgress(db *pgx.Conn, userID int, lessonID int) (*models.ProgressSumm
 // HELPER FUNCTIONS
 // ═══════════════════════════════════════════════════════════
 
+// getStageRunner memilih runner executor berdasarkan category lesson dari stage
+func getStageRunner(ctx context.Context, db *pgxpool.Pool, stageID int) (executor.Runner, error) {
+    var category string
+    err := db.QueryRow(ctx, `
+        SELECT l.category
+        FROM primm_stages ps
+        JOIN courses c ON ps.course_id = c.id
+        JOIN lessons l ON c.lesson_id = l.id
+        WHERE ps.id = $1`, stageID).Scan(&category)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("stage tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil bahasa lesson: " + err.Error())
+    }
+
+    return executor.Lookup(category)
+}
+
+// runStageResult adalah hasil eksekusi code RUN stage
+type runStageResult struct {
+    Output          string
+    MatchesTemplate bool
+    IsCorrect       bool
+    Message         string
+}
+
+// runStageTemplate memilih code yang harus ditulis ulang siswa
+// (run_code_template, fallback ke code_snippet jika template kosong)
+func runStageTemplate(runCodeTemplate, codeSnippet *string) string {
+    if runCodeTemplate != nil && strings.TrimSpace(*runCodeTemplate) != "" {
+        return *runCodeTemplate
+    }
+    if codeSnippet != nil {
+        return *codeSnippet
+    }
+    return ""
+}
+
+// executeRunStage menjalankan code RUN stage di sandbox
+// Stage dianggap selesai jika code sama dengan template (setelah normalisasi
+// whitespace) dan program berhasil dijalankan tanpa error
+func executeRunStage(ctx context.Context, db *pgxpool.Pool, stageID int, template string, submittedCode string) (*runStageResult, error) {
+    runner, err := getStageRunner(ctx, db, stageID)
+    if err != nil {
+        return nil, err
+    }
+
+    result, err := executor.RunOnce(ctx, executor.NewProgram(runner, submittedCode), "")
+    if err != nil {
+        return nil, errors.New("gagal menjalankan code: " + err.Error())
+    }
+
+    output := result.Stdout + result.Stderr
+    if result.TimedOut {
+        output += "\nProgram melebihi batas waktu eksekusi"
+    }
+
+    run := &runStageResult{
+        Output:          output,
+        MatchesTemplate: template != "" && normalizeCode(submittedCode) == normalizeCode(template),
+    }
+
+    switch {
+    case !run.MatchesTemplate:
+        run.Message = "Code belum sama dengan template. Tulis ulang code dengan teliti!"
+    case result.CompileFailed:
+        run.Message = "Code gagal di-compile. Cek kembali penulisan code!"
+    case result.TimedOut || result.ExitCode != 0:
+        run.Message = "Code error saat dijalankan. Cek kembali penulisan code!"
+    default:
+        run.IsCorrect = true
+        run.Message = "Code berhasil dijalankan!"
+    }
+
+    return run, nil
+}
+
+// normalizeCode menyamakan whitespace (spasi, tab, newline, CRLF) agar
+// perbedaan format tidak dianggap code yang berbeda
+func normalizeCode(code string) string {
+    return strings.Join(strings.Fields(code), " ")
+}
+
 // checkAndLevelUp mengecek apakah user perlu naik level
 // Logic: Setiap 100 XP = 1 level
-func checkAndLevelUp(db *pgx.Conn, userID int) error {
+func checkAndLevelUp(ctx context.Context, q querier, userID int) error {
     var currentLevel, currentXP int
-    err := db.QueryRow(context.Background(),
+    err := q.QueryRow(ctx,
         "SELECT level, experience_points FROM users WHERE id = $1", userID).Scan(&currentLevel, &currentXP)
 
     if err != nil {
@@ -499,7 +222,7 @@ func checkAndLevelUp(db *pgx.Conn, userID int) error {
 
     // Jika perlu level up
     if expectedLevel > currentLevel {
-        _, err = db.Exec(context.Background(),
+        _, err = q.Exec(ctx,
             "UPDATE users SET level = $1, updated_at = NOW() WHERE id = $2",
             expectedLevel, userID)
         return err
@@ -508,62 +231,66 @@ func checkAndLevelUp(db *pgx.Conn, userID int) error {
     return nil
 }
 
-// checkAndCompleteCourse mengecek apakah semua 5 stages sudah complete
-func checkAndCompleteCourse(db *pgx.Conn, userID int, stageID int) error {
+// checkAndCompleteCourse mengecek apakah semua stages di course sudah complete
+// Bonus coin_reward course dicatat di ledger sehingga hanya dibayar sekali
+func checkAndCompleteCourse(ctx context.Context, q querier, userID int, stageID int) error {
     // 1. Get course_id dari stage
     var courseID int
-    err := db.QueryRow(context.Background(),
+    err := q.QueryRow(ctx,
         "SELECT course_id FROM primm_stages WHERE id = $1", stageID).Scan(&courseID)
 
     if err != nil {
         return err
     }
 
-    // 2. Hitung berapa stages yang completed
-    var completedStages int
-    err = db.QueryRow(context.Background(), `
-        SELECT COUNT(*)
-        FROM user_stage_completions usc
-        JOIN primm_stages ps ON usc.stage_id = ps.id
-        WHERE usc.user_id = $1 AND ps.course_id = $2 AND usc.is_completed = true`,
-        userID, courseID).Scan(&completedStages)
+    // 2. Hitung total stages & stages yang completed
+    var totalStages, completedStages int
+    err = q.QueryRow(ctx, `
+        SELECT COUNT(DISTINCT ps.id),
+               COUNT(DISTINCT usc.stage_id) FILTER (WHERE usc.is_completed = true)
+        FROM primm_stages ps
+        LEFT JOIN user_stage_completions usc ON ps.id = usc.stage_id AND usc.user_id = $1
+        WHERE ps.course_id = $2`,
+        userID, courseID).Scan(&totalStages, &completedStages)
 
     if err != nil {
         return err
     }
 
-    // 3. Jika sudah 5 stages (semua PRIMM stages complete)
-    if completedStages == 5 {
-        // Get coin_reward dari course
-        var coinReward int
-        err = db.QueryRow(context.Background(),
-            "SELECT coin_reward FROM courses WHERE id = $1", courseID).Scan(&coinReward)
-
-        if err != nil {
-            return err
-        }
-
-        // Mark course as completed
-        _, err = db.Exec(context.Background(), `
-            INSERT INTO user_course_completions (user_id, course_id, is_completed, completed_at, coins_earned)
-            VALUES ($1, $2, true, NOW(), $3)
-            ON CONFLICT (user_id, course_id) 
-            DO UPDATE SET is_completed = true, completed_at = NOW(), coins_earned = $3`,
-            userID, courseID, coinReward)
+    // 3. Belum semua PRIMM stages complete
+    if totalStages == 0 || completedStages < totalStages {
+        return nil
+    }
 
-        if err != nil {
-            return err
-        }
+    // Get coin_reward dari course
+    var coinReward int
+    err = q.QueryRow(ctx,
+        "SELECT coin_reward FROM courses WHERE id = $1", courseID).Scan(&coinReward)
 
-        // Berikan bonus coins untuk complete course
-        _, err = db.Exec(context.Background(), `
-            UPDATE users
-            SET total_coins = total_coins + $1, updated_at = NOW()
-            WHERE id = $2`,
-            coinReward, userID)
+    if err != nil {
+        return err
+    }
 
+    // 4. Berikan bonus coins untuk complete course (idempotent)
+    granted, err := grantReward(ctx, q, userID, CoinSourceCourse, courseID, coinReward, 0, "course complete")
+    if err != nil {
         return err
     }
 
-    return nil
-}
\ No newline at end of file
+    coinsEarned := 0
+    if granted {
+        coinsEarned = coinReward
+    }
+
+    // 5. Mark course as completed
+    _, err = q.Exec(ctx, `
+        INSERT INTO user_course_completions (user_id, course_id, is_completed, completed_at, coins_earned)
+        VALUES ($1, $2, true, NOW(), $3)
+        ON CONFLICT (user_id, course_id) 
+        DO UPDATE SET is_completed = true, completed_at = NOW(),
+                      coins_earned = GREATEST(COALESCE(user_course_completions.coins_earned, 0), EXCLUDED.coins_earned)
+        WHERE user_course_completions.is_completed IS NOT TRUE`,
+        userID, courseID, coinsEarned)
+
+    return err
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// REFLECTION REVIEW (INVESTIGATE)
+// Refleksi tidak dinilai otomatis: setiap submission masuk review queue
+// sebagai pending_review, lalu teacher menilai dengan rubric stage
+// (rubric di-snapshot ke reflection_reviews saat refleksi masuk queue)
+// Completion, coins & XP baru diberikan saat refleksi di-approve
+// ═══════════════════════════════════════════════════════════
+
+// reviewColumns adalah kolom untuk scanReview
+const reviewColumns = `
+    rr.id, rr.attempt_id, rr.user_id, u.full_name, u.email,
+    rr.stage_id, ps.title, l.id, l.title, sa.answer, rr.rubric,
+    rr.status, sa.is_late, rr.late_penalty_percent, rr.rubric_scores, rr.score,
+    rr.reviewer_id, rr.reviewed_at, sa.submitted_at`
+
+// reviewJoins adalah FROM + JOIN untuk reviewColumns
+const reviewJoins = `
+    FROM reflection_reviews rr
+    JOIN stage_attempts sa ON rr.attempt_id = sa.id
+    JOIN users u ON rr.user_id = u.id
+    JOIN primm_stages ps ON rr.stage_id = ps.id
+    JOIN courses c ON ps.course_id = c.id
+    JOIN lessons l ON c.lesson_id = l.id`
+
+// scanReview membaca satu baris reviewColumns
+func scanReview(row pgx.Row) (*models.ReflectionReview, error) {
+    var review models.ReflectionReview
+    var rubricJSON, scoresJSON []byte
+    err := row.Scan(
+        &review.ID, &review.AttemptID, &review.UserID, &review.StudentName, &review.StudentEmail,
+        &review.StageID, &review.StageTitle, &review.LessonID, &review.LessonTitle,
+        &review.Reflection, &rubricJSON, &review.Status, &review.IsLate, &review.LatePenaltyPercent,
+        &scoresJSON, &review.Score, &review.ReviewerID, &review.ReviewedAt, &review.SubmittedAt)
+    if err != nil {
+        return nil, err
+    }
+
+    if rubricJSON != nil {
+        if err := json.Unmarshal(rubricJSON, &review.Rubric); err != nil {
+            return nil, errors.New("gagal parse rubric: " + err.Error())
+        }
+    }
+    if scoresJSON != nil {
+        if err := json.Unmarshal(scoresJSON, &review.RubricScores); err != nil {
+            return nil, errors.New("gagal parse rubric scores: " + err.Error())
+        }
+    }
+    return &review, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// RUBRIC (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// UpdateInvestigateRubric mengganti rubric INVESTIGATE stage (rubric kosong = dihapus)
+// Review yang sudah ada tetap memakai snapshot rubric-nya, rubric baru dipakai
+// untuk refleksi yang dikirim berikutnya
+func UpdateInvestigateRubric(ctx context.Context, db *pgxpool.Pool, actor Actor, stageID int, rubric []models.RubricCriterion) (*models.PRIMMStage, error) {
+    if err := Authorize(ctx, db, actor, PermLessonEdit, Resource{ResourceStage, stageID}); err != nil {
+        return nil, err
+    }
+
+    var stageType string
+    err := db.QueryRow(ctx, "SELECT stage_type FROM primm_stages WHERE id = $1", stageID).Scan(&stageType)
+    if err != nil {
+        return nil, errors.New("gagal mengambil stage: " + err.Error())
+    }
+    if stageType != "investigate" {
+        return nil, errors.New("rubric hanya untuk INVESTIGATE stage")
+    }
+
+    rubricJSON, err := marshalRubric(rubric)
+    if err != nil {
+        return nil, err
+    }
+
+    _, err = db.Exec(ctx, `
+        UPDATE primm_stages SET investigate_rubric = $1, updated_at = NOW()
+        WHERE id = $2`, rubricJSON, stageID)
+
+    if err != nil {
+        return nil, errors.New("gagal update rubric: " + err.Error())
+    }
+
+    return GetStageByID(ctx, db, stageID)
+}
+
+// ═══════════════════════════════════════════════════════════
+// REVIEW QUEUE (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// GetReviewQueue mengambil refleksi di lesson yang boleh dinilai actor
+// (default pending_review, yang paling lama menunggu di atas)
+func GetReviewQueue(ctx context.Context, db *pgxpool.Pool, actor Actor, query models.ReviewQueueQuery) ([]models.ReflectionReview, error) {
+    if query.LessonID != 0 {
+        if err := Authorize(ctx, db, actor, PermSubmissionFeedback, Resource{ResourceLesson, query.LessonID}); err != nil {
+            return nil, err
+        }
+    }
+    if query.StageID != 0 {
+        if err := Authorize(ctx, db, actor, PermSubmissionFeedback, Resource{ResourceStage, query.StageID}); err != nil {
+            return nil, err
+        }
+    }
+    if query.Status == "" {
+        query.Status = models.ReviewStatusPending
+    }
+
+    // Role collaborator yang boleh menilai (mengikuti relationPermissions)
+    var reviewerRoles []string
+    for relation, perms := range relationPermissions {
+        if containsPermission(perms, PermSubmissionFeedback) {
+            reviewerRoles = append(reviewerRoles, string(relation))
+        }
+    }
+
+    rows, err := db.Query(ctx, "SELECT "+reviewColumns+reviewJoins+`
+        WHERE rr.status = $1
+          AND ($2 = 0 OR l.id = $2)
+          AND ($3 = 0 OR rr.stage_id = $3)
+          AND ($4 OR EXISTS (
+              SELECT 1 FROM lesson_collaborators lc
+              WHERE lc.lesson_id = l.id AND lc.user_id = $5
+                AND lc.accepted_at IS NOT NULL AND lc.role = ANY($6::text[])
+          ))
+        ORDER BY sa.submitted_at ASC, rr.id ASC`,
+        query.Status, query.LessonID, query.StageID,
+        HasPermission(actor.Role, PermLessonManageAny), actor.UserID, reviewerRoles)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil review queue: " + err.Error())
+    }
+    defer rows.Close()
+
+    reviews := []models.ReflectionReview{}
+    for rows.Next() {
+        review, err := scanReview(rows)
+        if err != nil {
+            return nil, errors.New("gagal scan review: " + err.Error())
+        }
+        reviews = append(reviews, *review)
+    }
+
+    return reviews, nil
+}
+
+// ScoreReflection menilai refleksi di review queue
+// approve: stage complete, score tersimpan, reward & course completion diproses
+// reject: refleksi ditolak, student perlu mengirim refleksi baru
+// Comment disimpan sebagai feedback attempt sehingga terlihat oleh student
+func ScoreReflection(ctx context.Context, db *pgxpool.Pool, actor Actor, reviewID int, req models.ScoreReflectionRequest) (*models.ReflectionReview, error) {
+    review, err := getReview(ctx, db, reviewID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := Authorize(ctx, db, actor, PermSubmissionFeedback, Resource{ResourceStage, review.StageID}); err != nil {
+        return nil, err
+    }
+
+    if review.Status != models.ReviewStatusPending {
+        return nil, errors.New("refleksi sudah direview")
+    }
+
+    // 1. Hitung score dari snapshot rubric review (atau score langsung jika tanpa rubric)
+    score, err := rubricScore(review.Rubric, req)
+    if err != nil {
+        return nil, err
+    }
+    score = score * (100 - review.LatePenaltyPercent) / 100
+
+    var scoresJSON []byte
+    if len(review.Rubric) > 0 {
+        scoresJSON, err = json.Marshal(req.RubricScores)
+        if err != nil {
+            return nil, errors.New("gagal serialize rubric scores: " + err.Error())
+        }
+    }
+
+    status := models.ReviewStatusRejected
+    if req.Decision == models.ReviewDecisionApprove {
+        status = models.ReviewStatusApproved
+    }
+
+    // 2-5 ditulis dalam satu transaction: review, completion, ledger & course
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 2. Simpan hasil review (status dicek lagi agar tidak dinilai dua kali)
+    tag, err := tx.Exec(ctx, `
+        UPDATE reflection_reviews
+        SET status = $1, rubric_scores = $2, score = $3, reviewer_id = $4, reviewed_at = NOW()
+        WHERE id = $5 AND status = $6`,
+        status, scoresJSON, score, actor.UserID, reviewID, models.ReviewStatusPending)
+
+    if err != nil {
+        return nil, errors.New("gagal menyimpan review: " + err.Error())
+    }
+    if tag.RowsAffected() == 0 {
+        return nil, errors.New("refleksi sudah direview")
+    }
+
+    // 3. Komentar teacher menjadi feedback attempt
+    if comment := strings.TrimSpace(req.Comment); comment != "" {
+        _, err = tx.Exec(ctx, `
+            INSERT INTO submission_feedback (attempt_id, author_id, body)
+            VALUES ($1, $2, $3)`,
+            review.AttemptID, actor.UserID, comment)
+
+        if err != nil {
+            return nil, errors.New("gagal menyimpan feedback: " + err.Error())
+        }
+    }
+
+    if status == models.ReviewStatusApproved {
+        // 4. Tandai stage complete (completion tidak pernah turun, score terbaik disimpan)
+        _, err = tx.Exec(ctx, `
+            UPDATE user_stage_completions
+            SET investigate_completed = true, is_completed = true,
+                completed_at = COALESCE(completed_at, NOW()),
+                score = GREATEST(COALESCE(score, 0), $3),
+                updated_at = NOW()
+            WHERE user_id = $1 AND stage_id = $2`,
+            review.UserID, review.StageID, score)
+
+        if err != nil {
+            return nil, errors.New("gagal update completion: " + err.Error())
+        }
+
+        // 5. Reward (hanya sekali per stage) & course completion
+        reward := stageRewards["investigate"]
+        granted, err := grantReward(ctx, tx, review.UserID, CoinSourceStage, review.StageID,
+            reward.Coins, reward.XP, "investigate stage complete")
+        if err != nil {
+            return nil, err
+        }
+
+        if granted {
+            if err := checkAndLevelUp(ctx, tx, review.UserID); err != nil {
+                return nil, errors.New("gagal update level: " + err.Error())
+            }
+        }
+
+        if err := checkAndCompleteCourse(ctx, tx, review.UserID, review.StageID); err != nil {
+            return nil, errors.New("gagal cek course completion: " + err.Error())
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal menyimpan review: " + err.Error())
+    }
+
+    return getReview(ctx, db, reviewID)
+}
+
+// ═══════════════════════════════════════════════════════════
+// REVIEW STATUS (Student)
+// ═══════════════════════════════════════════════════════════
+
+// GetMyReflectionReview mengambil review refleksi terbaru user di stage (nil jika belum ada)
+func GetMyReflectionReview(ctx context.Context, db *pgxpool.Pool, userID int, stageID int) (*models.ReflectionReview, error) {
+    review, err := scanReview(db.QueryRow(ctx, "SELECT "+reviewColumns+reviewJoins+`
+        WHERE rr.user_id = $1 AND rr.stage_id = $2 AND rr.status <> $3
+        ORDER BY rr.id DESC
+        LIMIT 1`,
+        userID, stageID, models.ReviewStatusSuperseded))
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, nil
+        }
+        return nil, errors.New("gagal mengambil review: " + err.Error())
+    }
+    return review, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// HELPER FUNCTIONS
+// ═══════════════════════════════════════════════════════════
+
+// queueReflectionReview memasukkan attempt INVESTIGATE ke review queue
+// Refleksi lama yang belum dinilai diganti refleksi terbaru (superseded)
+// Rubric stage saat ini di-snapshot ke review (dipakai ScoreReflection)
+func queueReflectionReview(ctx context.Context, q querier, userID int, stageID int, attemptID int, latePenalty int) error {
+    _, err := q.Exec(ctx, `
+        UPDATE reflection_reviews SET status = $1
+        WHERE user_id = $2 AND stage_id = $3 AND status = $4`,
+        models.ReviewStatusSuperseded, userID, stageID, models.ReviewStatusPending)
+
+    if err != nil {
+        return errors.New("gagal update review queue: " + err.Error())
+    }
+
+    _, err = q.Exec(ctx, `
+        INSERT INTO reflection_reviews (attempt_id, user_id, stage_id, late_penalty_percent, rubric)
+        SELECT $1, $2, id, $4, investigate_rubric FROM primm_stages WHERE id = $3`,
+        attemptID, userID, stageID, latePenalty)
+
+    if err != nil {
+        return errors.New("gagal menambah review queue: " + err.Error())
+    }
+
+    return nil
+}
+
+// getReview mengambil satu review refleksi
+func getReview(ctx context.Context, db *pgxpool.Pool, reviewID int) (*models.ReflectionReview, error) {
+    review, err := scanReview(db.QueryRow(ctx, "SELECT "+reviewColumns+reviewJoins+" WHERE rr.id = $1", reviewID))
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("review tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil review: " + err.Error())
+    }
+    return review, nil
+}
+
+// rubricScore menghitung score 0-100 dari poin rubric
+// Stage tanpa rubric: score diisi langsung (wajib untuk approve, default 0 untuk reject)
+func rubricScore(rubric []models.RubricCriterion, req models.ScoreReflectionRequest) (int, error) {
+    if len(rubric) == 0 {
+        if req.Score == nil {
+            if req.Decision == models.ReviewDecisionApprove {
+                return 0, errors.New("score wajib diisi untuk stage tanpa rubric")
+            }
+            return 0, nil
+        }
+        return *req.Score, nil
+    }
+
+    if len(req.RubricScores) != len(rubric) {
+        return 0, errors.New("rubric_scores harus diisi untuk setiap kriteria rubric")
+    }
+
+    points, maxPoints := 0, 0
+    for i, criterion := range rubric {
+        if req.RubricScores[i] > criterion.MaxPoints {
+            return 0, errors.New("poin rubric melebihi max_points kriteria")
+        }
+        points += req.RubricScores[i]
+        maxPoints += criterion.MaxPoints
+    }
+
+    return points * 100 / maxPoints, nil
+}
+
+// marshalRubric mengubah rubric ke JSON (nil jika rubric kosong)
+func marshalRubric(rubric []models.RubricCriterion) ([]byte, error) {
+    if len(rubric) == 0 {
+        return nil, nil
+    }
+    rubricJSON, err := json.Marshal(rubric)
+    if err != nil {
+        return nil, errors.New("gagal serialize rubric: " + err.Error())
+    }
+    return rubricJSON, nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgconn"
+)
+
+// ═══════════════════════════════════════════════════════════
+// COIN LEDGER
+// Saldo coins user = SUM(amount) di coin_transactions
+// Unique (user_id, source_type, source_id) menjamin reward hanya dibayar sekali
+// ═══════════════════════════════════════════════════════════
+
+// Source type transaksi coin
+const (
+    CoinSourceStage  = "stage"  // Reward complete PRIMM stage (source_id = stage_id)
+    CoinSourceCourse = "course" // Bonus complete course (source_id = course_id)
+)
+
+// userCoinBalanceSQL menghitung saldo coins dari ledger (dipakai di SELECT dari tabel users)
+const userCoinBalanceSQL = "COALESCE((SELECT SUM(ct.amount) FROM coin_transactions ct WHERE ct.user_id = users.id), 0)"
+
+// querier adalah method yang dimiliki *pgx.Conn maupun pgx.Tx
+// sehingga helper bisa dipakai di dalam atau di luar transaction
+type querier interface {
+    Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+    Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+    QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// grantReward mencatat reward di ledger lalu menambah XP user
+// Return false jika reward untuk source yang sama sudah pernah dibayar
+func grantReward(ctx context.Context, q querier, userID int, sourceType string, sourceID int, coins int, xp int, description string) (bool, error) {
+    tag, err := q.Exec(ctx, `
+        INSERT INTO coin_transactions (user_id, source_type, source_id, amount, xp, description)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, source_type, source_id) DO NOTHING`,
+        userID, sourceType, sourceID, coins, xp, description)
+
+    if err != nil {
+        return false, errors.New("gagal mencatat transaksi coin: " + err.Error())
+    }
+
+    // Sudah pernah dibayar
+    if tag.RowsAffected() == 0 {
+        return false, nil
+    }
+
+    if xp > 0 {
+        _, err = q.Exec(ctx, `
+            UPDATE users
+            SET experience_points = experience_points + $1, updated_at = NOW()
+            WHERE id = $2`,
+            xp, userID)
+
+        if err != nil {
+            return false, errors.New("gagal memberikan reward: " + err.Error())
+        }
+    }
+
+    return true, nil
+}
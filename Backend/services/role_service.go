@@ -0,0 +1,219 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// ROLE PROVISIONING
+// Invite code untuk registrasi teacher/admin + promote/demote oleh admin
+// Semua perubahan role dicatat di role_audit_log
+// ═══════════════════════════════════════════════════════════
+
+// defaultInviteExpiry adalah masa berlaku invite code jika tidak diisi
+const defaultInviteExpiry = 72 * time.Hour
+
+// CreateRoleInvite membuat invite code sekali pakai
+// createdBy nil = dibuat lewat CLI (bootstrap admin pertama)
+func CreateRoleInvite(ctx context.Context, db *pgxpool.Pool, createdBy *int, req models.CreateInviteRequest) (*models.RoleInvite, error) {
+    code, codeHash, err := generateToken()
+    if err != nil {
+        return nil, errors.New("gagal generate invite code: " + err.Error())
+    }
+
+    expiry := defaultInviteExpiry
+    if req.ExpiresInHours > 0 {
+        expiry = time.Duration(req.ExpiresInHours) * time.Hour
+    }
+
+    invite := models.RoleInvite{Code: code}
+    err = db.QueryRow(ctx, `
+        INSERT INTO role_invites (code_hash, role, created_by, expires_at)
+        VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+        RETURNING id, role, created_by, expires_at, created_at`,
+        codeHash, req.Role, createdBy, expiry.Seconds()).Scan(
+        &invite.ID, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt, &invite.CreatedAt)
+
+    if err != nil {
+        return nil, errors.New("gagal membuat invite: " + err.Error())
+    }
+
+    return &invite, nil
+}
+
+// GetRoleInvites mengambil semua invite (terbaru dulu, code tidak ikut dikirim)
+func GetRoleInvites(ctx context.Context, db *pgxpool.Pool) ([]models.RoleInvite, error) {
+    rows, err := db.Query(ctx, `
+        SELECT id, role, created_by, expires_at, used_by, used_at, created_at
+        FROM role_invites
+        ORDER BY id DESC`)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil invite: " + err.Error())
+    }
+    defer rows.Close()
+
+    invites := []models.RoleInvite{}
+    for rows.Next() {
+        var invite models.RoleInvite
+        err := rows.Scan(&invite.ID, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt,
+            &invite.UsedBy, &invite.UsedAt, &invite.CreatedAt)
+        if err != nil {
+            return nil, errors.New("gagal scan invite: " + err.Error())
+        }
+        invites = append(invites, invite)
+    }
+
+    return invites, nil
+}
+
+// RevokeRoleInvite membatalkan invite yang belum dipakai (langsung expired)
+func RevokeRoleInvite(ctx context.Context, db *pgxpool.Pool, inviteID int) error {
+    tag, err := db.Exec(ctx, `
+        UPDATE role_invites
+        SET expires_at = NOW()
+        WHERE id = $1 AND used_at IS NULL`,
+        inviteID)
+
+    if err != nil {
+        return errors.New("gagal membatalkan invite: " + err.Error())
+    }
+
+    if tag.RowsAffected() == 0 {
+        return errors.New("invite tidak ditemukan atau sudah dipakai")
+    }
+
+    return nil
+}
+
+// lockRoleInvite mengunci invite (FOR UPDATE) dan memastikan masih bisa dipakai
+func lockRoleInvite(ctx context.Context, tx pgx.Tx, code string) (*models.RoleInvite, error) {
+    var invite models.RoleInvite
+    var expired bool
+    err := tx.QueryRow(ctx, `
+        SELECT id, role, created_by, expires_at, used_by, used_at, created_at, expires_at <= NOW()
+        FROM role_invites
+        WHERE code_hash = $1
+        FOR UPDATE`,
+        hashToken(code)).Scan(
+        &invite.ID, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt,
+        &invite.UsedBy, &invite.UsedAt, &invite.CreatedAt, &expired)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("invite code tidak valid")
+        }
+        return nil, errors.New("gagal cek invite code: " + err.Error())
+    }
+
+    if invite.UsedAt != nil {
+        return nil, errors.New("invite code sudah dipakai")
+    }
+    if expired {
+        return nil, errors.New("invite code sudah expired")
+    }
+
+    return &invite, nil
+}
+
+// UpdateUserRole mengubah role user (promote / demote) oleh admin
+// Session user dicabut agar role baru langsung berlaku
+func UpdateUserRole(ctx context.Context, db *pgxpool.Pool, adminID int, userID int, req models.UpdateRoleRequest) (*models.User, error) {
+    if adminID == userID {
+        return nil, errors.New("tidak bisa mengubah role sendiri")
+    }
+
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Ambil role lama (dikunci agar perubahan paralel tercatat berurutan)
+    var oldRole string
+    err = tx.QueryRow(ctx, "SELECT role FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&oldRole)
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("user tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil user: " + err.Error())
+    }
+
+    if oldRole == req.Role {
+        return nil, errors.New("user sudah memiliki role " + req.Role)
+    }
+
+    // 2. Update role + audit log
+    _, err = tx.Exec(ctx, "UPDATE users SET role = $1 WHERE id = $2", req.Role, userID)
+    if err != nil {
+        return nil, errors.New("gagal mengubah role: " + err.Error())
+    }
+
+    err = recordRoleChange(ctx, tx, userID, &oldRole, req.Role, &adminID, nil, req.Reason)
+    if err != nil {
+        return nil, err
+    }
+
+    // 3. Token lama masih membawa role lama -> paksa login ulang
+    if _, err := RevokeUserSessions(ctx, tx, userID); err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal mengubah role: " + err.Error())
+    }
+
+    return GetUserByID(ctx, db, userID)
+}
+
+// GetRoleAuditLog mengambil riwayat perubahan role (userID 0 = semua user)
+func GetRoleAuditLog(ctx context.Context, db *pgxpool.Pool, userID int) ([]models.RoleAuditEntry, error) {
+    rows, err := db.Query(ctx, `
+        SELECT id, user_id, old_role, new_role, changed_by, invite_id, reason, created_at
+        FROM role_audit_log
+        WHERE $1 = 0 OR user_id = $1
+        ORDER BY id DESC`, userID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil audit log: " + err.Error())
+    }
+    defer rows.Close()
+
+    entries := []models.RoleAuditEntry{}
+    for rows.Next() {
+        var entry models.RoleAuditEntry
+        err := rows.Scan(&entry.ID, &entry.UserID, &entry.OldRole, &entry.NewRole,
+            &entry.ChangedBy, &entry.InviteID, &entry.Reason, &entry.CreatedAt)
+        if err != nil {
+            return nil, errors.New("gagal scan audit log: " + err.Error())
+        }
+        entries = append(entries, entry)
+    }
+
+    return entries, nil
+}
+
+// recordRoleChange menambah satu baris ke role_audit_log
+func recordRoleChange(ctx context.Context, q querier, userID int, oldRole *string, newRole string, changedBy *int, inviteID *int, reason string) error {
+    var reasonArg *string
+    if reason != "" {
+        reasonArg = &reason
+    }
+
+    _, err := q.Exec(ctx, `
+        INSERT INTO role_audit_log (user_id, old_role, new_role, changed_by, invite_id, reason)
+        VALUES ($1, $2, $3, $4, $5, $6)`,
+        userID, oldRole, newRole, changedBy, inviteID, reasonArg)
+
+    if err != nil {
+        return errors.New("gagal mencatat audit log: " + err.Error())
+    }
+
+    return nil
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "os"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// SESSIONS & REFRESH TOKENS
+// Access token (JWT) berumur pendek dan membawa session id (claim "sid")
+// Refresh token acak, disimpan sebagai SHA-256 hash, di-rotate setiap dipakai
+// ═══════════════════════════════════════════════════════════
+
+// Default umur token (bisa di-override lewat ACCESS_TOKEN_TTL & REFRESH_TOKEN_TTL)
+const (
+    defaultAccessTokenTTL  = 15 * time.Minute
+    defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// ClientInfo adalah informasi device yang membuat session
+type ClientInfo struct {
+    UserAgent string
+    IPAddress string
+}
+
+// accessTokenTTL membaca umur access token dari environment (contoh: 15m)
+func accessTokenTTL() time.Duration {
+    if d, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil && d > 0 {
+        return d
+    }
+    return defaultAccessTokenTTL
+}
+
+// refreshTokenTTL membaca umur refresh token dari environment (contoh: 720h)
+func refreshTokenTTL() time.Duration {
+    if d, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_TTL")); err == nil && d > 0 {
+        return d
+    }
+    return defaultRefreshTokenTTL
+}
+
+// generateToken membuat token acak (256-bit) beserta hash yang disimpan di database
+// Dipakai untuk refresh token, invite code, dll
+func generateToken() (token string, hash string, err error) {
+    buf := make([]byte, 32)
+    if _, err := rand.Read(buf); err != nil {
+        return "", "", err
+    }
+
+    token = base64.RawURLEncoding.EncodeToString(buf)
+    return token, hashToken(token), nil
+}
+
+// hashToken meng-hash token acak (SHA-256 cukup karena token tidak bisa ditebak)
+func hashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
+
+// issueTokens menyimpan refresh token baru untuk session lalu generate access token
+func issueTokens(ctx context.Context, q querier, user *models.User, sessionID int64) (*models.TokenPair, error) {
+    refreshToken, refreshHash, err := generateToken()
+    if err != nil {
+        return nil, errors.New("gagal generate refresh token: " + err.Error())
+    }
+
+    // Expiry dihitung di database agar konsisten dengan NOW() saat dicek
+    var expiresAt time.Time
+    err = q.QueryRow(ctx, `
+        INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+        VALUES ($1, $2, NOW() + make_interval(secs => $3))
+        RETURNING expires_at`,
+        sessionID, refreshHash, refreshTokenTTL().Seconds()).Scan(&expiresAt)
+
+    if err != nil {
+        return nil, errors.New("gagal menyimpan refresh token: " + err.Error())
+    }
+
+    _, err = q.Exec(ctx, `
+        UPDATE auth_sessions
+        SET expires_at = $1, last_used_at = NOW()
+        WHERE id = $2`,
+        expiresAt, sessionID)
+
+    if err != nil {
+        return nil, errors.New("gagal update session: " + err.Error())
+    }
+
+    accessToken, err := GenerateJWT(user.ID, user.Email, user.Role, sessionID)
+    if err != nil {
+        return nil, errors.New("gagal generate token: " + err.Error())
+    }
+
+    return &models.TokenPair{
+        Token:        accessToken,
+        RefreshToken: refreshToken,
+        ExpiresIn:    int(accessTokenTTL().Seconds()),
+    }, nil
+}
+
+// CreateSession membuat session baru untuk user (dipanggil setelah login berhasil)
+func CreateSession(ctx context.Context, db *pgxpool.Pool, user *models.User, client ClientInfo) (*models.TokenPair, error) {
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    var sessionID int64
+    err = tx.QueryRow(ctx, `
+        INSERT INTO auth_sessions (user_id, user_agent, ip_address, expires_at)
+        VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+        RETURNING id`,
+        user.ID, client.UserAgent, client.IPAddress, refreshTokenTTL().Seconds()).Scan(&sessionID)
+
+    if err != nil {
+        return nil, errors.New("gagal membuat session: " + err.Error())
+    }
+
+    tokens, err := issueTokens(ctx, tx, user, sessionID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal menyimpan session: " + err.Error())
+    }
+
+    return tokens, nil
+}
+
+// RefreshSession menukar refresh token dengan pasangan token baru (rotation)
+// Refresh token yang sudah pernah dipakai mencabut seluruh session
+func RefreshSession(ctx context.Context, db *pgxpool.Pool, refreshToken string) (*models.TokenPair, error) {
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Cari token + session (dikunci agar refresh paralel tidak dobel)
+    var tokenID, sessionID int64
+    var tokenExpired bool
+    var usedAt, revokedAt *time.Time
+    var user models.User
+
+    err = tx.QueryRow(ctx, `
+        SELECT rt.id, rt.session_id, rt.expires_at <= NOW(), rt.used_at, s.revoked_at,
+               u.id, u.email, u.role
+        FROM refresh_tokens rt
+        JOIN auth_sessions s ON rt.session_id = s.id
+        JOIN users u ON s.user_id = u.id
+        WHERE rt.token_hash = $1
+        FOR UPDATE OF rt, s`,
+        hashToken(refreshToken)).Scan(
+        &tokenID, &sessionID, &tokenExpired, &usedAt, &revokedAt,
+        &user.ID, &user.Email, &user.Role)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("refresh token tidak valid")
+        }
+        return nil, errors.New("gagal cek refresh token: " + err.Error())
+    }
+
+    if revokedAt != nil {
+        return nil, errors.New("session sudah berakhir, silakan login ulang")
+    }
+
+    // 2. Token lama dipakai ulang -> kemungkinan dicuri, cabut session
+    if usedAt != nil {
+        _, err = tx.Exec(ctx,
+            "UPDATE auth_sessions SET revoked_at = NOW() WHERE id = $1", sessionID)
+        if err != nil {
+            return nil, errors.New("gagal mencabut session: " + err.Error())
+        }
+        if err := tx.Commit(ctx); err != nil {
+            return nil, errors.New("gagal mencabut session: " + err.Error())
+        }
+        return nil, errors.New("refresh token sudah pernah dipakai, session dicabut")
+    }
+
+    if tokenExpired {
+        return nil, errors.New("session sudah berakhir, silakan login ulang")
+    }
+
+    // 3. Rotate: tandai token lama, terbitkan token baru (role diambil ulang dari database)
+    _, err = tx.Exec(ctx, "UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1", tokenID)
+    if err != nil {
+        return nil, errors.New("gagal rotate refresh token: " + err.Error())
+    }
+
+    tokens, err := issueTokens(ctx, tx, &user, sessionID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal menyimpan refresh token: " + err.Error())
+    }
+
+    return tokens, nil
+}
+
+// RevokeSession mencabut satu session milik user (logout)
+func RevokeSession(ctx context.Context, db *pgxpool.Pool, userID int, sessionID int64) error {
+    _, err := db.Exec(ctx, `
+        UPDATE auth_sessions
+        SET revoked_at = NOW()
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`,
+        sessionID, userID)
+
+    if err != nil {
+        return errors.New("gagal logout: " + err.Error())
+    }
+
+    return nil
+}
+
+// RevokeUserSessions mencabut semua session aktif user (logout dari semua device)
+// Return jumlah session yang dicabut
+func RevokeUserSessions(ctx context.Context, q querier, userID int) (int64, error) {
+    tag, err := q.Exec(ctx, `
+        UPDATE auth_sessions
+        SET revoked_at = NOW()
+        WHERE user_id = $1 AND revoked_at IS NULL`,
+        userID)
+
+    if err != nil {
+        return 0, errors.New("gagal mencabut session: " + err.Error())
+    }
+
+    return tag.RowsAffected(), nil
+}
+
+// IsSessionActive mengecek apakah session milik user belum dicabut / expired
+func IsSessionActive(ctx context.Context, db *pgxpool.Pool, userID int, sessionID int64) (bool, error) {
+    var active bool
+    err := db.QueryRow(ctx, `
+        SELECT revoked_at IS NULL AND expires_at > NOW()
+        FROM auth_sessions
+        WHERE id = $1 AND user_id = $2`,
+        sessionID, userID).Scan(&active)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return false, nil
+        }
+        return false, errors.New("gagal cek session: " + err.Error())
+    }
+
+    return active, nil
+}
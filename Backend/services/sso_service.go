@@ -0,0 +1,250 @@
+package services
+
+import (
+    "context"
+    "crypto/subtle"
+    "errors"
+    "net/url"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+    "primmfy_db/oidc"
+)
+
+// ═══════════════════════════════════════════════════════════
+// OIDC SINGLE SIGN-ON
+// Login lewat identity provider sekolah (authorization code + PKCE)
+// Identity di-link ke user lewat (issuer, subject); login pertama di-link
+// berdasarkan email terverifikasi atau membuat user student baru
+// Hasil akhirnya session & JWT yang sama dengan /api/login
+// ═══════════════════════════════════════════════════════════
+
+// OIDCStateTTL adalah batas waktu user menyelesaikan login di provider
+// (juga umur cookie state di browser)
+const OIDCStateTTL = 10 * time.Minute
+
+// OIDCStateCookie adalah cookie (HttpOnly, SameSite=Lax) berisi state login SSO
+// Callback hanya diterima dari browser yang memulai login (mencegah login CSRF)
+const OIDCStateCookie = "primmfy_oidc_state"
+
+// StartOIDCLogin membuat state, nonce & PKCE code verifier lalu return URL login provider
+// State juga dikembalikan untuk disimpan di cookie OIDCStateCookie oleh handler
+func StartOIDCLogin(ctx context.Context, db *pgxpool.Pool, provider *oidc.Provider) (authURL string, state string, err error) {
+    state, stateHash, err := generateToken()
+    if err != nil {
+        return "", "", errors.New("gagal generate state: " + err.Error())
+    }
+    nonce, err := oidc.RandomString()
+    if err != nil {
+        return "", "", errors.New("gagal generate nonce: " + err.Error())
+    }
+    codeVerifier, err := oidc.RandomString()
+    if err != nil {
+        return "", "", errors.New("gagal generate code verifier: " + err.Error())
+    }
+
+    authURL, err = provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+    if err != nil {
+        return "", "", err
+    }
+
+    // Bersihkan state lama yang tidak pernah diselesaikan
+    _, err = db.Exec(ctx, "DELETE FROM oidc_login_states WHERE expires_at <= NOW()")
+    if err != nil {
+        return "", "", errors.New("gagal membersihkan login state: " + err.Error())
+    }
+
+    _, err = db.Exec(ctx, `
+        INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at)
+        VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))`,
+        stateHash, nonce, codeVerifier, OIDCStateTTL.Seconds())
+
+    if err != nil {
+        return "", "", errors.New("gagal menyimpan login state: " + err.Error())
+    }
+
+    return authURL, state, nil
+}
+
+// CompleteOIDCLogin menangani callback provider: validasi state (harus sama dengan
+// cookie browser), tukar code, verifikasi ID token, cari / link / buat user,
+// lalu buat session baru
+func CompleteOIDCLogin(ctx context.Context, db *pgxpool.Pool, provider *oidc.Provider, code string, state string, stateCookie string, client ClientInfo) (*models.LoginResponse, error) {
+    // 1. State harus berasal dari browser yang memulai login, baru dikonsumsi
+    if stateCookie == "" || subtle.ConstantTimeCompare([]byte(state), []byte(stateCookie)) != 1 {
+        return nil, errors.New("login state tidak cocok dengan browser ini")
+    }
+
+    // Ambil & hapus state (sekali pakai)
+    var nonce, codeVerifier string
+    var expired bool
+    err := db.QueryRow(ctx, `
+        DELETE FROM oidc_login_states
+        WHERE state_hash = $1
+        RETURNING nonce, code_verifier, expires_at <= NOW()`,
+        hashToken(state)).Scan(&nonce, &codeVerifier, &expired)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("login state tidak valid")
+        }
+        return nil, errors.New("gagal cek login state: " + err.Error())
+    }
+    if expired {
+        return nil, errors.New("login state sudah expired")
+    }
+
+    // 2. Tukar authorization code (dengan PKCE code verifier) lalu verifikasi ID token
+    tokens, err := provider.Exchange(ctx, code, codeVerifier)
+    if err != nil {
+        return nil, err
+    }
+
+    claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, nonce)
+    if err != nil {
+        return nil, err
+    }
+
+    // 3. Cari / link / buat user
+    userID, err := resolveOIDCUser(ctx, db, claims)
+    if err != nil {
+        return nil, err
+    }
+
+    user, err := GetUserByID(ctx, db, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    // 4. Buat session + generate token (sama dengan login password)
+    sessionTokens, err := CreateSession(ctx, db, user, client)
+    if err != nil {
+        return nil, err
+    }
+
+    return &models.LoginResponse{
+        Message:      "Login berhasil!",
+        Token:        sessionTokens.Token,
+        RefreshToken: sessionTokens.RefreshToken,
+        ExpiresIn:    sessionTokens.ExpiresIn,
+        User:         user,
+    }, nil
+}
+
+// resolveOIDCUser mengembalikan user ID untuk identity dari provider
+// Urutan: identity yang sudah ter-link -> user dengan email yang sama -> user student baru
+func resolveOIDCUser(ctx context.Context, db *pgxpool.Pool, claims *oidc.Claims) (int, error) {
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return 0, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 1. Identity sudah pernah login
+    var userID int
+    err = tx.QueryRow(ctx, `
+        UPDATE user_identities
+        SET last_login_at = NOW(), email = COALESCE(NULLIF($3, ''), email)
+        WHERE issuer = $1 AND subject = $2
+        RETURNING user_id`,
+        claims.Issuer, claims.Subject, claims.Email).Scan(&userID)
+
+    if err == nil {
+        if err := tx.Commit(ctx); err != nil {
+            return 0, errors.New("gagal menyimpan identity: " + err.Error())
+        }
+        return userID, nil
+    }
+    if err != pgx.ErrNoRows {
+        return 0, errors.New("gagal cek identity: " + err.Error())
+    }
+
+    // 2. Login pertama: hanya email terverifikasi yang boleh di-link / dibuat
+    if err := checkOIDCEmail(claims); err != nil {
+        return 0, err
+    }
+
+    // Email sudah diverifikasi provider, jadi sekalian tandai terverifikasi
+    err = tx.QueryRow(ctx, `
+        UPDATE users
+        SET email_verified_at = COALESCE(email_verified_at, NOW())
+        WHERE LOWER(email) = LOWER($1)
+        RETURNING id`,
+        claims.Email).Scan(&userID)
+
+    if err == pgx.ErrNoRows {
+        userID, err = provisionOIDCUser(ctx, tx, claims)
+        if err != nil {
+            return 0, err
+        }
+    } else if err != nil {
+        return 0, errors.New("gagal cek email: " + err.Error())
+    }
+
+    _, err = tx.Exec(ctx, `
+        INSERT INTO user_identities (user_id, issuer, subject, email)
+        VALUES ($1, $2, $3, $4)`,
+        userID, claims.Issuer, claims.Subject, claims.Email)
+
+    if err != nil {
+        return 0, errors.New("gagal menyimpan identity: " + err.Error())
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return 0, errors.New("gagal menyimpan identity: " + err.Error())
+    }
+
+    return userID, nil
+}
+
+// checkOIDCEmail memastikan email identity boleh dipakai untuk link / membuat user
+// (email yang belum diverifikasi provider bisa dipakai untuk mengambil alih akun)
+func checkOIDCEmail(claims *oidc.Claims) error {
+    if claims.Email == "" {
+        return errors.New("identity provider tidak mengirim email")
+    }
+    if !claims.EmailVerified {
+        return errors.New("email dari identity provider belum terverifikasi")
+    }
+    return nil
+}
+
+// provisionOIDCUser membuat user student baru dari identity provider
+// Password diisi acak (user tetap bisa set password lewat forgot password)
+func provisionOIDCUser(ctx context.Context, tx pgx.Tx, claims *oidc.Claims) (int, error) {
+    randomPassword, _, err := generateToken()
+    if err != nil {
+        return 0, errors.New("gagal generate password: " + err.Error())
+    }
+    hashedPassword, err := HashPassword(randomPassword)
+    if err != nil {
+        return 0, errors.New("gagal hash password: " + err.Error())
+    }
+
+    fullName := strings.TrimSpace(claims.Name)
+    if fullName == "" {
+        fullName = strings.Split(claims.Email, "@")[0]
+    }
+
+    var userID int
+    err = tx.QueryRow(ctx, `
+        INSERT INTO users (email, password_hash, full_name, role, level, experience_points, email_verified_at)
+        VALUES ($1, $2, $3, $4, 1, 0, NOW())
+        RETURNING id`,
+        claims.Email, hashedPassword, fullName, models.RoleStudent).Scan(&userID)
+
+    if err != nil {
+        return 0, errors.New("gagal membuat user: " + err.Error())
+    }
+
+    return userID, nil
+}
+
+// OIDCFrontendRedirect membuat URL halaman callback frontend (/oidc/callback)
+// Hasil login dikirim lewat URL fragment agar tidak tercatat di log server / Referer
+func OIDCFrontendRedirect(values url.Values) string {
+    return appBaseURL() + "/oidc/callback#" + values.Encode()
+}
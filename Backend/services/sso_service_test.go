@@ -0,0 +1,198 @@
+package services
+
+import (
+    "context"
+    "net/url"
+    "os"
+    "testing"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/migrations"
+    "primmfy_db/oidc"
+    "primmfy_db/oidc/oidctest"
+)
+
+func TestCheckOIDCEmail(t *testing.T) {
+    cases := []struct {
+        name    string
+        claims  oidc.Claims
+        wantErr bool
+    }{
+        {"verified", oidc.Claims{Email: "siswa@sekolah.sch.id", EmailVerified: true}, false},
+        {"unverified", oidc.Claims{Email: "siswa@sekolah.sch.id"}, true},
+        {"tanpa email", oidc.Claims{EmailVerified: true}, true},
+    }
+
+    for _, tc := range cases {
+        if err := checkOIDCEmail(&tc.claims); (err != nil) != tc.wantErr {
+            t.Errorf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+        }
+    }
+}
+
+// State yang tidak sama dengan cookie browser ditolak sebelum menyentuh database
+func TestCompleteOIDCLoginRejectsStateCookieMismatch(t *testing.T) {
+    for _, cookie := range []string{"", "state-lain"} {
+        _, err := CompleteOIDCLogin(context.Background(), nil, nil, "code", "state-123", cookie, ClientInfo{})
+        if err == nil || err.Error() != "login state tidak cocok dengan browser ini" {
+            t.Fatalf("cookie %q: err = %v", cookie, err)
+        }
+    }
+}
+
+// ═══════════════════════════════════════════════════════════
+// ALUR LENGKAP (butuh PostgreSQL)
+// Jalankan dengan TEST_DATABASE_URL berisi database kosong khusus test
+// ═══════════════════════════════════════════════════════════
+
+func newSSOTestEnv(t *testing.T) (*pgxpool.Pool, *oidctest.Server, *oidc.Provider) {
+    t.Helper()
+
+    dbURL := os.Getenv("TEST_DATABASE_URL")
+    if dbURL == "" {
+        t.Skip("TEST_DATABASE_URL tidak di-set")
+    }
+    if os.Getenv("JWT_SECRET") == "" {
+        t.Setenv("JWT_SECRET", "test-secret")
+    }
+
+    ctx := context.Background()
+    db, err := pgxpool.New(ctx, dbURL)
+    if err != nil {
+        t.Fatalf("gagal koneksi database: %v", err)
+    }
+    t.Cleanup(db.Close)
+
+    if _, err := migrations.Up(ctx, db); err != nil {
+        t.Fatalf("gagal menjalankan migrasi: %v", err)
+    }
+
+    server, err := oidctest.NewServer("primmfy")
+    if err != nil {
+        t.Fatalf("gagal menjalankan mock provider: %v", err)
+    }
+    t.Cleanup(server.Close)
+
+    provider := oidc.NewProvider(oidc.Config{
+        Issuer:      server.Issuer(),
+        ClientID:    "primmfy",
+        RedirectURL: "http://localhost:8080/api/auth/oidc/callback",
+    })
+    return db, server, provider
+}
+
+// ssoLogin menjalankan login SSO dari StartOIDCLogin sampai CompleteOIDCLogin
+func ssoLogin(t *testing.T, db *pgxpool.Pool, server *oidctest.Server, provider *oidc.Provider, identity oidctest.Identity) (int, error) {
+    t.Helper()
+    ctx := context.Background()
+
+    authURL, state, err := StartOIDCLogin(ctx, db, provider)
+    if err != nil {
+        t.Fatalf("StartOIDCLogin: %v", err)
+    }
+    code, returnedState, err := server.Authorize(authURL, identity, nil)
+    if err != nil {
+        t.Fatalf("Authorize: %v", err)
+    }
+
+    response, err := CompleteOIDCLogin(ctx, db, provider, code, returnedState, state, ClientInfo{UserAgent: "test"})
+    if err != nil {
+        return 0, err
+    }
+    return response.User.ID, nil
+}
+
+func createSSOTestUser(t *testing.T, db *pgxpool.Pool, email string) int {
+    t.Helper()
+    ctx := context.Background()
+
+    var userID int
+    err := db.QueryRow(ctx, `
+        INSERT INTO users (email, password_hash, full_name, role)
+        VALUES ($1, 'x', 'User Lama', 'student')
+        RETURNING id`, email).Scan(&userID)
+    if err != nil {
+        t.Fatalf("gagal membuat user: %v", err)
+    }
+    t.Cleanup(func() { db.Exec(ctx, "DELETE FROM users WHERE id = $1", userID) })
+    return userID
+}
+
+func TestOIDCLoginLinksVerifiedEmail(t *testing.T) {
+    db, server, provider := newSSOTestEnv(t)
+    existingID := createSSOTestUser(t, db, "link-verified@sekolah.sch.id")
+
+    identity := oidctest.Identity{Subject: "sub-verified", Email: "Link-Verified@sekolah.sch.id", EmailVerified: true}
+    userID, err := ssoLogin(t, db, server, provider, identity)
+    if err != nil {
+        t.Fatalf("login: %v", err)
+    }
+    if userID != existingID {
+        t.Fatalf("identity di-link ke user %d, want %d", userID, existingID)
+    }
+
+    // Login berikutnya memakai identity yang sudah ter-link
+    userID, err = ssoLogin(t, db, server, provider, identity)
+    if err != nil || userID != existingID {
+        t.Fatalf("login kedua: user %d, err %v", userID, err)
+    }
+}
+
+func TestOIDCLoginRejectsUnverifiedEmailLink(t *testing.T) {
+    db, server, provider := newSSOTestEnv(t)
+    createSSOTestUser(t, db, "link-unverified@sekolah.sch.id")
+
+    identity := oidctest.Identity{Subject: "sub-unverified", Email: "link-unverified@sekolah.sch.id"}
+    if _, err := ssoLogin(t, db, server, provider, identity); err == nil {
+        t.Fatal("email belum terverifikasi tidak boleh di-link ke akun yang ada")
+    }
+
+    var linked int
+    db.QueryRow(context.Background(), `
+        SELECT COUNT(*) FROM user_identities WHERE issuer = $1 AND subject = $2`,
+        server.Issuer(), identity.Subject).Scan(&linked)
+    if linked != 0 {
+        t.Fatal("identity tidak boleh tersimpan")
+    }
+}
+
+func TestOIDCLoginProvisionsNewStudent(t *testing.T) {
+    db, server, provider := newSSOTestEnv(t)
+    ctx := context.Background()
+
+    identity := oidctest.Identity{Subject: "sub-new", Email: "siswa-baru@sekolah.sch.id", EmailVerified: true, Name: "Siswa Baru"}
+    userID, err := ssoLogin(t, db, server, provider, identity)
+    if err != nil {
+        t.Fatalf("login: %v", err)
+    }
+    t.Cleanup(func() { db.Exec(ctx, "DELETE FROM users WHERE id = $1", userID) })
+
+    var role, fullName string
+    db.QueryRow(ctx, "SELECT role, full_name FROM users WHERE id = $1", userID).Scan(&role, &fullName)
+    if role != "student" || fullName != "Siswa Baru" {
+        t.Fatalf("user baru: role %q, nama %q", role, fullName)
+    }
+}
+
+func TestOIDCLoginStateIsSingleUse(t *testing.T) {
+    db, server, provider := newSSOTestEnv(t)
+    ctx := context.Background()
+
+    authURL, state, err := StartOIDCLogin(ctx, db, provider)
+    if err != nil {
+        t.Fatalf("StartOIDCLogin: %v", err)
+    }
+    identity := oidctest.Identity{Subject: "sub-replay", Email: "replay@sekolah.sch.id", EmailVerified: true}
+    code, _, _ := server.Authorize(authURL, identity, nil)
+
+    response, err := CompleteOIDCLogin(ctx, db, provider, code, state, state, ClientInfo{})
+    if err != nil {
+        t.Fatalf("login: %v", err)
+    }
+    t.Cleanup(func() { db.Exec(ctx, "DELETE FROM users WHERE id = $1", response.User.ID) })
+
+    parsed, _ := url.Parse(authURL)
+    if _, err := CompleteOIDCLogin(ctx, db, provider, code, parsed.Query().Get("state"), state, ClientInfo{}); err == nil {
+        t.Fatal("state yang sama tidak boleh dipakai dua kali")
+    }
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// SUBMISSION ENGINE
+// Semua route submit (/submit dan /submit-*) diproses di sini
+// dan ditulis ke user_stage_completions & user_course_completions
+// ═══════════════════════════════════════════════════════════
+
+// stageReward adalah reward coins & XP per tipe stage
+type stageReward struct {
+    Coins int
+    XP    int
+}
+
+var stageRewards = map[string]stageReward{
+    "predict":     {Coins: 50, XP: 20},
+    "run":         {Coins: 50, XP: 20},
+    "investigate": {Coins: 30, XP: 15}, // Diberikan saat refleksi di-approve teacher
+    "modify":      {Coins: 75, XP: 30},
+    "make":        {Coins: 100, XP: 50}, // Highest reward untuk MAKE stage
+}
+
+// StageAnswer adalah jawaban student untuk satu stage (semua tipe stage)
+type StageAnswer struct {
+    StageID        int
+    StageType      string // predict, run, investigate, modify, make
+    SelectedAnswer string // PREDICT
+    Code           string // RUN, MODIFY, MAKE
+    Reflection     string // INVESTIGATE
+    SubmittedAt    *time.Time // Waktu submit untuk cek deadline assignment (nil = sekarang)
+
+    // Finalize (opsional) dipanggil di dalam transaction tepat sebelum commit,
+    // dipakai GradingQueue untuk menandai submission 'graded' secara atomik
+    Finalize func(ctx context.Context, tx pgx.Tx, response *models.SubmitStageResponse) error
+}
+
+// stageSubmission adalah hasil engine: response untuk student + record completion
+type stageSubmission struct {
+    Response     *models.SubmitStageResponse
+    CompletionID int
+    Data         map[string]interface{} // Ringkasan jawaban (untuk response /submit)
+    SubmittedAt  time.Time
+}
+
+// gradedAnswer adalah hasil penilaian jawaban sebelum disimpan
+type gradedAnswer struct {
+    IsCorrect     bool
+    Score         int
+    IsLate        bool // Masuk setelah due_at assignment
+    PendingReview bool // Refleksi INVESTIGATE masuk review queue (belum complete)
+    Message       string
+    Output        string
+    CompileError  string
+    TestResults   []models.TestCaseResult
+    Data          map[string]interface{}
+}
+
+// submitStageAnswer menilai jawaban, menyimpan completion, memberi reward
+// dan mengecek course completion
+func submitStageAnswer(ctx context.Context, db *pgxpool.Pool, userID int, answer StageAnswer) (*stageSubmission, error) {
+    // 1. Ambil stage (termasuk test case hidden)
+    stage, err := getSubmissionStage(ctx, db, answer.StageID)
+    if err != nil {
+        if err.Error() == "stage tidak ditemukan" {
+            return nil, errors.New(answer.StageType + " stage tidak ditemukan")
+        }
+        return nil, err
+    }
+    if stage.StageType != answer.StageType {
+        return nil, errors.New(answer.StageType + " stage tidak ditemukan")
+    }
+
+    // 2. Cek jadwal assignment kelas (belum dibuka / lewat deadline dengan policy block)
+    window, err := checkAssignmentWindow(ctx, db, userID, stage.ID, answer.SubmittedAt)
+    if err != nil {
+        return nil, err
+    }
+
+    // 3. Nilai jawaban sesuai tipe stage, lalu terapkan penalty keterlambatan
+    graded, err := gradeStageAnswer(ctx, db, stage, answer)
+    if err != nil {
+        return nil, err
+    }
+    graded.IsLate = window != nil && window.IsLate
+    latePenalty := applyLatePenalty(window, graded)
+
+    // 4-7 ditulis dalam satu transaction: completion, attempt, ledger & course
+    tx, err := db.Begin(ctx)
+    if err != nil {
+        return nil, errors.New("gagal memulai transaction: " + err.Error())
+    }
+    defer tx.Rollback(ctx)
+
+    // 4. Simpan ke user_stage_completions
+    completionID, submittedAt, err := saveStageCompletion(ctx, tx, userID, answer, graded)
+    if err != nil {
+        return nil, err
+    }
+
+    // 5. Simpan ke riwayat stage_attempts (append-only)
+    //    Refleksi INVESTIGATE masuk review queue, reward menunggu approval teacher
+    attemptID, err := recordStageAttempt(ctx, tx, userID, answer, graded)
+    if err != nil {
+        return nil, err
+    }
+    if graded.PendingReview {
+        if err := queueReflectionReview(ctx, tx, userID, stage.ID, attemptID, latePenalty); err != nil {
+            return nil, err
+        }
+    }
+
+    response := &models.SubmitStageResponse{
+        Success:       true,
+        IsCorrect:     graded.IsCorrect,
+        Message:       graded.Message,
+        Score:         graded.Score,
+        IsLate:        graded.IsLate,
+        LatePenalty:   latePenalty,
+        AttemptID:     attemptID,
+        PendingReview: graded.PendingReview,
+        Output:        graded.Output,
+        CompileError:  graded.CompileError,
+        TestResults:   graded.TestResults,
+    }
+
+    if graded.IsCorrect {
+        // 6. Berikan reward (hanya sekali per stage, dijamin oleh ledger)
+        reward := stageRewards[stage.StageType]
+        granted, err := grantReward(ctx, tx, userID, CoinSourceStage, stage.ID,
+            reward.Coins, reward.XP, stage.StageType+" stage complete")
+        if err != nil {
+            return nil, err
+        }
+
+        if granted {
+            response.CoinsEarned = reward.Coins
+            response.XPEarned = reward.XP
+
+            if err := checkAndLevelUp(ctx, tx, userID); err != nil {
+                return nil, errors.New("gagal update level: " + err.Error())
+            }
+        }
+
+        // 7. Cek apakah semua stage di course sudah complete
+        if err := checkAndCompleteCourse(ctx, tx, userID, stage.ID); err != nil {
+            return nil, errors.New("gagal cek course completion: " + err.Error())
+        }
+    }
+
+    if answer.Finalize != nil {
+        if err := answer.Finalize(ctx, tx, response); err != nil {
+            return nil, err
+        }
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, errors.New("gagal menyimpan submission: " + err.Error())
+    }
+
+    return &stageSubmission{
+        Response:     response,
+        CompletionID: completionID,
+        Data:         graded.Data,
+        SubmittedAt:  submittedAt,
+    }, nil
+}
+
+// getSubmissionStage mengambil data stage yang dibutuhkan untuk grading
+// Berbeda dengan GetStageByID, test case hidden tidak dihapus
+func getSubmissionStage(ctx context.Context, db *pgxpool.Pool, stageID int) (*models.PRIMMStage, error) {
+    var stage models.PRIMMStage
+    var modifyTestCasesJSON, makeTestCasesJSON []byte
+
+    err := db.QueryRow(ctx, `
+        SELECT id, course_id, stage_type, code_snippet, correct_answer,
+               run_code_template, modify_test_cases, make_test_cases
+        FROM primm_stages WHERE id = $1`, stageID).Scan(
+        &stage.ID, &stage.CourseID, &stage.StageType, &stage.CodeSnippet, &stage.CorrectAnswer,
+        &stage.RunCodeTemplate, &modifyTestCasesJSON, &makeTestCasesJSON)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("stage tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil stage: " + err.Error())
+    }
+
+    if modifyTestCasesJSON != nil {
+        if err := json.Unmarshal(modifyTestCasesJSON, &stage.ModifyTestCases); err != nil {
+            return nil, errors.New("gagal parse test cases: " + err.Error())
+        }
+    }
+    if makeTestCasesJSON != nil {
+        if err := json.Unmarshal(makeTestCasesJSON, &stage.MakeTestCases); err != nil {
+            return nil, errors.New("gagal parse test cases: " + err.Error())
+        }
+    }
+
+    return &stage, nil
+}
+
+// gradeStageAnswer menilai jawaban student sesuai tipe stage
+func gradeStageAnswer(ctx context.Context, db *pgxpool.Pool, stage *models.PRIMMStage, answer StageAnswer) (*gradedAnswer, error) {
+    switch stage.StageType {
+    case "predict":
+        if stage.CorrectAnswer == nil {
+            return nil, errors.New("stage tidak memiliki correct_answer")
+        }
+        graded := &gradedAnswer{
+            IsCorrect: answer.SelectedAnswer == *stage.CorrectAnswer,
+            Message:   "Jawaban salah. Coba lagi!",
+            Data: map[string]interface{}{
+                "selected_answer": answer.SelectedAnswer,
+                "correct_answer":  *stage.CorrectAnswer,
+            },
+        }
+        if graded.IsCorrect {
+            graded.Score = 100
+            graded.Message = "Jawaban benar! Selamat!"
+        }
+        return graded, nil
+
+    case "run":
+        // Code dijalankan di server (output dari client tidak dipakai)
+        run, err := executeRunStage(ctx, db, stage.ID, runStageTemplate(stage.RunCodeTemplate, stage.CodeSnippet), answer.Code)
+        if err != nil {
+            return nil, err
+        }
+        graded := &gradedAnswer{
+            IsCorrect: run.IsCorrect,
+            Message:   run.Message,
+            Output:    run.Output,
+            Data: map[string]interface{}{
+                "submitted_code":   answer.Code,
+                "code_output":      run.Output,
+                "matches_template": run.MatchesTemplate,
+            },
+        }
+        if graded.IsCorrect {
+            graded.Score = 100
+        }
+        return graded, nil
+
+    case "investigate":
+        // Refleksi dinilai manual oleh teacher (rubric) di review queue
+        graded := &gradedAnswer{
+            Message: "Refleksi tidak boleh kosong",
+            Data: map[string]interface{}{
+                "reflection_text": answer.Reflection,
+            },
+        }
+        if strings.TrimSpace(answer.Reflection) != "" {
+            graded.PendingReview = true
+            graded.Message = "Refleksi terkirim! Menunggu review teacher"
+            graded.Data["status"] = models.ReviewStatusPending
+        }
+        return graded, nil
+
+    case "modify", "make":
+        testCases := stage.ModifyTestCases
+        codeKey := "modified_code"
+        successMessage := "Code berhasil passed semua test cases! Selamat!"
+        if stage.StageType == "make" {
+            testCases = stage.MakeTestCases
+            codeKey = "code"
+            successMessage = "Code berhasil passed semua test cases! Excellent work!"
+        }
+
+        // Jalankan code student di sandbox untuk setiap test case
+        report, err := gradeStageCode(ctx, db, stage.ID, answer.Code, testCases)
+        if err != nil {
+            return nil, err
+        }
+
+        graded := &gradedAnswer{
+            IsCorrect:    report.AllPassed,
+            Score:        report.Score,
+            Message:      "Code tidak passed test cases. Coba lagi!",
+            Output:       report.Summary(),
+            CompileError: report.CompileError,
+            TestResults:  report.Feedback(testCases),
+        }
+        graded.Data = map[string]interface{}{
+            codeKey:  answer.Code,
+            "output": graded.Output,
+        }
+        if graded.IsCorrect {
+            graded.Message = successMessage
+        }
+        return graded, nil
+
+    default:
+        return nil, errors.New("tipe stage tidak valid")
+    }
+}
+
+// saveStageCompletion menyimpan jawaban terakhir ke user_stage_completions
+// Completion tidak pernah turun: stage yang sudah complete tetap complete
+// walaupun submission berikutnya salah, dan score yang disimpan adalah score terbaik
+func saveStageCompletion(ctx context.Context, q querier, userID int, answer StageAnswer, graded *gradedAnswer) (int, time.Time, error) {
+    // Kolom jawaban per tipe stage (nama kolom konstan, bukan input user)
+    var columns []string
+    var values []interface{}
+    var updates []string
+
+    switch answer.StageType {
+    case "predict":
+        columns = []string{"predict_selected_answer", "predict_is_correct"}
+        values = []interface{}{answer.SelectedAnswer, graded.IsCorrect}
+    case "run":
+        columns = []string{"run_submitted_code", "run_output"}
+        values = []interface{}{answer.Code, graded.Output}
+    case "investigate":
+        columns = []string{"investigate_reflection", "investigate_completed"}
+        values = []interface{}{answer.Reflection, graded.IsCorrect}
+        // Refleksi yang sudah di-approve tetap complete walaupun student mengirim refleksi baru
+        updates = append(updates, "investigate_completed = COALESCE(user_stage_completions.investigate_completed, false) OR EXCLUDED.investigate_completed")
+    case "modify", "make":
+        prefix := answer.StageType
+        columns = []string{prefix + "_submitted_code", prefix + "_output", prefix + "_is_correct", prefix + "_attempts"}
+        values = []interface{}{answer.Code, graded.Output, graded.IsCorrect, 1}
+        updates = append(updates, fmt.Sprintf("%s_attempts = COALESCE(user_stage_completions.%s_attempts, 0) + 1", prefix, prefix))
+    default:
+        return 0, time.Time{}, errors.New("tipe stage tidak valid")
+    }
+
+    placeholders := make([]string, len(columns))
+    for i, column := range columns {
+        placeholders[i] = fmt.Sprintf("$%d", i+6)
+        if !strings.HasSuffix(column, "_attempts") && column != "investigate_completed" {
+            updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+        }
+    }
+
+    var completedAt *time.Time
+    if graded.IsCorrect {
+        now := time.Now()
+        completedAt = &now
+    }
+
+    query := fmt.Sprintf(`
+        INSERT INTO user_stage_completions
+        (user_id, stage_id, score, is_completed, completed_at, %s)
+        VALUES ($1, $2, $3, $4, $5, %s)
+        ON CONFLICT (user_id, stage_id) DO UPDATE SET
+            %s,
+            score = GREATEST(COALESCE(user_stage_completions.score, 0), EXCLUDED.score),
+            is_completed = COALESCE(user_stage_completions.is_completed, false) OR EXCLUDED.is_completed,
+            completed_at = COALESCE(user_stage_completions.completed_at, EXCLUDED.completed_at),
+            updated_at = NOW()
+        RETURNING id, updated_at`,
+        strings.Join(columns, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ",\n            "))
+
+    args := append([]interface{}{userID, answer.StageID, graded.Score, graded.IsCorrect, completedAt}, values...)
+
+    var completionID int
+    var submittedAt time.Time
+    err := q.QueryRow(ctx, query, args...).Scan(&completionID, &submittedAt)
+    if err != nil {
+        return 0, time.Time{}, errors.New("gagal menyimpan submission: " + err.Error())
+    }
+
+    return completionID, submittedAt, nil
+}
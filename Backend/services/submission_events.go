@@ -0,0 +1,88 @@
+package services
+
+import (
+    "sync"
+
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// SUBMISSION EVENTS (SSE)
+// ═══════════════════════════════════════════════════════════
+
+// Tipe event submission yang dikirim ke client
+const (
+    SubmissionEventStatus     = "status"
+    SubmissionEventTestResult = "test_result"
+)
+
+// subscriberBuffer adalah kapasitas channel per subscriber
+// Event dibuang untuk subscriber yang terlalu lambat membaca
+const subscriberBuffer = 64
+
+// SubmissionEvent adalah perubahan status / hasil test case satu submission
+type SubmissionEvent struct {
+    Type         string                 `json:"type"`
+    SubmissionID int                    `json:"submission_id"`
+    Status       string                 `json:"status,omitempty"`
+    TestResult   *models.TestCaseResult `json:"test_result,omitempty"`
+    Submission   *models.Submission     `json:"submission,omitempty"` // Hanya di status akhir (graded/failed)
+}
+
+// SubmissionBroker meneruskan event submission dari worker ke subscriber SSE
+type SubmissionBroker struct {
+    mu          sync.Mutex
+    subscribers map[int]map[chan SubmissionEvent]struct{}
+}
+
+// NewSubmissionBroker membuat broker kosong
+func NewSubmissionBroker() *SubmissionBroker {
+    return &SubmissionBroker{
+        subscribers: make(map[int]map[chan SubmissionEvent]struct{}),
+    }
+}
+
+// Subscribe mendaftarkan subscriber untuk satu submission
+// Panggil fungsi unsubscribe yang dikembalikan setelah selesai
+func (b *SubmissionBroker) Subscribe(submissionID int) (<-chan SubmissionEvent, func()) {
+    ch := make(chan SubmissionEvent, subscriberBuffer)
+
+    b.mu.Lock()
+    if b.subscribers[submissionID] == nil {
+        b.subscribers[submissionID] = make(map[chan SubmissionEvent]struct{})
+    }
+    b.subscribers[submissionID][ch] = struct{}{}
+    b.mu.Unlock()
+
+    var once sync.Once
+    unsubscribe := func() {
+        once.Do(func() {
+            b.mu.Lock()
+            defer b.mu.Unlock()
+            delete(b.subscribers[submissionID], ch)
+            if len(b.subscribers[submissionID]) == 0 {
+                delete(b.subscribers, submissionID)
+            }
+        })
+    }
+
+    return ch, unsubscribe
+}
+
+// Publish mengirim event ke semua subscriber submission (non-blocking)
+func (b *SubmissionBroker) Publish(event SubmissionEvent) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    for ch := range b.subscribers[event.SubmissionID] {
+        select {
+        case ch <- event:
+        default:
+        }
+    }
+}
+
+// IsSubmissionFinished mengecek apakah status submission sudah final (graded/failed)
+func IsSubmissionFinished(status string) bool {
+    return status == models.SubmissionStatusGraded || status == models.SubmissionStatusFailed
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+    "context"
+    "errors"
+    "strings"
+
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+    "primmfy_db/models"
+)
+
+// ═══════════════════════════════════════════════════════════
+// SUBMISSION REVIEW
+// Teacher (owner / collaborator lesson) membaca attempt student yang enroll
+// lesson dan memberi feedback: umum atau per baris code (line_number)
+// Student melihat feedback di GET /api/stages/:id/my-completion
+// ═══════════════════════════════════════════════════════════
+
+// feedbackColumns adalah kolom untuk scanFeedback
+const feedbackColumns = `
+    sf.id, sf.attempt_id, sf.author_id, COALESCE(u.full_name, ''),
+    sf.body, sf.line_number, sf.created_at, sf.updated_at`
+
+// feedbackJoins adalah FROM + JOIN untuk feedbackColumns
+const feedbackJoins = `
+    FROM submission_feedback sf
+    LEFT JOIN users u ON sf.author_id = u.id`
+
+// scanFeedback membaca satu baris feedbackColumns
+func scanFeedback(row pgx.Row) (*models.SubmissionFeedback, error) {
+    var feedback models.SubmissionFeedback
+    err := row.Scan(
+        &feedback.ID, &feedback.AttemptID, &feedback.AuthorID, &feedback.AuthorName,
+        &feedback.Body, &feedback.LineNumber, &feedback.CreatedAt, &feedback.UpdatedAt)
+    if err != nil {
+        return nil, err
+    }
+    return &feedback, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// SUBMISSION LIST & DETAIL (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// GetStageSubmissions mengambil ringkasan submission semua student di stage
+// (hanya student yang enroll lesson & sudah submit, terbaru di atas)
+func GetStageSubmissions(ctx context.Context, db *pgxpool.Pool, actor Actor, stageID int) ([]models.StageSubmissionSummary, error) {
+    if err := Authorize(ctx, db, actor, PermSubmissionViewAll, Resource{ResourceStage, stageID}); err != nil {
+        return nil, err
+    }
+
+    rows, err := db.Query(ctx, `
+        WITH attempts AS (
+            SELECT user_id, COUNT(*) AS attempts, MAX(id) AS latest_id, MAX(score) AS best_score,
+                   BOOL_OR(is_late) AS has_late, MAX(submitted_at) AS last_submitted_at
+            FROM stage_attempts
+            WHERE stage_id = $1
+            GROUP BY user_id
+        ),
+        feedback AS (
+            SELECT sa.user_id, COUNT(*) AS feedback_count
+            FROM submission_feedback sf
+            JOIN stage_attempts sa ON sf.attempt_id = sa.id
+            WHERE sa.stage_id = $1
+            GROUP BY sa.user_id
+        )
+        SELECT u.id, u.full_name, u.email, a.attempts, a.latest_id,
+               CASE rr.status
+                   WHEN 'approved' THEN 'correct'
+                   WHEN 'rejected' THEN 'incorrect'
+                   ELSE latest.verdict
+               END,
+               a.best_score,
+               COALESCE(usc.is_completed, false), a.has_late, COALESCE(f.feedback_count, 0),
+               a.last_submitted_at
+        FROM attempts a
+        JOIN users u ON a.user_id = u.id AND u.role = 'student'
+        JOIN stage_attempts latest ON latest.id = a.latest_id
+        LEFT JOIN reflection_reviews rr ON rr.attempt_id = a.latest_id
+        JOIN user_lessons ul ON ul.user_id = a.user_id AND ul.lesson_id = (
+            SELECT c.lesson_id FROM primm_stages ps JOIN courses c ON ps.course_id = c.id WHERE ps.id = $1
+        )
+        LEFT JOIN user_stage_completions usc ON usc.user_id = a.user_id AND usc.stage_id = $1
+        LEFT JOIN feedback f ON f.user_id = a.user_id
+        ORDER BY a.last_submitted_at DESC, u.id ASC`, stageID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil submissions: " + err.Error())
+    }
+    defer rows.Close()
+
+    submissions := []models.StageSubmissionSummary{}
+    for rows.Next() {
+        var submission models.StageSubmissionSummary
+        err := rows.Scan(
+            &submission.UserID, &submission.FullName, &submission.Email, &submission.Attempts,
+            &submission.LatestAttemptID, &submission.LatestVerdict, &submission.BestScore,
+            &submission.IsCompleted, &submission.HasLateAttempt, &submission.FeedbackCount,
+            &submission.LastSubmittedAt)
+        if err != nil {
+            return nil, errors.New("gagal scan submission: " + err.Error())
+        }
+        submissions = append(submissions, submission)
+    }
+
+    return submissions, nil
+}
+
+// GetStudentSubmissions mengambil semua attempt satu student di stage beserta feedback-nya
+func GetStudentSubmissions(ctx context.Context, db *pgxpool.Pool, actor Actor, stageID int, userID int) (*models.StudentSubmissions, error) {
+    if err := Authorize(ctx, db, actor, PermSubmissionViewAll, Resource{ResourceStage, stageID}); err != nil {
+        return nil, err
+    }
+
+    result := &models.StudentSubmissions{StageID: stageID, UserID: userID}
+    err := db.QueryRow(ctx, `
+        SELECT ps.stage_type, u.full_name, u.email
+        FROM primm_stages ps
+        JOIN courses c ON ps.course_id = c.id
+        JOIN user_lessons ul ON ul.lesson_id = c.lesson_id
+        JOIN users u ON ul.user_id = u.id
+        WHERE ps.id = $1 AND u.id = $2`,
+        stageID, userID).Scan(&result.StageType, &result.FullName, &result.Email)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("student tidak terdaftar di lesson ini")
+        }
+        return nil, errors.New("gagal mengambil student: " + err.Error())
+    }
+
+    attempts, err := GetStageAttempts(ctx, db, stageID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    feedbacks, err := getStageFeedback(ctx, db, stageID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    index := make(map[int]int, len(attempts)) // attempt_id -> index di attempts
+    for i, attempt := range attempts {
+        index[attempt.ID] = i
+    }
+    for _, feedback := range feedbacks {
+        if i, ok := index[feedback.AttemptID]; ok {
+            attempts[i].Feedback = append(attempts[i].Feedback, feedback)
+        }
+    }
+    result.Attempts = attempts
+
+    return result, nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// FEEDBACK (Teacher)
+// ═══════════════════════════════════════════════════════════
+
+// AddSubmissionFeedback menambah feedback ke satu attempt
+// line_number (opsional) harus menunjuk baris yang ada di jawaban student
+// Hanya attempt student yang terdaftar di lesson (sama dengan GetStudentSubmissions)
+func AddSubmissionFeedback(ctx context.Context, db *pgxpool.Pool, actor Actor, attemptID int, req models.CreateFeedbackRequest) (*models.SubmissionFeedback, error) {
+    var stageID int
+    var answer string
+    var enrolled bool
+    err := db.QueryRow(ctx, `
+        SELECT sa.stage_id, sa.answer, EXISTS (
+            SELECT 1 FROM primm_stages ps
+            JOIN courses c ON ps.course_id = c.id
+            JOIN user_lessons ul ON ul.lesson_id = c.lesson_id
+            WHERE ps.id = sa.stage_id AND ul.user_id = sa.user_id
+        )
+        FROM stage_attempts sa
+        WHERE sa.id = $1`,
+        attemptID).Scan(&stageID, &answer, &enrolled)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("attempt tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil attempt: " + err.Error())
+    }
+
+    if err := Authorize(ctx, db, actor, PermSubmissionFeedback, Resource{ResourceStage, stageID}); err != nil {
+        return nil, err
+    }
+
+    if !enrolled {
+        return nil, errors.New("student tidak terdaftar di lesson ini")
+    }
+
+    if req.LineNumber != nil && *req.LineNumber > strings.Count(answer, "\n")+1 {
+        return nil, errors.New("line_number melebihi jumlah baris submission")
+    }
+
+    var feedbackID int
+    err = db.QueryRow(ctx, `
+        INSERT INTO submission_feedback (attempt_id, author_id, body, line_number)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id`,
+        attemptID, actor.UserID, strings.TrimSpace(req.Body), req.LineNumber).Scan(&feedbackID)
+
+    if err != nil {
+        return nil, errors.New("gagal menyimpan feedback: " + err.Error())
+    }
+
+    return getFeedback(ctx, db, feedbackID)
+}
+
+// UpdateSubmissionFeedback mengubah isi feedback (hanya penulisnya, admin bisa semua)
+func UpdateSubmissionFeedback(ctx context.Context, db *pgxpool.Pool, actor Actor, feedbackID int, req models.UpdateFeedbackRequest) (*models.SubmissionFeedback, error) {
+    if err := authorizeFeedbackAuthor(ctx, db, actor, feedbackID); err != nil {
+        return nil, err
+    }
+
+    _, err := db.Exec(ctx, "UPDATE submission_feedback SET body = $1 WHERE id = $2", strings.TrimSpace(req.Body), feedbackID)
+    if err != nil {
+        return nil, errors.New("gagal update feedback: " + err.Error())
+    }
+
+    return getFeedback(ctx, db, feedbackID)
+}
+
+// DeleteSubmissionFeedback menghapus feedback (hanya penulisnya, admin bisa semua)
+func DeleteSubmissionFeedback(ctx context.Context, db *pgxpool.Pool, actor Actor, feedbackID int) error {
+    if err := authorizeFeedbackAuthor(ctx, db, actor, feedbackID); err != nil {
+        return err
+    }
+
+    _, err := db.Exec(ctx, "DELETE FROM submission_feedback WHERE id = $1", feedbackID)
+    if err != nil {
+        return errors.New("gagal menghapus feedback: " + err.Error())
+    }
+
+    return nil
+}
+
+// ═══════════════════════════════════════════════════════════
+// FEEDBACK (Student)
+// ═══════════════════════════════════════════════════════════
+
+// GetMyStageFeedback mengambil feedback teacher untuk semua attempt user di stage
+func GetMyStageFeedback(ctx context.Context, db *pgxpool.Pool, userID int, stageID int) ([]models.SubmissionFeedback, error) {
+    return getStageFeedback(ctx, db, stageID, userID)
+}
+
+// ═══════════════════════════════════════════════════════════
+// HELPER FUNCTIONS
+// ═══════════════════════════════════════════════════════════
+
+// getFeedback mengambil satu feedback
+func getFeedback(ctx context.Context, db *pgxpool.Pool, feedbackID int) (*models.SubmissionFeedback, error) {
+    feedback, err := scanFeedback(db.QueryRow(ctx, "SELECT "+feedbackColumns+feedbackJoins+" WHERE sf.id = $1", feedbackID))
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return nil, errors.New("feedback tidak ditemukan")
+        }
+        return nil, errors.New("gagal mengambil feedback: " + err.Error())
+    }
+    return feedback, nil
+}
+
+// getStageFeedback mengambil feedback semua attempt user di stage
+// (urut per attempt, feedback umum dulu lalu per baris)
+func getStageFeedback(ctx context.Context, db *pgxpool.Pool, stageID int, userID int) ([]models.SubmissionFeedback, error) {
+    rows, err := db.Query(ctx, "SELECT "+feedbackColumns+feedbackJoins+`
+        JOIN stage_attempts sa ON sf.attempt_id = sa.id
+        WHERE sa.stage_id = $1 AND sa.user_id = $2
+        ORDER BY sf.attempt_id ASC, sf.line_number ASC NULLS FIRST, sf.id ASC`,
+        stageID, userID)
+
+    if err != nil {
+        return nil, errors.New("gagal mengambil feedback: " + err.Error())
+    }
+    defer rows.Close()
+
+    feedbacks := []models.SubmissionFeedback{}
+    for rows.Next() {
+        feedback, err := scanFeedback(rows)
+        if err != nil {
+            return nil, errors.New("gagal scan feedback: " + err.Error())
+        }
+        feedbacks = append(feedbacks, *feedback)
+    }
+
+    return feedbacks, nil
+}
+
+// authorizeFeedbackAuthor mengecek akses ke stage feedback & bahwa actor penulisnya
+func authorizeFeedbackAuthor(ctx context.Context, db *pgxpool.Pool, actor Actor, feedbackID int) error {
+    var stageID int
+    var authorID *int
+    err := db.QueryRow(ctx, `
+        SELECT sa.stage_id, sf.author_id
+        FROM submission_feedback sf
+        JOIN stage_attempts sa ON sf.attempt_id = sa.id
+        WHERE sf.id = $1`, feedbackID).Scan(&stageID, &authorID)
+
+    if err != nil {
+        if err == pgx.ErrNoRows {
+            return errors.New("feedback tidak ditemukan")
+        }
+        return errors.New("gagal mengambil feedback: " + err.Error())
+    }
+
+    if err := Authorize(ctx, db, actor, PermSubmissionFeedback, Resource{ResourceStage, stageID}); err != nil {
+        return err
+    }
+
+    if (authorID == nil || *authorID != actor.UserID) && !HasPermission(actor.Role, PermLessonManageAny) {
+        return &PermissionDeniedError{Permission: PermSubmissionFeedback}
+    }
+
+    return nil
+}
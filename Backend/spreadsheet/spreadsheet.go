@@ -0,0 +1,123 @@
+package spreadsheet
+
+import (
+    "encoding/csv"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ═══════════════════════════════════════════════════════════
+// SPREADSHEET
+// Writer baris per baris untuk export (CSV & XLSX) tanpa menyimpan
+// seluruh file di memory: setiap baris langsung ditulis ke io.Writer
+// Nilai: string (teks), int / float64 (angka), time.Time, *time.Time, nil (kosong)
+// ═══════════════════════════════════════════════════════════
+
+// Writer menulis satu sheet baris per baris
+type Writer interface {
+    WriteRow(values ...interface{}) error
+    // Flush mengirim baris yang masih di buffer ke io.Writer
+    // (dan ke client jika io.Writer adalah http.ResponseWriter)
+    Flush() error
+    // Close menutup file (XLSX: menulis penutup sheet & zip)
+    Close() error
+}
+
+// timeLayout adalah format waktu di CSV & XLSX (teks, tanpa zona waktu)
+const timeLayout = "2006-01-02 15:04:05"
+
+// flusher adalah io.Writer yang bisa di-flush (http.ResponseWriter / gin.ResponseWriter)
+type flusher interface {
+    Flush()
+}
+
+// flushOutput mem-flush io.Writer jika didukung
+func flushOutput(w io.Writer) {
+    if f, ok := w.(flusher); ok {
+        f.Flush()
+    }
+}
+
+// CSVWriter menulis baris ke CSV (RFC 4180)
+type CSVWriter struct {
+    out io.Writer
+    w   *csv.Writer
+}
+
+// NewCSVWriter membuat CSVWriter yang menulis ke w
+func NewCSVWriter(w io.Writer) *CSVWriter {
+    return &CSVWriter{out: w, w: csv.NewWriter(w)}
+}
+
+// WriteRow menulis satu baris CSV
+// Teks diberi prefix ' agar tidak dieksekusi sebagai formula (lihat escapeFormula)
+func (c *CSVWriter) WriteRow(values ...interface{}) error {
+    record := make([]string, len(values))
+    for i, value := range values {
+        record[i] = formatValue(value)
+        if text, ok := value.(string); ok {
+            record[i] = escapeFormula(text)
+        }
+    }
+    return c.w.Write(record)
+}
+
+// escapeFormula memberi prefix ' pada teks yang bisa dibaca spreadsheet sebagai formula:
+// diawali tab / carriage return, atau karakter pertama selain spasi adalah = + - @
+func escapeFormula(text string) string {
+    if text == "" {
+        return text
+    }
+    if text[0] == '\t' || text[0] == '\r' {
+        return "'" + text
+    }
+    if trimmed := strings.TrimLeft(text, " "); trimmed != "" && strings.ContainsRune("=+-@", rune(trimmed[0])) {
+        return "'" + text
+    }
+    return text
+}
+
+// Flush mengirim buffer CSV ke io.Writer
+func (c *CSVWriter) Flush() error {
+    c.w.Flush()
+    if err := c.w.Error(); err != nil {
+        return err
+    }
+    flushOutput(c.out)
+    return nil
+}
+
+// Close sama dengan Flush (CSV tidak punya penutup)
+func (c *CSVWriter) Close() error {
+    return c.Flush()
+}
+
+// formatValue mengubah nilai cell menjadi teks
+func formatValue(value interface{}) string {
+    switch v := value.(type) {
+    case nil:
+        return ""
+    case string:
+        return v
+    case int:
+        return strconv.Itoa(v)
+    case float64:
+        return strconv.FormatFloat(v, 'f', -1, 64)
+    case bool:
+        if v {
+            return "ya"
+        }
+        return "tidak"
+    case time.Time:
+        return v.Format(timeLayout)
+    case *time.Time:
+        if v == nil {
+            return ""
+        }
+        return v.Format(timeLayout)
+    default:
+        return ""
+    }
+}
@@ -0,0 +1,87 @@
+package spreadsheet
+
+import (
+    "testing"
+    "time"
+)
+
+func TestEscapeFormula(t *testing.T) {
+    cases := []struct {
+        input string
+        want  string
+    }{
+        {"", ""},
+        {"Budi", "Budi"},
+        {"=SUM(A1:A2)", "'=SUM(A1:A2)"},
+        {"+62812", "'+62812"},
+        {"-1+1", "'-1+1"},
+        {"@cmd", "'@cmd"},
+        {"   =1+1", "'   =1+1"},
+        {"\t=1+1", "'\t=1+1"},
+        {"\rdata", "'\rdata"},
+        {"   ", "   "},
+        {"a=b", "a=b"},
+        {"email@sekolah.sch.id", "email@sekolah.sch.id"},
+    }
+
+    for _, tc := range cases {
+        if got := escapeFormula(tc.input); got != tc.want {
+            t.Errorf("escapeFormula(%q) = %q, want %q", tc.input, got, tc.want)
+        }
+    }
+}
+
+func TestFormatValue(t *testing.T) {
+    submitted := time.Date(2024, 3, 9, 7, 5, 0, 0, time.UTC)
+    var noTime *time.Time
+
+    cases := []struct {
+        name  string
+        value interface{}
+        want  string
+    }{
+        {"nil", nil, ""},
+        {"string", "halo", "halo"},
+        {"int", 42, "42"},
+        {"int negatif", -7, "-7"},
+        {"float", 87.5, "87.5"},
+        {"float bulat", 100.0, "100"},
+        {"float kecil tanpa eksponen", 0.000001, "0.000001"},
+        {"bool true", true, "ya"},
+        {"bool false", false, "tidak"},
+        {"time", submitted, "2024-03-09 07:05:00"},
+        {"pointer time", &submitted, "2024-03-09 07:05:00"},
+        {"pointer time nil", noTime, ""},
+        {"tipe lain", []int{1}, ""},
+    }
+
+    for _, tc := range cases {
+        if got := formatValue(tc.value); got != tc.want {
+            t.Errorf("%s: formatValue(%v) = %q, want %q", tc.name, tc.value, got, tc.want)
+        }
+    }
+}
+
+func TestColumnName(t *testing.T) {
+    cases := []struct {
+        index int
+        want  string
+    }{
+        {0, "A"},
+        {1, "B"},
+        {25, "Z"},
+        {26, "AA"},
+        {27, "AB"},
+        {51, "AZ"},
+        {52, "BA"},
+        {701, "ZZ"},
+        {702, "AAA"},
+        {16383, "XFD"},
+    }
+
+    for _, tc := range cases {
+        if got := columnName(tc.index); got != tc.want {
+            t.Errorf("columnName(%d) = %q, want %q", tc.index, got, tc.want)
+        }
+    }
+}
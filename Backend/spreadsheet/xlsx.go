@@ -0,0 +1,193 @@
+package spreadsheet
+
+import (
+    "archive/zip"
+    "bytes"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// ═══════════════════════════════════════════════════════════
+// XLSX (Office Open XML) minimal: satu sheet, string inline (tanpa sharedStrings)
+// sehingga sheet bisa ditulis langsung ke zip baris per baris
+// Baris pertama dianggap header (bold & di-freeze)
+// ═══════════════════════════════════════════════════════════
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// xlsxStyles: style 0 = normal, style 1 = bold (header)
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+<sheetData>`
+
+const xlsxSheetEnd = `</sheetData>
+</worksheet>`
+
+// XLSXWriter menulis satu sheet XLSX langsung ke io.Writer
+type XLSXWriter struct {
+    out   io.Writer
+    zw    *zip.Writer
+    sheet io.Writer // Entry xl/worksheets/sheet1.xml (masih terbuka)
+    rows  int
+    buf   bytes.Buffer
+}
+
+// NewXLSXWriter menulis bagian statis workbook lalu membuka entry sheet
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+    x := &XLSXWriter{out: w, zw: zip.NewWriter(w)}
+
+    var name bytes.Buffer
+    xml.EscapeText(&name, []byte(cleanSheetName(sheetName)))
+
+    parts := []struct {
+        Name    string
+        Content string
+    }{
+        {"[Content_Types].xml", xlsxContentTypes},
+        {"_rels/.rels", xlsxRootRels},
+        {"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+        {"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+        {"xl/styles.xml", xlsxStyles},
+    }
+    for _, part := range parts {
+        f, err := x.zw.Create(part.Name)
+        if err != nil {
+            return nil, errors.New("gagal menulis xlsx: " + err.Error())
+        }
+        if _, err := io.WriteString(f, part.Content); err != nil {
+            return nil, errors.New("gagal menulis xlsx: " + err.Error())
+        }
+    }
+
+    // Sheet harus entry terakhir: zip hanya bisa menulis satu entry dalam satu waktu
+    sheet, err := x.zw.Create("xl/worksheets/sheet1.xml")
+    if err != nil {
+        return nil, errors.New("gagal menulis xlsx: " + err.Error())
+    }
+    if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+        return nil, errors.New("gagal menulis xlsx: " + err.Error())
+    }
+    x.sheet = sheet
+
+    return x, nil
+}
+
+// WriteRow menulis satu baris <row> ke sheet
+func (x *XLSXWriter) WriteRow(values ...interface{}) error {
+    x.rows++
+    rowNumber := strconv.Itoa(x.rows)
+
+    x.buf.Reset()
+    x.buf.WriteString(`<row r="` + rowNumber + `">`)
+    for i, value := range values {
+        ref := columnName(i) + rowNumber
+        style := ""
+        if x.rows == 1 {
+            style = ` s="1"`
+        }
+
+        switch v := value.(type) {
+        case nil:
+            continue
+        case int:
+            x.buf.WriteString(`<c r="` + ref + `"` + style + `><v>` + strconv.Itoa(v) + `</v></c>`)
+        case float64:
+            x.buf.WriteString(`<c r="` + ref + `"` + style + `><v>` + strconv.FormatFloat(v, 'f', -1, 64) + `</v></c>`)
+        default:
+            text := formatValue(value)
+            if text == "" {
+                continue
+            }
+            x.buf.WriteString(`<c r="` + ref + `"` + style + ` t="inlineStr"><is><t xml:space="preserve">`)
+            xml.EscapeText(&x.buf, []byte(text))
+            x.buf.WriteString(`</t></is></c>`)
+        }
+    }
+    x.buf.WriteString(`</row>`)
+
+    _, err := x.sheet.Write(x.buf.Bytes())
+    return err
+}
+
+// Flush mengirim data zip yang sudah di-compress ke io.Writer
+func (x *XLSXWriter) Flush() error {
+    if err := x.zw.Flush(); err != nil {
+        return err
+    }
+    flushOutput(x.out)
+    return nil
+}
+
+// Close menutup sheet & menulis central directory zip
+func (x *XLSXWriter) Close() error {
+    if _, err := io.WriteString(x.sheet, xlsxSheetEnd); err != nil {
+        return err
+    }
+    return x.zw.Close()
+}
+
+// columnName mengubah index kolom (0-based) menjadi nama kolom Excel (A, B, ..., Z, AA, ...)
+func columnName(index int) string {
+    name := ""
+    for index >= 0 {
+        name = string(rune('A'+index%26)) + name
+        index = index/26 - 1
+    }
+    return name
+}
+
+// cleanSheetName membuang karakter yang tidak boleh ada di nama sheet (maks 31 karakter)
+func cleanSheetName(name string) string {
+    name = strings.Map(func(r rune) rune {
+        if strings.ContainsRune(`[]:*?/\`, r) {
+            return '-'
+        }
+        return r
+    }, name)
+
+    if runes := []rune(name); len(runes) > 31 {
+        name = string(runes[:31])
+    }
+    if strings.TrimSpace(name) == "" {
+        name = "Sheet1"
+    }
+    return name
+}